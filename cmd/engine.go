@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// engineFlags são as opções de origem compartilhadas por eval e serve.
+type engineFlags struct {
+	policies       string
+	requestSchema  string
+	responseSchema string
+	inputType      string
+}
+
+func (f *engineFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.policies, "policies", "", "origem do arquivo de políticas (caminho, s3:// ou ssm://)")
+	fs.StringVar(&f.requestSchema, "schema", "", "origem do JSON Schema da requisição (opcional)")
+	fs.StringVar(&f.responseSchema, "response-schema", "", "origem do JSON Schema da resposta (opcional)")
+	fs.StringVar(&f.inputType, "input-type", "Local", "tipo de entrada informado ao motor ($meta.inputType)")
+}
+
+// engine carrega schemas, políticas e tabelas de referência das origens informadas e monta
+// o contexto do motor. Chamadas de função inválidas nas regras impedem a carga.
+func (f *engineFlags) engine() (*core.EngineContext, error) {
+	if f.policies == "" {
+		return nil, fmt.Errorf("a opção -policies é obrigatória")
+	}
+
+	policyLoader, err := policy.NewLoader(f.policies)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := policyLoader.Load()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao carregar políticas de '%s': %v", f.policies, err)
+	}
+
+	reqSchema, err := loadSchema(f.requestSchema)
+	if err != nil {
+		return nil, err
+	}
+	respSchema, err := loadSchema(f.responseSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	ec := core.NewEngineContext(reqSchema, respSchema, catalog.Policies, f.inputType)
+	ec.PolicySets = catalog.Sets
+	ec.Expressions = catalog.Expressions
+	ec.Tenants = catalog.Tenants
+	ec.TableSources = catalog.Tables
+	if err := ec.CheckFunctions(); err != nil {
+		return nil, fmt.Errorf("políticas de '%s' inválidas: %v", f.policies, err)
+	}
+	if err := ec.ReloadTables(); err != nil {
+		return nil, err
+	}
+	return ec, nil
+}
+
+// loadSchema carrega um schema opcional; origem vazia resulta em nil.
+func loadSchema(source string) (*schema.Schema, error) {
+	if source == "" {
+		return nil, nil
+	}
+	schemaLoader, err := schema.NewLoader(source)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := schemaLoader.Load()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao carregar schema de '%s': %v", source, err)
+	}
+	return sch, nil
+}
+
+// newFlagSet cria o conjunto de opções de um subcomando escrevendo a ajuda em stderr.
+func (c *cli) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "uso: policy %s [opções] %s\n\nopções:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags interpreta as opções; retorna o código de saída quando a execução deve parar.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+)
+
+// runEval processa uma requisição (arquivo ou entrada padrão) e imprime a resposta em JSON.
+func runEval(c *cli, args []string) int {
+	var (
+		source  engineFlags
+		explain bool
+		compact bool
+	)
+	fs := c.newFlagSet("eval", "[requisicao.json]")
+	source.register(fs)
+	fs.BoolVar(&explain, "explain", false, "inclui o trace de avaliação na resposta")
+	fs.BoolVar(&compact, "compact", false, "imprime o JSON sem indentação")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	ec, err := source.engine()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	body, err := c.readInput(fs.Args())
+	if err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	var req core.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return c.fail(&core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao desserializar requisição: %v", err)})
+	}
+	req.Explain = req.Explain || explain
+
+	response, err := ec.Process(&req)
+	if err != nil {
+		return c.fail(err)
+	}
+	return c.writeJSON(response, compact)
+}
+
+func (c *cli) writeJSON(v interface{}, compact bool) int {
+	var (
+		out []byte
+		err error
+	)
+	if compact {
+		out, err = json.Marshal(v)
+	} else {
+		out, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return c.fail(fmt.Errorf("falha ao serializar resposta: %v", err))
+	}
+	fmt.Fprintln(c.stdout, string(out))
+	return exitOK
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// runFmt normaliza arquivos de políticas locais (ver policy.Format). Sem arquivos,
+// formata a entrada padrão. Por padrão imprime o resultado; -w reescreve os arquivos,
+// -l lista os que estão fora do formato e -check (para CI) apenas falha se algum estiver.
+func runFmt(c *cli, args []string) int {
+	var write, list, check bool
+	fs := c.newFlagSet("fmt", "[arquivo.yaml...]")
+	fs.BoolVar(&write, "w", false, "reescreve os arquivos com o resultado")
+	fs.BoolVar(&list, "l", false, "lista os arquivos cuja formatação difere")
+	fs.BoolVar(&check, "check", false, "não altera nada; termina com falha se algum arquivo estiver fora do formato")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if check && write {
+		fmt.Fprintln(c.stderr, "erro: -check e -w não podem ser usados juntos")
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		if write {
+			fmt.Fprintln(c.stderr, "erro: -w exige ao menos um arquivo")
+			return exitUsage
+		}
+		content, err := io.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+		formatted, err := policy.Format(content)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "<stdin>: %v\n", err)
+			return exitFailure
+		}
+		if check {
+			if !bytes.Equal(content, formatted) {
+				fmt.Fprintln(c.stderr, "<stdin>: fora do formato canônico")
+				return exitFailure
+			}
+			return exitOK
+		}
+		c.stdout.Write(formatted)
+		return exitOK
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			code = exitUsage
+			continue
+		}
+		formatted, err := policy.Format(content)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "%s: %v\n", path, err)
+			code = exitFailure
+			continue
+		}
+
+		changed := !bytes.Equal(content, formatted)
+		if list && changed {
+			fmt.Fprintln(c.stdout, path)
+		}
+		if write && changed {
+			if err := os.WriteFile(path, formatted, 0o644); err != nil {
+				fmt.Fprintf(c.stderr, "erro: %v\n", err)
+				code = exitUsage
+			}
+		}
+		if check && changed {
+			fmt.Fprintf(c.stderr, "%s: fora do formato canônico\n", path)
+			if code == exitOK {
+				code = exitFailure
+			}
+		}
+		if !write && !list && !check {
+			c.stdout.Write(formatted)
+		}
+	}
+	return code
+}
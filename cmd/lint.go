@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core/loader"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// runLint analisa cada arquivo de políticas sem executá-lo (ver policy.Lint) e lista os
+// problemas com linha e coluna. Com -schema e -response-schema, verifica também os caminhos
+// e tipos das regras contra os schemas da requisição e da resposta.
+func runLint(c *cli, args []string) int {
+	var requestSchema, responseSchema string
+	fs := c.newFlagSet("lint", "<origem>...")
+	fs.StringVar(&requestSchema, "schema", "", "origem do JSON Schema da requisição (opcional)")
+	fs.StringVar(&responseSchema, "response-schema", "", "origem do JSON Schema da resposta (opcional)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var (
+		opts policy.LintOptions
+		err  error
+	)
+	if opts.RequestSchema, err = loadSchema(requestSchema); err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	if opts.ResponseSchema, err = loadSchema(responseSchema); err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	code := exitOK
+	for _, source := range fs.Args() {
+		issues, err := lintSource(source, opts)
+		if err != nil {
+			fmt.Fprintf(c.stdout, "%s: %v\n", source, err)
+			code = exitFailure
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Position.Line > 0 {
+				fmt.Fprintf(c.stdout, "%s:%d:%d: %s\n", source, issue.Position.Line, issue.Position.Column, issue)
+			} else {
+				fmt.Fprintf(c.stdout, "%s: %s\n", source, issue)
+			}
+		}
+		if len(issues) > 0 {
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+func lintSource(source string, opts policy.LintOptions) ([]policy.Issue, error) {
+	content, err := loader.ReadSource(source)
+	if err != nil {
+		return nil, err
+	}
+	return policy.LintContent(content, opts)
+}
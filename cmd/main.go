@@ -1,52 +1,116 @@
+// Comando de linha do motor de políticas.
+//
+//	policy eval     -policies <origem> [-schema <origem>] [requisicao.json]
+//	policy validate -schema <origem> [dados.json]
+//	policy lint     <origem>...
+//	policy fmt      [-w] [-l] [-check] [arquivo.yaml...]
+//	policy test     [-format text|junit|tap] [-o relatorio] <testes.yaml>...
+//	policy serve    -policies <origem> [-schema <origem>] [-addr :8080]
+//	policy repl     [-policies <origem>] [dados.json]
+//
+// As origens de schema e políticas aceitam caminho local, "s3://bucket/chave" ou "ssm://parametro".
+// Sem arquivo, a entrada é lida da entrada padrão.
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"os"
 
 	"github.com/raywall/cloud-policy-serializer/pkg/core"
-	"github.com/raywall/cloud-policy-serializer/pkg/utils"
 )
 
-// --- Main (Exemplo de Uso) ---
+// Códigos de saída do comando
+const (
+	exitOK       = 0
+	exitFailure  = 1 // Política reprovada, testes falhando, problemas de lint ou arquivos fora do formato
+	exitUsage    = 2 // Argumentos inválidos ou origem de schema/políticas inacessível
+	exitRequest  = 3 // Requisição malformada
+	exitSchema   = 4 // Dados não conformes ao schema
+	exitInternal = 5 // Falha inesperada do motor
+)
+
+// cli reúne a entrada e as saídas usadas pelos subcomandos.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	summary string
+	run     func(c *cli, args []string) int
+}
+
+var commands = map[string]command{
+	"eval":     {summary: "processa uma requisição e imprime a resposta", run: runEval},
+	"validate": {summary: "valida dados contra o schema da requisição", run: runValidate},
+	"lint":     {summary: "verifica arquivos de políticas", run: runLint},
+	"fmt":      {summary: "normaliza arquivos de políticas", run: runFmt},
+	"test":     {summary: "executa testes de políticas descritos em YAML", run: runTest},
+	"serve":    {summary: "inicia o servidor HTTP do motor", run: runServe},
+	"repl":     {summary: "avalia regras interativamente sobre um documento JSON", run: runRepl},
+}
+
 func main() {
-	// Definir Schemas (simplificado)
-	reqSchemaPath := utils.FilePath("./examples/request_schema.json")
-	reqSchema, err := reqSchemaPath.GetSchema()
-	if err != nil {
-		panic(err)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		c.usage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
 	}
 
-	respSchemaPath := utils.FilePath("./examples/response_schema.json")
-	respSchema, err := respSchemaPath.GetSchema()
-	if err != nil {
-		panic(err)
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "subcomando desconhecido: '%s'\n\n", args[0])
+		c.usage()
+		return exitUsage
 	}
+	return cmd.run(c, args[1:])
+}
 
-	// Definir Políticas
-	policiesPath := utils.FilePath("./examples/policy.yaml")
-	policies, err := policiesPath.GetPolicies()
-	if err != nil {
-		panic(err)
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "uso: policy <subcomando> [opções]")
+	fmt.Fprintln(c.stderr, "\nsubcomandos:")
+	for _, name := range sortedKeys(commands) {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", name, commands[name].summary)
 	}
+	fmt.Fprintln(c.stderr, "\nuse 'policy <subcomando> -h' para ver as opções")
+}
 
-	// Criar Contexto do Motor
-	engine := core.NewEngineContext(reqSchema, respSchema, *policies, "Local")
+// fail imprime o erro e retorna o código de saída correspondente à classificação do erro do motor.
+func (c *cli) fail(err error) int {
+	kind := core.KindOf(err)
+	fmt.Fprintf(c.stderr, "erro (%s): %v\n", kind, err)
+	return exitCode(kind)
+}
 
-	// Exemplo de Requisição
-	requestBody, err := ioutil.ReadFile("./examples/request_data.json")
-	if err != nil {
-		panic(err)
+func exitCode(kind core.ErrorKind) int {
+	switch kind {
+	case core.ErrorKindPolicy:
+		return exitFailure
+	case core.ErrorKindRequest:
+		return exitRequest
+	case core.ErrorKindSchema:
+		return exitSchema
+	default:
+		return exitInternal
 	}
+}
 
-	fmt.Println("--- Processando Requisição (Válida) ---")
-	response, err := engine.ProcessRequest(requestBody)
-	if err != nil {
-		fmt.Printf("Erro:\n%v\n", err)
-	} else {
-		respBytes, _ := json.MarshalIndent(response, "", "  ")
-		fmt.Printf("Resposta:\n%s\n", string(respBytes))
+// readInput lê o arquivo informado ou, sem arquivo (ou com "-"), a entrada padrão.
+func (c *cli) readInput(args []string) ([]byte, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("esperado no máximo um arquivo de entrada, recebidos %d", len(args))
+	}
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(c.stdin)
 	}
-	fmt.Println("\n--- Fim Requisição ---")
+	return os.ReadFile(args[0])
 }
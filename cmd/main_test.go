@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	examplePolicies = "../examples/policy.yaml"
+	exampleSchema   = "../examples/request_schema.json"
+	exampleRequest  = "../examples/request_data.json"
+)
+
+func runCLI(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected int
+	}{
+		{name: "sem subcomando", args: nil, expected: exitUsage},
+		{name: "subcomando desconhecido", args: []string{"compilar"}, expected: exitUsage},
+		{name: "ajuda", args: []string{"eval", "-h"}, expected: exitOK},
+		{name: "eval sem políticas", args: []string{"eval", exampleRequest}, expected: exitUsage},
+		{name: "eval com origem inexistente", args: []string{"eval", "-policies", "inexistente.yaml"}, expected: exitUsage},
+		{name: "eval com sucesso", args: []string{"eval", "-policies", examplePolicies, "-schema", exampleSchema, exampleRequest}, expected: exitOK},
+		{name: "eval com chamada de função inválida", args: []string{"eval", "-policies", "testdata/funcao_invalida.yaml"}, stdin: `{"data":{"nome":"ana"}}`, expected: exitUsage},
+		{name: "eval com requisição malformada", args: []string{"eval", "-policies", examplePolicies}, stdin: `{"data":`, expected: exitRequest},
+		{name: "eval fora do schema", args: []string{"eval", "-policies", examplePolicies, "-schema", exampleSchema}, stdin: `{"data":{"idade":"vinte"},"policies":["ValidarIdade"]}`, expected: exitSchema},
+		{name: "eval com política reprovada", args: []string{"eval", "-policies", examplePolicies, "-"}, stdin: `{"data":{"idade":10},"policies":["ValidarIdade"]}`, expected: exitFailure},
+		{name: "validate sem schema", args: []string{"validate"}, expected: exitUsage},
+		{name: "validate válido", args: []string{"validate", "-schema", "testdata/idade_schema.json"}, stdin: `{"idade":30}`, expected: exitOK},
+		{name: "validate inválido", args: []string{"validate", "-schema", "testdata/idade_schema.json"}, stdin: `{"idade":"trinta"}`, expected: exitSchema},
+		{name: "validate requisição", args: []string{"validate", "-schema", exampleSchema, "-request", exampleRequest}, expected: exitOK},
+		{name: "lint sem problemas", args: []string{"lint", "-schema", exampleSchema, "testdata/lint_ok.yaml"}, expected: exitOK},
+		{name: "lint com schema inexistente", args: []string{"lint", "-schema", "inexistente.json", "testdata/lint_ok.yaml"}, expected: exitUsage},
+		{name: "test sem arquivos", args: []string{"test"}, expected: exitUsage},
+		{name: "test aprovado", args: []string{"test", "../examples/policy_test.yaml"}, expected: exitOK},
+		{name: "test com formato inválido", args: []string{"test", "-format", "html", "../examples/policy_test.yaml"}, expected: exitUsage},
+		{name: "fmt da entrada padrão", args: []string{"fmt"}, stdin: "A: [$.x > 1]\n", expected: exitOK},
+		{name: "fmt de arquivo inválido", args: []string{"fmt"}, stdin: "A: {dependsOn: [B]}\n", expected: exitFailure},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			code, _, stderr := runCLI(cenario.stdin, cenario.args...)
+			assert.Equal(t, cenario.expected, code, stderr)
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	code, stdout, _ := runCLI("", "eval", "-policies", examplePolicies, "-compact", exampleRequest)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"id":"req-001-response"`)
+	assert.Contains(t, stdout, `"desconto":22.5`)
+
+	code, stdout, _ = runCLI(`{"data":{"idade":30,"tipo":"adulto"},"policies":["ValidarIdade"]}`, "eval", "-policies", examplePolicies, "-explain")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"explain"`)
+}
+
+func TestLint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("A: []\nB:\n- $.x > 1\n- $.x > 1\n- SET $.y = EXP($.x * )\n"), 0o644))
+
+	code, stdout, _ := runCLI("", "lint", path)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stdout, path+":1:1: warning: política 'A': política não possui regras [empty-policy]")
+	assert.Contains(t, stdout, path+":4:3: warning: política 'B', regra 1: regra duplica a regra 0: '$.x > 1' [duplicate-rule]")
+	assert.Contains(t, stdout, path+":5:3: error: política 'B', regra 2: operando ausente na expressão '$.x *'")
+
+	// As políticas de exemplo devem permanecer sem problemas
+	code, stdout, _ = runCLI("", "lint", examplePolicies)
+	assert.Equal(t, exitOK, code, stdout)
+	assert.Empty(t, stdout)
+}
+
+func TestFmt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("A: [\"$.x > 1\"]\n"), 0o644))
+
+	code, stdout, _ := runCLI("", "fmt", "-l", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, path+"\n", stdout)
+
+	code, _, _ = runCLI("", "fmt", "-w", path)
+	assert.Equal(t, exitOK, code)
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "A:\n- $.x > 1\n", string(content))
+
+	_, stdout, _ = runCLI("", "fmt", "-l", path)
+	assert.Empty(t, stdout)
+}
+
+func TestFmtCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("A:\n- if $.x>1 then set $.y = 'a'\n"), 0o644))
+
+	code, stdout, stderr := runCLI("", "fmt", "-check", path)
+	assert.Equal(t, exitFailure, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, path+": fora do formato canônico")
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "A:\n- if $.x>1 then set $.y = 'a'\n", string(content))
+
+	code, _, _ = runCLI("", "fmt", "-w", path)
+	assert.Equal(t, exitOK, code)
+	content, _ = os.ReadFile(path)
+	assert.Equal(t, "A:\n- IF $.x > 1 THEN SET $.y = \"a\"\n", string(content))
+
+	code, _, stderr = runCLI("", "fmt", "--check", path)
+	assert.Equal(t, exitOK, code)
+	assert.Empty(t, stderr)
+
+	code, _, _ = runCLI("A: [$.x>1]\n", "fmt", "-check")
+	assert.Equal(t, exitFailure, code)
+
+	code, _, _ = runCLI("", "fmt", "-check", "-w", path)
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestTest(t *testing.T) {
+	dir := t.TempDir()
+	suite := filepath.Join(dir, "idade_test.yaml")
+	require.NoError(t, os.WriteFile(suite, []byte("tests:\n- name: reprovado\n  policies: [ValidarIdade]\n  data: {idade: 10}\n  expect: {passed: true}\n"), 0o644))
+
+	report := filepath.Join(dir, "junit.xml")
+	code, _, _ := runCLI("", "test", "-policies", examplePolicies, "-format", "junit", "-o", report, suite)
+	assert.Equal(t, exitFailure, code)
+
+	content, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<testsuite name="idade_test.yaml" tests="1" failures="1"`)
+
+	code, _, stderr := runCLI("", "test", suite)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "-policies")
+}
+
+func TestTestCoverage(t *testing.T) {
+	dir := t.TempDir()
+	coverJSON := filepath.Join(dir, "cover.json")
+	coverHTML := filepath.Join(dir, "cover.html")
+
+	code, _, stderr := runCLI("", "test", "-cover", "-cover-json", coverJSON, "-cover-html", coverHTML, "../examples/policy_test.yaml")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "cobertura: regras")
+
+	content, err := os.ReadFile(coverJSON)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"rulesCovered"`)
+
+	content, err = os.ReadFile(coverHTML)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `class="miss"`)
+}
+
+func TestLintSchemas(t *testing.T) {
+	code, stdout, _ := runCLI("", "lint", "-schema", exampleSchema, "-response-schema", "../examples/response_schema.json", examplePolicies)
+	assert.Equal(t, exitOK, code, stdout)
+	assert.Empty(t, stdout)
+
+	policies := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policies, []byte("A:\n- ADD [1] TO $.endereco.estado\n- $.tipo == \"servicos\"\n"), 0o644))
+	code, stdout, _ = runCLI("", "lint", "-schema", exampleSchema, policies)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stdout, "ADD exige um array, mas $.endereco.estado é string no schema da requisição [type-mismatch]")
+	assert.Contains(t, stdout, `"servicos" não está entre os valores permitidos para $.tipo`)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+const (
+	replPrompt      = "policy> "
+	replHistoryFile = ".policy_repl_history"
+)
+
+// replCommands são os comandos do REPL; qualquer outra linha é avaliada como regra.
+var replCommands = map[string]string{
+	":load":  ":load <arquivo.json>  carrega um documento JSON como dados",
+	":show":  ":show [$.caminho]     mostra os dados ou o valor de um caminho",
+	":reset": ":reset                desfaz as alterações e variáveis desde o último :load",
+	":trace": ":trace                liga/desliga a exibição do trace de avaliação",
+	":help":  ":help                 mostra esta ajuda",
+	":quit":  ":quit                 encerra o REPL",
+}
+
+// runRepl inicia o modo interativo: cada linha é avaliada como regra sobre os dados carregados,
+// exibindo o resultado, os detalhes e as alterações nos dados. Com -policies, as expressões
+// nomeadas e as tabelas de referência do arquivo de políticas ficam disponíveis nas regras.
+func runRepl(c *cli, args []string) int {
+	fs := c.newFlagSet("repl", "[dados.json]")
+	source := engineFlags{inputType: "Local"}
+	fs.StringVar(&source.policies, "policies", "", "origem do arquivo de políticas com expressões nomeadas e tabelas (opcional)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	ec := core.NewEngineContext(nil, nil, nil, source.inputType)
+	if source.policies != "" {
+		var err error
+		if ec, err = source.engine(); err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	session := newReplSession(c.stdout, ec)
+	if fs.NArg() == 1 {
+		if err := session.load(fs.Arg(0)); err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	if isTerminal(c.stdin) && liner.TerminalSupported() {
+		return session.interactive()
+	}
+
+	// Entrada não interativa (ex.: redirecionamento de arquivo): uma linha por comando, sem prompt.
+	scanner := bufio.NewScanner(c.stdin)
+	for scanner.Scan() {
+		if session.execute(scanner.Text()) {
+			break
+		}
+	}
+	return exitOK
+}
+
+// replSession guarda os dados carregados e o estado do REPL. O contexto de avaliação é o
+// mesmo entre as linhas, para que variáveis de LET continuem disponíveis, e é recriado no
+// :load e no :reset.
+type replSession struct {
+	out      io.Writer
+	engine   *core.EngineContext
+	original map[string]interface{}
+	data     map[string]interface{}
+	ctx      *rules.Context
+	trace    bool
+}
+
+func newReplSession(out io.Writer, ec *core.EngineContext) *replSession {
+	s := &replSession{
+		out:      out,
+		engine:   ec,
+		original: map[string]interface{}{},
+	}
+	s.restore()
+	return s
+}
+
+// restore volta os dados ao último documento carregado e recria o contexto de avaliação,
+// descartando as variáveis de LET.
+func (s *replSession) restore() {
+	s.data = patch.Clone(s.original).(map[string]interface{})
+	s.ctx = s.engine.NewRuleContext(s.data, "repl", core.ExecutionOptions{})
+}
+
+// interactive lê as linhas com edição, histórico (persistido em ~/.policy_repl_history)
+// e completação de caminhos e comandos com Tab.
+func (s *replSession) interactive() int {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(s.complete)
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, replHistoryFile)
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+
+	fmt.Fprintln(s.out, "REPL de regras. Digite uma regra para avaliá-la ou :help para ver os comandos.")
+	for {
+		input, err := line.Prompt(replPrompt)
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+		if strings.TrimSpace(input) != "" {
+			line.AppendHistory(input)
+		}
+		if s.execute(input) {
+			break
+		}
+	}
+
+	if historyPath != "" {
+		if f, err := os.Create(historyPath); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}
+	return exitOK
+}
+
+// execute processa uma linha e indica se o REPL deve ser encerrado.
+func (s *replSession) execute(input string) bool {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.HasPrefix(input, "#") {
+		return false
+	}
+	if !strings.HasPrefix(input, ":") {
+		s.evaluate(input)
+		return false
+	}
+
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(s.out, "uso: :load <arquivo.json>")
+			return false
+		}
+		if err := s.load(arg); err != nil {
+			fmt.Fprintf(s.out, "erro: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(s.out, "dados carregados de '%s'\n", arg)
+	case ":show":
+		s.show(arg)
+	case ":reset":
+		s.restore()
+		fmt.Fprintln(s.out, "dados restaurados")
+	case ":trace":
+		s.trace = !s.trace
+		fmt.Fprintf(s.out, "trace %s\n", map[bool]string{true: "ligado", false: "desligado"}[s.trace])
+	case ":help":
+		for _, command := range sortedKeys(replCommands) {
+			fmt.Fprintln(s.out, replCommands[command])
+		}
+	case ":quit", ":q", ":exit":
+		return true
+	default:
+		fmt.Fprintf(s.out, "comando desconhecido: '%s' (use :help)\n", name)
+	}
+	return false
+}
+
+// load lê um documento JSON (objeto) e o usa como dados da sessão. Se o documento for uma
+// requisição do motor (com "data" e "policies"), apenas o campo "data" é carregado.
+func (s *replSession) load(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return fmt.Errorf("'%s' não é um objeto JSON válido: %v", path, err)
+	}
+	if requestData, ok := data["data"].(map[string]interface{}); ok && data["policies"] != nil {
+		data = requestData
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	s.original = data
+	s.restore()
+	return nil
+}
+
+func (s *replSession) show(path string) {
+	var value interface{} = s.data
+	if path != "" {
+		var err error
+		if value, err = s.ctx.Lookup(path); err != nil {
+			fmt.Fprintf(s.out, "erro: %v\n", err)
+			return
+		}
+	}
+	out, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Fprintln(s.out, string(out))
+}
+
+// evaluate avalia a regra sobre os dados da sessão e mostra resultado, detalhes e alterações.
+func (s *replSession) evaluate(rule string) {
+	before := patch.Clone(s.data)
+	root := rules.NewTrace(rules.TracePolicy, "repl")
+	passed, details, err := rules.EvaluateRuleWithContext(rule, s.ctx, root)
+
+	if err != nil {
+		fmt.Fprintf(s.out, "erro: %v\n", err)
+	} else {
+		fmt.Fprintf(s.out, "resultado: %v\n", passed)
+	}
+	if details != "" {
+		fmt.Fprintf(s.out, "detalhes: %s\n", details)
+	}
+	if changes := patch.Diff(before, s.data); len(changes) > 0 {
+		fmt.Fprintln(s.out, "alterações:")
+		for _, change := range changes {
+			fmt.Fprintf(s.out, "  %s\n", change)
+		}
+	}
+	if s.trace && len(root.Children) > 0 {
+		fmt.Fprint(s.out, root.Children[0].String())
+	}
+}
+
+// complete completa a palavra sob o cursor: comandos no início da linha e caminhos "$." dos dados.
+// pos é a posição do cursor em runas, não em bytes.
+func (s *replSession) complete(line string, pos int) (string, []string, string) {
+	runes := []rune(line)
+	head, tail := string(runes[:pos]), string(runes[pos:])
+	start := strings.LastIndexAny(head, " \t(,") + 1
+	word := head[start:]
+
+	var candidates []string
+	switch {
+	case start == 0 && strings.HasPrefix(word, ":"):
+		candidates = sortedKeys(replCommands)
+	case strings.HasPrefix(word, "$"):
+		candidates = dataPaths("$", s.data, nil)
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, candidate)
+		}
+	}
+	return head[:start], completions, tail
+}
+
+// dataPaths lista todos os caminhos dos dados, incluindo índices de arrays.
+func dataPaths(prefix string, value interface{}, paths []string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			path := prefix + "." + key
+			paths = append(paths, path)
+			paths = dataPaths(path, v[key], paths)
+		}
+	case []interface{}:
+		for i, item := range v {
+			path := fmt.Sprintf("%s[%d]", prefix, i)
+			paths = append(paths, path)
+			paths = dataPaths(path, item, paths)
+		}
+	}
+	return paths
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+)
+
+func newTestReplSession(out *bytes.Buffer) *replSession {
+	ec := core.NewEngineContext(nil, nil, nil, "Local")
+	ec.Expressions = map[string]string{"desconto": "EXP($.valor * 0.1)"}
+	return newReplSession(out, ec)
+}
+
+func TestReplSession(t *testing.T) {
+	var out bytes.Buffer
+	session := newTestReplSession(&out)
+	require.NoError(t, session.load(exampleRequest))
+
+	all_cases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "condição", input: `$.idade >= 18`, expected: []string{"resultado: true", "path $.idade = 21"}},
+		{name: "mutação", input: `SET $.desconto = EXP($.valor * 0.1)`, expected: []string{"resultado: true", "alterações:", "add /desconto: 15"}},
+		{name: "show do caminho alterado", input: `:show $.desconto`, expected: []string{"15"}},
+		{name: "erro de avaliação", input: `$.cliente > 1`, expected: []string{"erro:"}},
+		{name: "LET", input: `LET total = EXP($.valor + 10)`, expected: []string{"resultado: true"}},
+		{name: "variável da linha anterior", input: `@total == 160`, expected: []string{"resultado: true"}},
+		{name: "expressão nomeada", input: `SET $.taxa = @desconto`, expected: []string{"add /taxa: 15"}},
+		{name: "show de variável", input: `:show @total`, expected: []string{"160"}},
+		{name: "reset", input: `:reset`, expected: []string{"dados restaurados"}},
+		{name: "show após reset", input: `:show $.desconto`, expected: []string{"null"}},
+		{name: "variável descartada no reset", input: `@total == 160`, expected: []string{"erro:", "variável @total não definida"}},
+		{name: "trace", input: `:trace`, expected: []string{"trace ligado"}},
+		{name: "regra com trace", input: `$.moeda == "BRL" OR $.moeda == "USD"`, expected: []string{"[rule]", "[or.right]", "(não avaliado)"}},
+		{name: "load inexistente", input: `:load inexistente.json`, expected: []string{"erro:"}},
+		{name: "comando desconhecido", input: `:compilar`, expected: []string{"comando desconhecido"}},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			out.Reset()
+			assert.False(t, session.execute(cenario.input))
+			for _, expected := range cenario.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+
+	assert.True(t, session.execute(":quit"))
+}
+
+func TestReplComplete(t *testing.T) {
+	session := newTestReplSession(&bytes.Buffer{})
+	require.NoError(t, session.load(exampleRequest))
+
+	all_cases := []struct {
+		name     string
+		line     string
+		head     string
+		expected []string
+	}{
+		{name: "caminho no início", line: "$.cli", head: "", expected: []string{"$.cliente", "$.cliente.tipo"}},
+		{name: "caminho após operador", line: "$.valor > $.limites.m", head: "$.valor > ", expected: []string{"$.limites.maxTransacoes"}},
+		{name: "índices de arrays", line: "SUM($.transacoes[1", head: "SUM(", expected: []string{"$.transacoes[1]", "$.transacoes[1].id", "$.transacoes[1].valor"}},
+		{name: "comandos", line: ":s", head: "", expected: []string{":show"}},
+		{name: "literal", line: "$.tipo == adu", head: "$.tipo == ", expected: nil},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			head, completions, tail := session.complete(cenario.line, len([]rune(cenario.line)))
+			assert.Equal(t, cenario.head, head)
+			assert.Equal(t, cenario.expected, completions)
+			assert.Empty(t, tail)
+		})
+	}
+
+	t.Run("caminhos e literais acentuados", func(t *testing.T) {
+		session.data = map[string]interface{}{"nome": "José", "endereço": map[string]interface{}{"cidade": "São Paulo"}}
+
+		// A posição do cursor é em runas: logo após "$.endereço.ci", antes do THEN
+		line := `IF $.nome == "José" AND $.endereço.ci THEN SET $.ok = true`
+		pos := len([]rune(`IF $.nome == "José" AND $.endereço.ci`))
+		head, completions, tail := session.complete(line, pos)
+		assert.Equal(t, `IF $.nome == "José" AND `, head)
+		assert.Equal(t, []string{"$.endereço.cidade"}, completions)
+		assert.Equal(t, " THEN SET $.ok = true", tail)
+	})
+}
+
+func TestRepl(t *testing.T) {
+	code, stdout, _ := runCLI("$.idade >= 18\n:quit\n$.idade < 18\n", "repl", exampleRequest)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "resultado: true\ndetalhes: path $.idade = 21 >= literal 18.000000 -> true\n", stdout)
+
+	// Com -policies, as expressões nomeadas do arquivo ficam disponíveis
+	code, stdout, _ = runCLI("@freteMaximo > 0\n", "repl", "-policies", examplePolicies, exampleRequest)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "resultado: true")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/server"
+)
+
+// runServe inicia o servidor HTTP até receber SIGINT ou SIGTERM.
+func runServe(c *cli, args []string) int {
+	var (
+		source engineFlags
+		cfg    server.Config
+	)
+	fs := c.newFlagSet("serve", "")
+	source.register(fs)
+	fs.StringVar(&cfg.Addr, "addr", server.DefaultAddr, "endereço de escuta")
+	fs.Int64Var(&cfg.MaxBodyBytes, "max-body", server.DefaultMaxBodyBytes, "tamanho máximo do corpo das requisições em bytes")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", server.DefaultShutdownTimeout, "tempo de espera das requisições em andamento no encerramento")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	ec, err := source.engine()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(c.stderr, "servidor escutando em %s (%d políticas)\n", cfg.Addr, len(ec.Policies))
+	if err := server.New(ec, cfg).ListenAndServe(ctx); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
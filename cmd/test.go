@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/core/loader"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/policytest"
+)
+
+// runTest executa arquivos de testes de políticas (ver policytest) e escreve o relatório
+// em texto, JUnit XML ou TAP. Cada arquivo usa as políticas declaradas nele, exceto com -policies.
+// Com -cover, -cover-json ou -cover-html, registra a cobertura das regras e de seus ramos.
+func runTest(c *cli, args []string) int {
+	var (
+		source    engineFlags
+		format    string
+		output    string
+		cover     bool
+		coverJSON string
+		coverHTML string
+	)
+	fs := c.newFlagSet("test", "<testes.yaml>...")
+	fs.StringVar(&source.policies, "policies", "", "origem do arquivo de políticas; substitui a declarada nos testes")
+	fs.StringVar(&format, "format", policytest.FormatText, "formato do relatório: text, junit ou tap")
+	fs.StringVar(&output, "o", "", "arquivo de saída do relatório (padrão: saída padrão)")
+	fs.BoolVar(&cover, "cover", false, "imprime o resumo de cobertura das regras em stderr")
+	fs.StringVar(&coverJSON, "cover-json", "", "arquivo de saída da cobertura em JSON")
+	fs.StringVar(&coverHTML, "cover-html", "", "arquivo de saída da cobertura em HTML")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	withCoverage := cover || coverJSON != "" || coverHTML != ""
+	engines := make(map[string]*core.EngineContext)
+	coverages := make(map[string]*policytest.Coverage)
+	var (
+		results []policytest.SuiteResult
+		sources []string
+	)
+	for _, path := range fs.Args() {
+		suite, err := policytest.LoadSuite(path)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+
+		suiteSource := source
+		if suiteSource.policies == "" {
+			suiteSource.policies = suite.Policies
+		}
+		ec, cached := engines[suiteSource.policies]
+		if !cached {
+			if ec, err = suiteSource.engine(); err != nil {
+				fmt.Fprintf(c.stderr, "erro: %s: %v\n", path, err)
+				return exitUsage
+			}
+			engines[suiteSource.policies] = ec
+			sources = append(sources, suiteSource.policies)
+			if withCoverage {
+				coverages[suiteSource.policies] = policytest.NewCoverage(suiteSource.policies, ec.Policies)
+			}
+		}
+		results = append(results, policytest.RunWithCoverage(ec, suite, coverages[suiteSource.policies]))
+	}
+
+	var w io.Writer = c.stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := policytest.WriteReport(w, format, results); err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	if withCoverage {
+		var ordered []*policytest.Coverage
+		for _, src := range sources {
+			ordered = append(ordered, coverages[src])
+		}
+		if err := writeCoverage(c, ordered, cover, coverJSON, coverHTML); err != nil {
+			fmt.Fprintf(c.stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	for _, result := range results {
+		if result.Failed() > 0 {
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// writeCoverage escreve os relatórios de cobertura pedidos. O HTML exibe o conteúdo dos
+// arquivos de políticas, lidos novamente das origens.
+func writeCoverage(c *cli, coverages []*policytest.Coverage, summary bool, jsonPath, htmlPath string) error {
+	if summary {
+		if err := policytest.WriteCoverageText(c.stderr, coverages); err != nil {
+			return err
+		}
+	}
+	if jsonPath != "" {
+		if err := writeFile(jsonPath, func(w io.Writer) error { return policytest.WriteCoverageJSON(w, coverages) }); err != nil {
+			return err
+		}
+	}
+	if htmlPath != "" {
+		contents := make(map[string][]byte, len(coverages))
+		for _, coverage := range coverages {
+			content, err := loader.ReadSource(coverage.Source)
+			if err != nil {
+				return err
+			}
+			contents[coverage.Source] = content
+		}
+		return writeFile(htmlPath, func(w io.Writer) error { return policytest.WriteCoverageHTML(w, coverages, contents) })
+	}
+	return nil
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+)
+
+// runValidate valida um documento de dados (arquivo ou entrada padrão) contra o schema da requisição.
+// Com -request, o documento é uma requisição completa e apenas o campo "data" é validado.
+func runValidate(c *cli, args []string) int {
+	var (
+		schemaSource string
+		isRequest    bool
+	)
+	fs := c.newFlagSet("validate", "[dados.json]")
+	fs.StringVar(&schemaSource, "schema", "", "origem do JSON Schema da requisição (caminho, s3:// ou ssm://)")
+	fs.BoolVar(&isRequest, "request", false, "a entrada é uma requisição e o campo \"data\" é validado")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if schemaSource == "" {
+		fmt.Fprintln(c.stderr, "erro: a opção -schema é obrigatória")
+		return exitUsage
+	}
+
+	sch, err := loadSchema(schemaSource)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	body, err := c.readInput(fs.Args())
+	if err != nil {
+		fmt.Fprintf(c.stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	var data map[string]interface{}
+	if isRequest {
+		var req core.Request
+		err = json.Unmarshal(body, &req)
+		data = req.Data
+	} else {
+		err = json.Unmarshal(body, &data)
+	}
+	if err != nil {
+		return c.fail(&core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao desserializar dados: %v", err)})
+	}
+
+	ec := core.NewEngineContext(sch, nil, nil, "")
+	if validationErrors := ec.ValidateData(data); len(validationErrors) > 0 {
+		for _, vErr := range validationErrors {
+			fmt.Fprintf(c.stderr, "inválido: %v\n", vErr)
+		}
+		return exitSchema
+	}
+	fmt.Fprintln(c.stdout, "válido")
+	return exitOK
+}
@@ -0,0 +1,56 @@
+package awslambda
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HeaderTraceID é o cabeçalho de rastreamento adicionado pelo ALB, usado como ID da requisição.
+const HeaderTraceID = "x-amzn-trace-id"
+
+// ALB processa eventos de um target group do Application Load Balancer.
+// Suporta cabeçalhos simples e multivalorados (quando habilitados no target group);
+// a resposta usa o mesmo formato de cabeçalhos recebido. O ALB não tem authorizer, então
+// $ctx.userId fica vazio.
+func (h *Handler) ALB(_ context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	multiValue := len(event.MultiValueHeaders) > 0
+	headers := event.Headers
+	if multiValue {
+		headers = make(map[string]string, len(event.MultiValueHeaders))
+		for name, values := range event.MultiValueHeaders {
+			if len(values) > 0 {
+				headers[name] = values[len(values)-1]
+			}
+		}
+	}
+
+	ev := eventRequest{
+		body:       event.Body,
+		isBase64:   event.IsBase64Encoded,
+		headers:    headers,
+		source:     InputTypeALB,
+		inputType:  InputTypeALB,
+		fromClient: true,
+	}
+	for name, value := range headers {
+		if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(HeaderTraceID) {
+			ev.requestID = value
+		}
+	}
+	status, body := h.process(ev)
+
+	response := events.ALBTargetGroupResponse{
+		StatusCode:        status,
+		StatusDescription: fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:              body,
+	}
+	if multiValue {
+		response.MultiValueHeaders = map[string][]string{"Content-Type": {"application/json"}}
+	} else {
+		response.Headers = jsonHeaders()
+	}
+	return response, nil
+}
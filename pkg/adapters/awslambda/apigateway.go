@@ -0,0 +1,90 @@
+package awslambda
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// APIGatewayProxy processa eventos do API Gateway REST (proxy integration).
+// O usuário é obtido apenas do authorizer (principalId ou claim "sub").
+func (h *Handler) APIGatewayProxy(_ context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var timestamp time.Time
+	if event.RequestContext.RequestTimeEpoch > 0 {
+		timestamp = time.UnixMilli(event.RequestContext.RequestTimeEpoch)
+	}
+
+	status, body := h.process(eventRequest{
+		body:       event.Body,
+		isBase64:   event.IsBase64Encoded,
+		headers:    event.Headers,
+		requestID:  event.RequestContext.RequestID,
+		userID:     restAuthorizerUser(event.RequestContext.Authorizer),
+		source:     InputTypeAPIGatewayProxy,
+		inputType:  InputTypeAPIGatewayProxy,
+		timestamp:  timestamp,
+		fromClient: true,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    jsonHeaders(),
+		Body:       body,
+	}, nil
+}
+
+// APIGatewayV2HTTP processa eventos do API Gateway HTTP API (payload 2.0).
+// O usuário é obtido do authorizer JWT (claim "sub"), Lambda (principalId) ou IAM.
+func (h *Handler) APIGatewayV2HTTP(_ context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	var timestamp time.Time
+	if event.RequestContext.TimeEpoch > 0 {
+		timestamp = time.UnixMilli(event.RequestContext.TimeEpoch)
+	}
+
+	status, body := h.process(eventRequest{
+		body:       event.Body,
+		isBase64:   event.IsBase64Encoded,
+		headers:    event.Headers,
+		requestID:  event.RequestContext.RequestID,
+		userID:     httpAuthorizerUser(event.RequestContext.Authorizer),
+		source:     InputTypeAPIGatewayV2HTTP,
+		inputType:  InputTypeAPIGatewayV2HTTP,
+		timestamp:  timestamp,
+		fromClient: true,
+	})
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: status,
+		Headers:    jsonHeaders(),
+		Body:       body,
+	}, nil
+}
+
+func restAuthorizerUser(authorizer map[string]interface{}) string {
+	if principal, ok := authorizer["principalId"].(string); ok && principal != "" {
+		return principal
+	}
+	// Authorizer do Cognito: as claims ficam em "claims"
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok {
+			return sub
+		}
+	}
+	return ""
+}
+
+func httpAuthorizerUser(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) string {
+	if authorizer == nil {
+		return ""
+	}
+	if authorizer.JWT != nil && authorizer.JWT.Claims["sub"] != "" {
+		return authorizer.JWT.Claims["sub"]
+	}
+	if principal, ok := authorizer.Lambda["principalId"]; ok {
+		return fmt.Sprint(principal)
+	}
+	if authorizer.IAM != nil {
+		return authorizer.IAM.UserID
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package awslambda
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Tipos de entrada assíncronos (EngineContext.InputType)
+const (
+	InputTypeSQS         = "SQS"
+	InputTypeKinesis     = "Kinesis"
+	InputTypeEventBridge = "EventBridge"
+)
+
+// DefaultBatchWorkers é o número de registros processados em paralelo quando Handler.Workers não é informado.
+const DefaultBatchWorkers = 10
+
+// SQS processa um lote de mensagens SQS. Cada corpo é uma requisição do motor; os atributos
+// de mensagem do tipo String são tratados como cabeçalhos (ex.: x-user-id, x-source).
+// Mensagens que falham (requisição, schema ou política) são devolvidas em batchItemFailures
+// para que apenas elas voltem à fila; é necessário habilitar ReportBatchItemFailures no trigger.
+func (h *Handler) SQS(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	failed := h.processBatch(ctx, len(event.Records), func(i int) (string, eventRequest) {
+		message := event.Records[i]
+		return message.MessageId, eventRequest{
+			body:      message.Body,
+			headers:   sqsAttributeHeaders(message.MessageAttributes),
+			requestID: message.MessageId,
+			source:    InputTypeSQS,
+			inputType: InputTypeSQS,
+			timestamp: epochMillis(message.Attributes["SentTimestamp"]),
+		}
+	})
+
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, id := range failed {
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: id})
+	}
+	return response, nil
+}
+
+// Kinesis processa um lote de registros de um stream Kinesis. Registros que falham são
+// identificados pelo número de sequência em batchItemFailures.
+func (h *Handler) Kinesis(ctx context.Context, event events.KinesisEvent) (events.KinesisEventResponse, error) {
+	failed := h.processBatch(ctx, len(event.Records), func(i int) (string, eventRequest) {
+		record := event.Records[i]
+		return record.Kinesis.SequenceNumber, eventRequest{
+			body:      string(record.Kinesis.Data),
+			requestID: record.EventID,
+			source:    InputTypeKinesis,
+			inputType: InputTypeKinesis,
+			timestamp: record.Kinesis.ApproximateArrivalTimestamp.Time,
+		}
+	})
+
+	response := events.KinesisEventResponse{BatchItemFailures: []events.KinesisBatchItemFailure{}}
+	for _, id := range failed {
+		response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{ItemIdentifier: id})
+	}
+	return response, nil
+}
+
+// EventBridge processa um evento do EventBridge cujo "detail" é a requisição do motor.
+// O EventBridge entrega um evento por invocação, então a falha é sinalizada com erro,
+// o que aciona a política de retentativas e a DLQ configuradas na regra.
+func (h *Handler) EventBridge(ctx context.Context, event events.EventBridgeEvent) error {
+	failed := h.processBatch(ctx, 1, func(int) (string, eventRequest) {
+		return event.ID, eventRequest{
+			body:      string(event.Detail),
+			requestID: event.ID,
+			source:    firstNonEmpty(event.Source, InputTypeEventBridge),
+			inputType: InputTypeEventBridge,
+			timestamp: event.Time,
+		}
+	})
+	if len(failed) > 0 {
+		return fmt.Errorf("evento '%s' do EventBridge não passou pelo motor de políticas", event.ID)
+	}
+	return nil
+}
+
+// processBatch executa os n registros no motor com no máximo h.Workers em paralelo e retorna,
+// na ordem do lote, os identificadores dos registros que falharam. Registros ainda não iniciados
+// quando o contexto é cancelado também são considerados falhas, para serem reentregues.
+func (h *Handler) processBatch(ctx context.Context, n int, record func(i int) (string, eventRequest)) []string {
+	workers := h.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	if workers > n {
+		workers = n
+	}
+
+	ids := make([]string, n)
+	errs := make([]error, n)
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				id, ev := record(i)
+				ids[i] = id
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = h.processRecord(ev)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("registro '%s' falhou: %v", ids[i], err)
+			failed = append(failed, ids[i])
+		}
+	}
+	return failed
+}
+
+// processRecord executa um registro assíncrono no motor; a resposta é descartada.
+func (h *Handler) processRecord(ev eventRequest) error {
+	req, err := ev.toRequest()
+	if err != nil {
+		return err
+	}
+	_, err = h.Engine.Process(req)
+	return err
+}
+
+func sqsAttributeHeaders(attributes map[string]events.SQSMessageAttribute) map[string]string {
+	headers := make(map[string]string, len(attributes))
+	for name, attr := range attributes {
+		if attr.StringValue != nil && strings.HasPrefix(attr.DataType, "String") {
+			headers[name] = *attr.StringValue
+		}
+	}
+	return headers
+}
+
+// epochMillis converte os timestamps em milissegundos usados nos atributos do SQS.
+func epochMillis(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || millis <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
@@ -0,0 +1,108 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQS(t *testing.T) {
+	var event events.SQSEvent
+	loadFixture(t, "sqs.json", &event)
+
+	response, err := newTestHandler(InputTypeSQS).SQS(context.Background(), event)
+	require.NoError(t, err)
+
+	// Mensagem reprovada na política e mensagem fora do schema voltam para a fila
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "2e1424d4-f796-459a-8184-9c92662be6da"},
+		{ItemIdentifier: "d2a5e0b1-3c4f-4e6a-9b8c-7d1e2f3a4b5c"},
+	}, response.BatchItemFailures)
+
+	t.Run("lote sem falhas", func(t *testing.T) {
+		event := events.SQSEvent{Records: event.Records[:1]}
+		response, err := newTestHandler(InputTypeSQS).SQS(context.Background(), event)
+		require.NoError(t, err)
+
+		body, _ := json.Marshal(response)
+		assert.JSONEq(t, `{"batchItemFailures":[]}`, string(body))
+	})
+}
+
+func TestKinesis(t *testing.T) {
+	var event events.KinesisEvent
+	loadFixture(t, "kinesis.json", &event)
+
+	response, err := newTestHandler(InputTypeKinesis).Kinesis(context.Background(), event)
+	require.NoError(t, err)
+
+	// Política reprovada e JSON inválido
+	assert.Equal(t, []events.KinesisBatchItemFailure{
+		{ItemIdentifier: "49590338271490256608559692540925702759324208523137515618"},
+		{ItemIdentifier: "49590338271490256608559692541114820743079301239382343778"},
+	}, response.BatchItemFailures)
+}
+
+func TestEventBridge(t *testing.T) {
+	var event events.EventBridgeEvent
+	loadFixture(t, "eventbridge.json", &event)
+
+	handler := newTestHandler(InputTypeEventBridge)
+	assert.NoError(t, handler.EventBridge(context.Background(), event))
+
+	event.Detail = json.RawMessage(`{"data":{"valor":200,"idade":10},"policies":["ValidarIdade"]}`)
+	err := handler.EventBridge(context.Background(), event)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), event.ID)
+}
+
+func TestProcessBatch(t *testing.T) {
+	var records []events.SQSMessage
+	for i := 0; i < 25; i++ {
+		idade := 20
+		if i%5 == 0 {
+			idade = 10
+		}
+		records = append(records, events.SQSMessage{
+			MessageId: fmt.Sprintf("msg-%02d", i),
+			Body:      fmt.Sprintf(`{"data":{"idade":%d},"policies":["ValidarIdade"]}`, idade),
+		})
+	}
+
+	all_cases := []struct {
+		name    string
+		workers int
+	}{
+		{name: "padrão", workers: 0},
+		{name: "um worker", workers: 1},
+		{name: "mais workers que registros", workers: 100},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			handler := newTestHandler(InputTypeSQS)
+			handler.Workers = cenario.workers
+
+			response, err := handler.SQS(context.Background(), events.SQSEvent{Records: records})
+			require.NoError(t, err)
+			assert.Equal(t, []events.SQSBatchItemFailure{
+				{ItemIdentifier: "msg-00"}, {ItemIdentifier: "msg-05"}, {ItemIdentifier: "msg-10"},
+				{ItemIdentifier: "msg-15"}, {ItemIdentifier: "msg-20"},
+			}, response.BatchItemFailures)
+		})
+	}
+
+	t.Run("contexto cancelado", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		response, err := newTestHandler(InputTypeSQS).SQS(ctx, events.SQSEvent{Records: records})
+		require.NoError(t, err)
+		assert.Len(t, response.BatchItemFailures, len(records))
+	})
+}
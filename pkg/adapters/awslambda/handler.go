@@ -0,0 +1,157 @@
+package awslambda
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+)
+
+// Tipos de entrada suportados (EngineContext.InputType)
+const (
+	InputTypeAPIGatewayProxy  = "APIGatewayProxy"
+	InputTypeAPIGatewayV2HTTP = "APIGatewayV2HTTP"
+	InputTypeALB              = "ALB"
+)
+
+// Cabeçalhos usados como fallback para o contexto da requisição. Em eventos HTTP, x-user-id
+// é ignorado: o usuário vem apenas do authorizer.
+const (
+	HeaderUserID = "x-user-id"
+	HeaderSource = "x-source"
+)
+
+// Handler adapta eventos do AWS Lambda (API Gateway REST, HTTP API, ALB, SQS, Kinesis
+// e EventBridge) para o motor de políticas.
+type Handler struct {
+	Engine *core.EngineContext
+	// Workers limita quantos registros de um lote são processados em paralelo (padrão DefaultBatchWorkers).
+	Workers int
+}
+
+// NewHandler cria um adaptador para o motor informado.
+func NewHandler(ec *core.EngineContext) *Handler {
+	return &Handler{Engine: ec}
+}
+
+// HandlerFor retorna a função de handler correspondente ao tipo de entrada
+// (ex.: EngineContext.InputType), pronta para ser usada com lambda.Start.
+func (h *Handler) HandlerFor(inputType string) (interface{}, error) {
+	switch inputType {
+	case InputTypeAPIGatewayProxy:
+		return h.APIGatewayProxy, nil
+	case InputTypeAPIGatewayV2HTTP:
+		return h.APIGatewayV2HTTP, nil
+	case InputTypeALB:
+		return h.ALB, nil
+	case InputTypeSQS:
+		return h.SQS, nil
+	case InputTypeKinesis:
+		return h.Kinesis, nil
+	case InputTypeEventBridge:
+		return h.EventBridge, nil
+	default:
+		return nil, fmt.Errorf("tipo de entrada não suportado pelo adaptador lambda: '%s'", inputType)
+	}
+}
+
+// eventRequest reúne o que foi extraído do evento para montar a core.Request.
+type eventRequest struct {
+	body      string
+	isBase64  bool
+	headers   map[string]string
+	requestID string
+	userID    string
+	source    string
+	inputType string // Tipo do evento decodificado, exposto como $meta.inputType
+	timestamp time.Time
+	// Corpo e cabeçalhos enviados pelo cliente (API Gateway e ALB): o usuário é sempre userID
+	fromClient bool
+}
+
+// process monta a core.Request a partir do evento, executa o motor e retorna o status HTTP e o corpo JSON.
+func (h *Handler) process(ev eventRequest) (int, string) {
+	req, err := ev.toRequest()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	response, err := h.Engine.Process(req)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return errorResponse(fmt.Errorf("falha ao serializar resposta: %v", err))
+	}
+	return http.StatusOK, string(body)
+}
+
+// toRequest desserializa o corpo e completa ID, timestamp e contexto com os dados do evento
+// quando não vierem no próprio corpo. Em eventos do cliente, o usuário do authorizer sempre
+// substitui o informado no corpo, que não pode ser usado para se passar por outro usuário.
+func (ev eventRequest) toRequest() (*core.Request, error) {
+	body := []byte(ev.body)
+	if ev.isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(ev.body)
+		if err != nil {
+			return nil, &core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("corpo base64 inválido: %v", err)}
+		}
+		body = decoded
+	}
+
+	var req core.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, &core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao desserializar requisição: %v", err)}
+	}
+
+	headers := make(map[string]string, len(ev.headers))
+	for name, value := range ev.headers {
+		headers[strings.ToLower(name)] = value
+	}
+	req.Headers = headers
+	req.InputType = ev.inputType
+
+	if req.ID == "" {
+		req.ID = ev.requestID
+	}
+	if req.Timestamp == "" && !ev.timestamp.IsZero() {
+		req.Timestamp = ev.timestamp.UTC().Format(time.RFC3339)
+	}
+	if req.Context == nil {
+		req.Context = &core.Context{}
+	}
+	switch {
+	case ev.fromClient:
+		req.Context.UserID = ev.userID
+	case req.Context.UserID == "":
+		req.Context.UserID = firstNonEmpty(ev.userID, headers[HeaderUserID])
+	}
+	if req.Context.Source == "" {
+		req.Context.Source = firstNonEmpty(headers[HeaderSource], ev.source)
+	}
+	return &req, nil
+}
+
+func errorResponse(err error) (int, string) {
+	body, _ := json.Marshal(core.NewErrorBody(err))
+	return core.KindOf(err).HTTPStatus(), string(body)
+}
+
+func jsonHeaders() map[string]string {
+	return map[string]string{"Content-Type": "application/json"}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
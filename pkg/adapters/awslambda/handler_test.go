@@ -0,0 +1,163 @@
+package awslambda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+func newTestHandler(inputType string) *Handler {
+	reqSchema := schema.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"valor": map[string]interface{}{"type": "number"},
+			"idade": map[string]interface{}{"type": "integer"},
+		},
+	}
+	policies := map[string]policy.PolicyDefinition{
+		"ValidarIdade": {Name: "ValidarIdade", Rules: []string{`$.idade >= 18`}},
+		"CalcularDesconto": {Name: "CalcularDesconto", Rules: []string{
+			`SET $.desconto = EXP($.valor * 0.1)`,
+			`SET $.usuario = $ctx.userId`,
+			`SET $.canal = $ctx.source`,
+			`SET $.entrada = $meta.inputType`,
+		}},
+	}
+	return NewHandler(core.NewEngineContext(&reqSchema, nil, policies, inputType))
+}
+
+func loadFixture(t *testing.T, name string, event interface{}) {
+	content, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, event))
+}
+
+func TestAPIGatewayProxy(t *testing.T) {
+	var event events.APIGatewayProxyRequest
+	loadFixture(t, "apigateway_proxy.json", &event)
+
+	response, err := newTestHandler(InputTypeAPIGatewayProxy).APIGatewayProxy(context.Background(), event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "c6af9ac6-7b61-11e6-9a41-93e8deadbeef-response", body["id"])
+
+	data := body["processedData"].(map[string]interface{})
+	assert.Equal(t, 15.0, data["desconto"])
+	assert.Equal(t, "user-123", data["usuario"])
+	assert.Equal(t, "web", data["canal"])
+
+	t.Run("corpo e cabeçalho não substituem o usuário do authorizer", func(t *testing.T) {
+		event.Body = `{"data":{"valor":10,"idade":30},"policies":["CalcularDesconto"],"context":{"userId":"admin"}}`
+		event.Headers["X-User-Id"] = "admin"
+
+		response, err := newTestHandler(InputTypeAPIGatewayProxy).APIGatewayProxy(context.Background(), event)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Contains(t, response.Body, `"usuario":"user-123"`)
+
+		event.RequestContext.Authorizer = nil
+		response, err = newTestHandler(InputTypeAPIGatewayProxy).APIGatewayProxy(context.Background(), event)
+		require.NoError(t, err)
+		assert.Contains(t, response.Body, `"usuario":""`)
+	})
+}
+
+func TestAPIGatewayV2HTTP(t *testing.T) {
+	var event events.APIGatewayV2HTTPRequest
+	loadFixture(t, "apigateway_v2_http.json", &event)
+
+	response, err := newTestHandler(InputTypeAPIGatewayV2HTTP).APIGatewayV2HTTP(context.Background(), event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+	var body core.ErrorBody
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "policy", body.Kind)
+	assert.Contains(t, body.Message, "ValidarIdade")
+
+	t.Run("corpo e cabeçalho não substituem o usuário do JWT", func(t *testing.T) {
+		event.Body = `{"data":{"valor":10,"idade":30},"policies":["CalcularDesconto"],"context":{"userId":"admin"}}`
+		event.Headers["x-user-id"] = "admin"
+		event.IsBase64Encoded = false
+
+		// O tipo do evento recebido prevalece sobre o configurado no motor
+		response, err := newTestHandler(InputTypeAPIGatewayProxy).APIGatewayV2HTTP(context.Background(), event)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Contains(t, response.Body, `"usuario":"user-456"`)
+		assert.Contains(t, response.Body, `"entrada":"APIGatewayV2HTTP"`)
+	})
+}
+
+func TestALB(t *testing.T) {
+	var event events.ALBTargetGroupRequest
+	loadFixture(t, "alb.json", &event)
+
+	response, err := newTestHandler(InputTypeALB).ALB(context.Background(), event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "400 Bad Request", response.StatusDescription)
+
+	var body core.ErrorBody
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "schema", body.Kind)
+
+	t.Run("cabeçalhos multivalorados sem usuário do cabeçalho", func(t *testing.T) {
+		event.Body = `{"data":{"valor":10,"idade":30},"policies":["CalcularDesconto"]}`
+		event.MultiValueHeaders = map[string][]string{"x-user-id": {"user-789"}}
+
+		response, err := newTestHandler(InputTypeALB).ALB(context.Background(), event)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, []string{"application/json"}, response.MultiValueHeaders["Content-Type"])
+		assert.Contains(t, response.Body, `"usuario":""`)
+		assert.Contains(t, response.Body, `"entrada":"ALB"`)
+	})
+}
+
+func TestStatusCodes(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "json inválido", body: `{"data":`, expected: http.StatusBadRequest},
+		{name: "schema inválido", body: `{"data":{"idade":"vinte"},"policies":["ValidarIdade"]}`, expected: http.StatusBadRequest},
+		{name: "política falhou", body: `{"data":{"idade":10},"policies":["ValidarIdade"]}`, expected: http.StatusUnprocessableEntity},
+		{name: "sucesso", body: `{"data":{"idade":20},"policies":["ValidarIdade"]}`, expected: http.StatusOK},
+	}
+
+	t.Run("", func(t *testing.T) {
+		handler := newTestHandler(InputTypeAPIGatewayProxy)
+		for _, cenario := range all_cases {
+			response, err := handler.APIGatewayProxy(context.Background(), events.APIGatewayProxyRequest{Body: cenario.body})
+			assert.NoError(t, err, cenario.name)
+			assert.Equal(t, cenario.expected, response.StatusCode, cenario.name)
+		}
+	})
+}
+
+func TestHandlerFor(t *testing.T) {
+	handler := newTestHandler(InputTypeALB)
+
+	fn, err := handler.HandlerFor(handler.Engine.InputType)
+	assert.NoError(t, err)
+	assert.NotNil(t, fn)
+
+	_, err = handler.HandlerFor("Local")
+	assert.Error(t, err)
+}
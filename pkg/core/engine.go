@@ -7,17 +7,47 @@ import (
 	"strings"
 	"time"
 
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
 	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
 	"github.com/raywall/cloud-policy-serializer/pkg/policy"
 	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
 )
 
-// ExecutePolicies executa as políticas especificadas contra os dados.
+// ExecutePolicies executa as políticas especificadas contra os dados, registrando as
+// alterações feitas por cada política (PolicyExecutionResult.Changes).
 func (ec *EngineContext) ExecutePolicies(data map[string]interface{}, policyNames []string) ([]policy.PolicyExecutionResult, bool) {
+	return ec.ExecutePoliciesWithOptions(data, policyNames, ExecutionOptions{Changes: true})
+}
+
+// ExecutePoliciesWithOptions executa as políticas especificadas contra os dados usando as opções informadas.
+func (ec *EngineContext) ExecutePoliciesWithOptions(data map[string]interface{}, policyNames []string, opts ExecutionOptions) ([]policy.PolicyExecutionResult, bool) {
 	var results []policy.PolicyExecutionResult
 	allPassedOverall := true
 
-	for _, policyName := range policyNames {
+	// Sem políticas informadas, aplica o conjunto padrão (se declarado)
+	if len(policyNames) == 0 {
+		if _, hasDefault := ec.PolicySets[policy.DefaultSet]; hasDefault {
+			policyNames = []string{policy.DefaultSet}
+		}
+	}
+
+	// Expande conjuntos e tags e inclui as dependências declaradas (dependsOn),
+	// ordenando para que executem antes
+	selectedNames, err := policy.ExpandSelectors(ec.Policies, ec.PolicySets, policyNames)
+	if err == nil {
+		selectedNames, err = policy.ResolveOrder(ec.Policies, selectedNames)
+	}
+	orderedNames := selectedNames
+	if err != nil {
+		return []policy.PolicyExecutionResult{{
+			PolicyName: strings.Join(policyNames, ", "),
+			Passed:     false,
+			Error:      err,
+		}}, false
+	}
+	passedPolicies := make(map[string]bool, len(orderedNames))
+
+	for _, policyName := range orderedNames {
 		policyDef, exists := ec.Policies[policyName]
 		if !exists {
 			results = append(results, policy.PolicyExecutionResult{
@@ -29,12 +59,61 @@ func (ec *EngineContext) ExecutePolicies(data map[string]interface{}, policyName
 			continue
 		}
 
+		// Uma política não executa se alguma de suas dependências falhou
+		if failedDep := firstFailedDependency(policyDef, passedPolicies); failedDep != "" {
+			results = append(results, policy.PolicyExecutionResult{
+				PolicyName: policyName,
+				Passed:     false,
+				Error:      fmt.Errorf("política '%s' não executada: dependência '%s' falhou", policyName, failedDep),
+			})
+			allPassedOverall = false
+			continue
+		}
+
+		params, err := ec.policyParams(policyDef, opts)
+		if err != nil {
+			results = append(results, policy.PolicyExecutionResult{
+				PolicyName: policyName,
+				Passed:     false,
+				Error:      err,
+			})
+			allPassedOverall = false
+			continue
+		}
+
 		currentPolicyAllRulesPassed := true
 		var currentPolicyFirstError error
 		var ruleResultsForThisPolicy []rules.RuleExecutionResult
 
-		for _, ruleStr := range policyDef.Rules {
-			passedThisRule, details, errThisRule := rules.EvaluateRule(ruleStr, data)
+		var changesForThisPolicy []patch.Operation
+
+		var policyTrace *rules.TraceNode
+		if opts.Explain {
+			policyTrace = rules.NewTrace(rules.TracePolicy, policyName)
+		}
+		evalCtx := ec.NewRuleContext(data, policyName, opts)
+		evalCtx.Decimal = policyDef.Decimal()
+		evalCtx.Params = params
+
+		for ruleIndex, ruleStr := range policyDef.Rules {
+			isSetOrIf := rules.IsActionRule(ruleStr)
+
+			// Regras de ação podem alterar os dados: guarda uma cópia para calcular o JSON Patch
+			trackChanges := isSetOrIf && opts.Changes
+			var snapshot interface{}
+			if trackChanges {
+				snapshot = patch.Clone(data)
+			}
+
+			passedThisRule, details, errThisRule := rules.EvaluateRuleWithContext(ruleStr, evalCtx, policyTrace)
+
+			if trackChanges {
+				for _, op := range patch.Diff(snapshot, data) {
+					op.Policy = policyName
+					op.RuleIndex = ruleIndex
+					changesForThisPolicy = append(changesForThisPolicy, op)
+				}
+			}
 
 			ruleExecRes := rules.RuleExecutionResult{
 				Rule:    ruleStr,
@@ -46,8 +125,6 @@ func (ec *EngineContext) ExecutePolicies(data map[string]interface{}, policyName
 			}
 			ruleResultsForThisPolicy = append(ruleResultsForThisPolicy, ruleExecRes)
 
-			isSetOrIf := strings.HasPrefix(strings.TrimSpace(ruleStr), "SET ") || strings.HasPrefix(strings.TrimSpace(ruleStr), "IF ")
-
 			if errThisRule != nil {
 				currentPolicyAllRulesPassed = false
 				if currentPolicyFirstError == nil { // Pega o primeiro erro da política
@@ -71,12 +148,16 @@ func (ec *EngineContext) ExecutePolicies(data map[string]interface{}, policyName
 			// Se errThisRule == nil, então a operação SET/IF foi bem-sucedida ou a condição IF foi falsa (o que não é uma falha).
 		}
 
+		policyTrace.Finish(currentPolicyAllRulesPassed, currentPolicyFirstError)
 		results = append(results, policy.PolicyExecutionResult{
 			PolicyName:  policyName,
 			Passed:      currentPolicyAllRulesPassed,
 			Error:       currentPolicyFirstError,
 			RuleResults: ruleResultsForThisPolicy,
+			Changes:     changesForThisPolicy,
+			Trace:       policyTrace,
 		})
+		passedPolicies[policyName] = currentPolicyAllRulesPassed
 		if !currentPolicyAllRulesPassed {
 			allPassedOverall = false
 		}
@@ -84,32 +165,158 @@ func (ec *EngineContext) ExecutePolicies(data map[string]interface{}, policyName
 	return results, allPassedOverall
 }
 
-// NewEngineContext cria um novo contexto de motor.
+// NewRuleContext monta o contexto de avaliação das regras de uma política, expondo como
+// somente leitura o contexto da requisição ($ctx), os metadados ($meta, incluindo o nome
+// da política em execução) e os valores de ambiente do motor ($env). O relógio do motor
+// é usado pelas funções de data. Cada política tem suas próprias variáveis de LET; as
+// expressões nomeadas do motor são compartilhadas. Também é usado para avaliar regras
+// avulsas como o motor as avaliaria (ex.: no REPL).
+func (ec *EngineContext) NewRuleContext(data map[string]interface{}, policyName string, opts ExecutionOptions) *rules.Context {
+	meta := map[string]interface{}{"policy": policyName}
+	for key, value := range opts.Meta {
+		meta[key] = value
+	}
+	ctx := rules.NewContext(data).
+		WithRoot(rules.RootContext, nonNilMap(opts.Context)).
+		WithRoot(rules.RootMeta, meta).
+		WithRoot(rules.RootEnv, nonNilMap(ec.Environment))
+	ctx.Clock = ec.Clock
+	ctx.Expressions = ec.Expressions
+	ctx.Functions = ec.Functions
+	ctx.Tables = ec.Tables
+	ctx.MaxIterations = ec.MaxIterations
+	return ctx
+}
+
+// policyParams resolve os parâmetros da política: os padrões declarados, os valores do
+// tenant e os da requisição, nessa ordem de precedência.
+func (ec *EngineContext) policyParams(policyDef policy.PolicyDefinition, opts ExecutionOptions) (map[string]interface{}, error) {
+	var tenant policy.ParamOverrides
+	if opts.Tenant != "" {
+		var exists bool
+		if tenant, exists = ec.Tenants[opts.Tenant]; !exists {
+			return nil, fmt.Errorf("tenant '%s' não configurado", opts.Tenant)
+		}
+	}
+	return policyDef.ResolveParams(tenant, opts.Params)
+}
+
+// checkParams verifica o tenant e os valores de parâmetros informados na requisição.
+func (ec *EngineContext) checkParams(tenant string, params policy.ParamOverrides) error {
+	if _, exists := ec.Tenants[tenant]; tenant != "" && !exists {
+		return fmt.Errorf("tenant '%s' não configurado", tenant)
+	}
+	if err := policy.ValidateOverrides(ec.Policies, params); err != nil {
+		return fmt.Errorf("parâmetros da requisição inválidos: %v", err)
+	}
+	return nil
+}
+
+func nonNilMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
+// firstFailedDependency retorna a primeira dependência da política que não passou (ou "").
+func firstFailedDependency(policyDef policy.PolicyDefinition, passedPolicies map[string]bool) string {
+	for _, dep := range policyDef.DependsOn {
+		if !passedPolicies[dep] {
+			return dep
+		}
+	}
+	return ""
+}
+
+// formatTraces renderiza como árvore legível o trace de cada política executada.
+func formatTraces(results []policy.PolicyExecutionResult) string {
+	var sb strings.Builder
+	for _, res := range results {
+		sb.WriteString(res.Trace.String())
+	}
+	return sb.String()
+}
+
+// NewEngineContext cria um novo contexto de motor, com um registro de funções próprio ao
+// qual podem ser acrescentadas funções com ec.Functions.Register (verificadas contra as
+// políticas por CheckFunctions) e um cache de tabelas de referência vazio (ver ReloadTables).
 func NewEngineContext(reqSchema, respSchema *schema.Schema, policiesConfig map[string]policy.PolicyDefinition, inputType string) *EngineContext {
 	return &EngineContext{
 		RequestSchema:  reqSchema,
 		ResponseSchema: respSchema,
 		Policies:       policiesConfig,
 		InputType:      inputType,
+		Functions:      rules.NewFunctionRegistry(),
+		Tables:         rules.NewTableSet(),
+		functionCheck:  &functionCheck{},
 	}
 }
 
+// ReloadTables lê novamente as tabelas de TableSources e as substitui no cache, sem recarregar
+// as políticas. Se alguma tabela falhar, nenhuma é substituída e as regras continuam usando
+// as versões anteriores.
+func (ec *EngineContext) ReloadTables() error {
+	tables, err := policy.LoadTables(ec.TableSources)
+	if err != nil {
+		return err
+	}
+	if ec.Tables == nil {
+		ec.Tables = rules.NewTableSet()
+	}
+	ec.Tables.Store(tables...)
+	return nil
+}
+
+// tableNames retorna as tabelas conhecidas pelo motor: as declaradas em TableSources e as
+// acrescentadas diretamente ao cache.
+func (ec *EngineContext) tableNames() map[string]policy.TableSource {
+	names := make(map[string]policy.TableSource, len(ec.TableSources))
+	for _, name := range ec.Tables.Names() {
+		names[name] = policy.TableSource{}
+	}
+	for name, source := range ec.TableSources {
+		names[name] = source
+	}
+	return names
+}
+
+// ValidateData valida os dados contra o schema da requisição (sem schema, tudo é válido).
+func (ec *EngineContext) ValidateData(data map[string]interface{}) []error {
+	if ec.RequestSchema == nil {
+		return nil
+	}
+	_, validationErrors := ec.RequestSchema.Validate(data)
+	return validationErrors
+}
+
 // ProcessRequest lida com uma string de requisição raw.
+// Os erros retornados são *EngineError; use KindOf para classificá-los.
 func (ec *EngineContext) ProcessRequest(rawRequestBody []byte) (map[string]interface{}, error) {
 	var req Request
 	if err := json.Unmarshal(rawRequestBody, &req); err != nil {
-		return nil, fmt.Errorf("falha ao desserializar requisição: %v", err)
+		return nil, newEngineError(ErrorKindRequest, fmt.Errorf("falha ao desserializar requisição: %v", err))
+	}
+	return ec.Process(&req)
+}
+
+// Process valida e executa uma requisição já desserializada (ex.: montada por um adaptador de eventos).
+// Os erros retornados são *EngineError; use KindOf para classificá-los.
+func (ec *EngineContext) Process(req *Request) (map[string]interface{}, error) {
+	// As chamadas de função das políticas precisam ser válidas para as funções registradas
+	if err := ec.functionsChecked(); err != nil {
+		return nil, newEngineError(ErrorKindInternal, err)
 	}
 
 	// 2. Validar dados contra o schema da requisição
 	if ec.RequestSchema != nil {
-		_, validationErrors := ec.RequestSchema.Validate(req.Data)
+		validationErrors := ec.ValidateData(req.Data)
 		if len(validationErrors) > 0 {
 			var errMsgs []string
 			for _, vErr := range validationErrors {
 				errMsgs = append(errMsgs, vErr.Error())
 			}
-			return nil, fmt.Errorf("validação do schema dos dados da requisição falhou: %s", strings.Join(errMsgs, "; "))
+			return nil, newEngineError(ErrorKindSchema, fmt.Errorf("validação do schema dos dados da requisição falhou: %s", strings.Join(errMsgs, "; ")))
 		}
 
 		// validationErrors := validateDataAgainstSchema(req.Data, ec.RequestSchema, "")
@@ -122,8 +329,26 @@ func (ec *EngineContext) ProcessRequest(rawRequestBody []byte) (map[string]inter
 		// }
 	}
 
+	// Tenant e parâmetros informados precisam ser conhecidos e do tipo declarado
+	if err := ec.checkParams(req.Tenant, req.Params); err != nil {
+		return nil, newEngineError(ErrorKindRequest, err)
+	}
+
 	// 3. Executar políticas
-	policyExecutionResults, allPoliciesPassed := ec.ExecutePolicies(req.Data, req.Policies)
+	policyExecutionResults, allPoliciesPassed := ec.ExecutePoliciesWithOptions(req.Data, req.Policies, ExecutionOptions{
+		Explain: req.Explain,
+		Changes: req.IncludeChanges,
+		Context: req.Context.toMap(),
+		Meta: map[string]interface{}{
+			"id":        req.ID,
+			"timestamp": req.Timestamp,
+			"inputType": req.inputType(ec.InputType),
+			"headers":   req.headersToMap(),
+			"tenant":    req.Tenant,
+		},
+		Tenant: req.Tenant,
+		Params: req.Params,
+	})
 
 	// 4. Lidar com falhas de política
 	if !allPoliciesPassed {
@@ -143,7 +368,7 @@ func (ec *EngineContext) ProcessRequest(rawRequestBody []byte) (map[string]inter
 				ruleStatus := "OK"
 				// Para regras de condição, !rr.Passed significa que a condição não foi atendida.
 				// Para SET/IF, rr.Passed geralmente é true se a operação foi tentada; um erro real estaria em res.Error ou no details.
-				isSetOrIf := strings.HasPrefix(strings.TrimSpace(rr.Rule), "SET ") || strings.HasPrefix(strings.TrimSpace(rr.Rule), "IF ")
+				isSetOrIf := rules.IsActionRule(rr.Rule)
 				if !isSetOrIf && !rr.Passed {
 					ruleStatus = "FALHA_CONDICAO"
 				} else if strings.Contains(rr.Details, "Erro:") { // Se o detalhe da regra indica um erro de execução
@@ -154,17 +379,95 @@ func (ec *EngineContext) ProcessRequest(rawRequestBody []byte) (map[string]inter
 
 			errorMessages = append(errorMessages, fmt.Sprintf("  Política '%s': %s.%s\n  Detalhes das Regras:\n%s", res.PolicyName, status, errMsg, strings.Join(ruleDetailsStrings, "\n")))
 		}
-		return nil, errors.New(strings.Join(errorMessages, "\n"))
+		if req.Explain {
+			errorMessages = append(errorMessages, "Trace de avaliação:", formatTraces(policyExecutionResults))
+		}
+		return nil, newEngineError(ErrorKindPolicy, errors.New(strings.Join(errorMessages, "\n")))
 	}
 
 	// 5. Montar resposta baseada no schema de resposta (simplificado: retorna dados modificados)
 	responsePayload := make(map[string]interface{})
 	responsePayload["id"] = req.ID + "-response"
-	responsePayload["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+	responsePayload["timestamp"] = ec.now().UTC().Format(time.RFC3339Nano)
 	responsePayload["status"] = "success"
 	responsePayload["processedData"] = req.Data // Os dados após as políticas
+	if req.IncludeChanges {
+		responsePayload["changes"] = policy.CollectChanges(policyExecutionResults)
+	}
+	if req.Explain {
+		var traces []*rules.TraceNode
+		for _, res := range policyExecutionResults {
+			traces = append(traces, res.Trace)
+		}
+		responsePayload["explain"] = traces
+	}
 
 	// TODO: Implementar transformação real do schema de resposta usando ec.ResponseSchema
 
 	return responsePayload, nil
 }
+
+// CheckFunctions verifica, sem executar as políticas, as chamadas de função das regras e das
+// expressões nomeadas contra o registro do motor: a função existe e recebe a quantidade e os
+// tipos de argumentos da assinatura. Deve ser chamada depois de carregar as políticas e de
+// registrar as funções próprias; de qualquer forma, Process a executa antes da primeira
+// requisição e depois de cada Register, recusando as requisições enquanto ela falhar.
+func (ec *EngineContext) CheckFunctions() error {
+	if ec.functionCheck == nil {
+		return ec.checkFunctions()
+	}
+	ec.functionCheck.mu.Lock()
+	defer ec.functionCheck.mu.Unlock()
+	return ec.recordFunctionCheck()
+}
+
+// functionsChecked retorna o resultado de CheckFunctions para a versão atual do registro de
+// funções, executando a verificação apenas se ela ainda não foi feita para essa versão. Um
+// motor criado sem NewEngineContext verifica as políticas a cada chamada.
+func (ec *EngineContext) functionsChecked() error {
+	check := ec.functionCheck
+	if check == nil {
+		return ec.checkFunctions()
+	}
+	check.mu.Lock()
+	defer check.mu.Unlock()
+	if check.done && check.version == ec.Functions.Version() {
+		return check.err
+	}
+	return ec.recordFunctionCheck()
+}
+
+// recordFunctionCheck verifica as chamadas de função e guarda o resultado com a versão do
+// registro lida antes da verificação; deve ser chamada com ec.functionCheck.mu travado.
+func (ec *EngineContext) recordFunctionCheck() error {
+	check := ec.functionCheck
+	check.version = ec.Functions.Version()
+	check.err = ec.checkFunctions()
+	check.done = true
+	return check.err
+}
+
+func (ec *EngineContext) checkFunctions() error {
+	var messages []string
+	for _, issue := range ec.CheckPolicies() {
+		if issue.Code == policy.LintUnknownFunction || issue.Code == policy.LintFunctionCall {
+			messages = append(messages, issue.String())
+		}
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("chamadas de função inválidas:\n%s", strings.Join(messages, "\n"))
+	}
+	return nil
+}
+
+// CheckPolicies analisa as políticas do motor sem executá-las (ver policy.Lint), verificando
+// os caminhos e tipos das regras contra os schemas de requisição e resposta configurados.
+func (ec *EngineContext) CheckPolicies() []policy.Issue {
+	return policy.Lint(ec.Policies, nil, policy.LintOptions{
+		RequestSchema:  ec.RequestSchema,
+		ResponseSchema: ec.ResponseSchema,
+		Expressions:    ec.Expressions,
+		Functions:      ec.Functions,
+		Tables:         ec.tableNames(),
+	})
+}
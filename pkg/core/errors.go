@@ -0,0 +1,73 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorKind classifica as falhas do processamento de uma requisição.
+type ErrorKind string
+
+const (
+	ErrorKindRequest  ErrorKind = "request"  // Requisição malformada (ex.: JSON inválido)
+	ErrorKindSchema   ErrorKind = "schema"   // Dados não conformes ao schema da requisição
+	ErrorKindPolicy   ErrorKind = "policy"   // Alguma política não passou
+	ErrorKindInternal ErrorKind = "internal" // Falha inesperada do motor
+)
+
+// EngineError é o erro retornado por ProcessRequest, com a classificação da falha.
+// A mensagem é a mesma do erro original.
+type EngineError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *EngineError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EngineError) Unwrap() error {
+	return e.Err
+}
+
+// KindOf retorna a classificação de um erro do motor. Erros não classificados são internos.
+func KindOf(err error) ErrorKind {
+	var engineErr *EngineError
+	if errors.As(err, &engineErr) {
+		return engineErr.Kind
+	}
+	return ErrorKindInternal
+}
+
+// HTTPStatus mapeia a classificação para o status HTTP usado pelos adaptadores:
+// 400 para requisição ou schema inválidos, 422 para falha de política e 500 para erros internos.
+func (k ErrorKind) HTTPStatus() int {
+	switch k {
+	case ErrorKindRequest, ErrorKindSchema:
+		return http.StatusBadRequest
+	case ErrorKindPolicy:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorBody é o corpo JSON das respostas de erro dos adaptadores HTTP.
+type ErrorBody struct {
+	Status  string `json:"status"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// NewErrorBody monta o corpo de erro a partir de um erro do motor.
+func NewErrorBody(err error) ErrorBody {
+	return ErrorBody{
+		Status:  "error",
+		Kind:    string(KindOf(err)),
+		Message: err.Error(),
+	}
+}
+
+func newEngineError(kind ErrorKind, err error) *EngineError {
+	return &EngineError{Kind: kind, Err: err}
+}
@@ -9,4 +9,14 @@ func ParseS3Path(path string) (bucket, key string) {
 		return parts[0], ""
 	}
 	return parts[0], parts[1]
-}
\ No newline at end of file
+}
+
+// ParseSSMPath retorna o nome do parâmetro de "ssm://nome". Nomes hierárquicos podem ser
+// escritos com ou sem a barra inicial ("ssm://app/politicas" ou "ssm:///app/politicas").
+func ParseSSMPath(path string) string {
+	name := strings.TrimPrefix(path, "ssm://")
+	if strings.Contains(name, "/") && !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -90,4 +91,22 @@ func GetParameter(client SSMClient, parameterPath string, withDecryption bool) (
 	}
 
 	return []byte(*output.Parameter.Value), nil
-}
\ No newline at end of file
+}
+
+// ReadSource lê o conteúdo bruto de uma origem: "s3://bucket/chave", "ssm://parametro" ou caminho local.
+func ReadSource(source string) ([]byte, error) {
+	ld, err := NewLoader(source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := ld.(type) {
+	case *S3Loader:
+		bucket, key := ParseS3Path(v.Path)
+		return GetObject(v.Client, bucket, key)
+	case *SSMLoader:
+		return GetParameter(v.Client, ParseSSMPath(v.Path), true)
+	default:
+		return os.ReadFile(source)
+	}
+}
@@ -1,8 +1,13 @@
 package core
 
 import (
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
 	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
 )
 
 // Para checagem de tipos na validação de schema e operações
@@ -16,18 +21,72 @@ type Context struct {
 	Source string `json:"source,omitempty"`
 }
 
+// toMap converte o contexto para o formato exposto às regras como $ctx.
+func (c *Context) toMap() map[string]interface{} {
+	if c == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"userId": c.UserID,
+		"source": c.Source,
+	}
+}
+
 // Request representa a estrutura da requisição recebida
 // - ID: Identificador único da solicitação
 // - Timestamp: Data e hora da solicitação (opcional)
 // - Data: Dados para aplicação das políticas (obrigatório)
 // - Context: Contexto da solicitação (opcional)
-// - Lista de políticas a serem aplicadas (obrigatório)
+// - Policies: políticas, conjuntos ou "tag:<nome>" a aplicar (opcional se houver o conjunto "default")
+// - IncludeChanges: inclui na resposta o JSON Patch das alterações feitas pelas políticas (opcional)
+// - Explain: inclui na resposta o trace estruturado da avaliação das regras (opcional)
+// - Tenant: tenant cujos valores de parâmetros (EngineContext.Tenants) são aplicados (opcional)
+// - Params: valores de parâmetros por política, aplicados sobre os do tenant (opcional)
 type Request struct {
-	ID        string                 `json:"id"`
-	Timestamp string                 `json:"timestamp,omitempty"`
-	Context   *Context               `json:"context,omitempty"`
-	Data      map[string]interface{} `json:"data"`
-	Policies  []string               `json:"policies"`
+	ID             string                 `json:"id"`
+	Timestamp      string                 `json:"timestamp,omitempty"`
+	Context        *Context               `json:"context,omitempty"`
+	Data           map[string]interface{} `json:"data"`
+	Policies       []string               `json:"policies"`
+	IncludeChanges bool                   `json:"includeChanges,omitempty"`
+	Explain        bool                   `json:"explain,omitempty"`
+	Tenant         string                 `json:"tenant,omitempty"`
+	Params         policy.ParamOverrides  `json:"params,omitempty"`
+	Headers        map[string]string      `json:"-"` // Cabeçalhos HTTP do evento de origem, expostos como $meta.headers
+	InputType      string                 `json:"-"` // Tipo do evento de origem ($meta.inputType); vazio usa EngineContext.InputType
+}
+
+// inputType retorna o tipo do evento informado pelo adaptador ou, sem ele, o tipo configurado no motor.
+func (r *Request) inputType(configured string) string {
+	if r.InputType != "" {
+		return r.InputType
+	}
+	return configured
+}
+
+// headersToMap converte os cabeçalhos para o formato exposto às regras, com nomes em minúsculas.
+func (r *Request) headersToMap() map[string]interface{} {
+	headers := make(map[string]interface{}, len(r.Headers))
+	for name, value := range r.Headers {
+		headers[strings.ToLower(name)] = value
+	}
+	return headers
+}
+
+// ExecutionOptions controla comportamentos opcionais da execução de políticas.
+// - Explain: registra o trace de avaliação de cada regra (PolicyExecutionResult.Trace)
+// - Changes: registra as alterações feitas nos dados (PolicyExecutionResult.Changes)
+// - Context: valores expostos às regras como $ctx (somente leitura)
+// - Meta: valores expostos às regras como $meta (somente leitura); "policy" é preenchido pelo motor
+// - Tenant: tenant cujos valores de parâmetros são aplicados sobre os padrões das políticas
+// - Params: valores de parâmetros por política, aplicados sobre os do tenant
+type ExecutionOptions struct {
+	Explain bool
+	Changes bool
+	Context map[string]interface{}
+	Meta    map[string]interface{}
+	Tenant  string
+	Params  policy.ParamOverrides
 }
 
 // EngineContext mantém a configuração para o motor de processamento de requisições.
@@ -35,5 +94,33 @@ type EngineContext struct {
 	RequestSchema  *schema.Schema                     // Definição de schema simplificada
 	ResponseSchema *schema.Schema                     // Definição de schema simplificada
 	Policies       map[string]policy.PolicyDefinition // Mapa do nome da política para sua definição
+	PolicySets     map[string][]string                // Conjuntos nomeados de políticas (ver policy.Catalog)
+	Tenants        map[string]policy.ParamOverrides   // Valores dos parâmetros das políticas por tenant (ver policy.Catalog)
+	Expressions    map[string]string                  // Expressões nomeadas, lidas nas regras como "@nome"
+	Functions      *rules.FunctionRegistry            // Funções chamáveis nas regras; nil disponibiliza apenas as nativas
+	Tables         *rules.TableSet                    // Tabelas de referência em cache, lidas nas regras por TABLE e LOOKUP
+	TableSources   map[string]policy.TableSource      // Origens das tabelas recarregadas por ReloadTables (ver policy.Catalog)
 	InputType      string                             // Ex: "APIGatewayProxy", "ALB", "Local"
+	Environment    map[string]interface{}             // Valores expostos às regras como $env (somente leitura)
+	Clock          func() time.Time                   // Relógio das regras (NOW(), AGE()) e da resposta; nil usa o horário atual
+	MaxIterations  int                                // Itens de cada FOREACH, ALL ou ANY nas regras; 0 usa rules.DefaultMaxIterations
+
+	functionCheck *functionCheck // Última verificação das chamadas de função (ver CheckFunctions)
+}
+
+// functionCheck guarda o resultado de CheckFunctions para a versão do registro de funções
+// verificada, para que Process só verifique as políticas de novo depois de um Register.
+type functionCheck struct {
+	mu      sync.Mutex
+	done    bool
+	version uint64
+	err     error
+}
+
+// now retorna o horário atual segundo o relógio do motor.
+func (ec *EngineContext) now() time.Time {
+	if ec.Clock == nil {
+		return time.Now()
+	}
+	return ec.Clock()
 }
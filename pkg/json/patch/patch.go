@@ -0,0 +1,150 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Operações suportadas (RFC 6902)
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Operation representa uma operação JSON Patch (RFC 6902).
+// Além dos membros definidos pela RFC, carrega o valor anterior (OldValue) e a origem
+// da alteração (Policy e RuleIndex). Membros desconhecidos são ignorados por
+// consumidores de JSON Patch, então o documento continua aplicável.
+type Operation struct {
+	Op        string      `json:"op"`
+	Path      string      `json:"path"`
+	Value     interface{} `json:"value"`
+	OldValue  interface{} `json:"oldValue"`
+	Policy    string      `json:"policy"`
+	RuleIndex int         `json:"ruleIndex"`
+}
+
+// MarshalJSON omite "value" em remoções e "oldValue" em adições, preservando valores null explícitos.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Op        string       `json:"op"`
+		Path      string       `json:"path"`
+		Value     *interface{} `json:"value,omitempty"`
+		OldValue  *interface{} `json:"oldValue,omitempty"`
+		Policy    string       `json:"policy,omitempty"`
+		RuleIndex *int         `json:"ruleIndex,omitempty"`
+	}{
+		Op:     o.Op,
+		Path:   o.Path,
+		Policy: o.Policy,
+	}
+	if o.Op != OpRemove {
+		out.Value = &o.Value
+	}
+	if o.Op != OpAdd {
+		out.OldValue = &o.OldValue
+	}
+	if o.Policy != "" {
+		out.RuleIndex = &o.RuleIndex
+	}
+	return json.Marshal(out)
+}
+
+// String retorna a operação em formato legível (ex.: "replace /desconto: 15 -> 22.5").
+func (o Operation) String() string {
+	switch o.Op {
+	case OpAdd:
+		return fmt.Sprintf("%s %s: %v", o.Op, o.Path, o.Value)
+	case OpRemove:
+		return fmt.Sprintf("%s %s (era %v)", o.Op, o.Path, o.OldValue)
+	default:
+		return fmt.Sprintf("%s %s: %v -> %v", o.Op, o.Path, o.OldValue, o.Value)
+	}
+}
+
+// Diff calcula as operações necessárias para transformar 'before' em 'after'.
+// Os valores das operações são copiados, então alterações posteriores nos dados não as afetam.
+// Objetos são comparados chave a chave; arrays elemento a elemento quando apenas
+// crescem ou mantêm o tamanho, e substituídos por inteiro nos demais casos.
+func Diff(before, after interface{}) []Operation {
+	return diff("", before, after, nil)
+}
+
+func diff(path string, before, after interface{}, ops []Operation) []Operation {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(b) {
+			if _, exists := a[key]; !exists {
+				ops = append(ops, Operation{Op: OpRemove, Path: path + "/" + EscapePointer(key), OldValue: Clone(b[key])})
+			}
+		}
+		for _, key := range sortedKeys(a) {
+			childPath := path + "/" + EscapePointer(key)
+			if old, exists := b[key]; exists {
+				ops = diff(childPath, old, a[key], ops)
+			} else {
+				ops = append(ops, Operation{Op: OpAdd, Path: childPath, Value: Clone(a[key])})
+			}
+		}
+		return ops
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) < len(b) {
+			break
+		}
+		for i := range b {
+			ops = diff(fmt.Sprintf("%s/%d", path, i), b[i], a[i], ops)
+		}
+		for i := len(b); i < len(a); i++ {
+			ops = append(ops, Operation{Op: OpAdd, Path: fmt.Sprintf("%s/%d", path, i), Value: Clone(a[i])})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		ops = append(ops, Operation{Op: OpReplace, Path: path, Value: Clone(after), OldValue: Clone(before)})
+	}
+	return ops
+}
+
+// Clone faz uma cópia profunda de valores JSON (mapas, arrays e escalares).
+func Clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = Clone(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = Clone(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// EscapePointer escapa um token de JSON Pointer (RFC 6901).
+func EscapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		before   interface{}
+		after    interface{}
+		expected []Operation
+	}{
+		{
+			name:     "sem alterações",
+			before:   map[string]interface{}{"valor": 150.0},
+			after:    map[string]interface{}{"valor": 150.0},
+			expected: nil,
+		},
+		{
+			name:     "chave adicionada",
+			before:   map[string]interface{}{"valor": 150.0},
+			after:    map[string]interface{}{"valor": 150.0, "desconto": 15.0},
+			expected: []Operation{{Op: OpAdd, Path: "/desconto", Value: 15.0}},
+		},
+		{
+			name:     "valor substituído em objeto aninhado",
+			before:   map[string]interface{}{"impostos": map[string]interface{}{"iss": 7.5}},
+			after:    map[string]interface{}{"impostos": map[string]interface{}{"iss": 8.0}},
+			expected: []Operation{{Op: OpReplace, Path: "/impostos/iss", Value: 8.0, OldValue: 7.5}},
+		},
+		{
+			name:     "chave removida",
+			before:   map[string]interface{}{"a/b": "x", "c": 1.0},
+			after:    map[string]interface{}{"c": 1.0},
+			expected: []Operation{{Op: OpRemove, Path: "/a~1b", OldValue: "x"}},
+		},
+		{
+			name:     "item adicionado ao array",
+			before:   map[string]interface{}{"itens": []interface{}{1.0}},
+			after:    map[string]interface{}{"itens": []interface{}{1.0, 2.0}},
+			expected: []Operation{{Op: OpAdd, Path: "/itens/1", Value: 2.0}},
+		},
+		{
+			name:     "array reduzido é substituído",
+			before:   map[string]interface{}{"itens": []interface{}{1.0, 2.0}},
+			after:    map[string]interface{}{"itens": []interface{}{2.0}},
+			expected: []Operation{{Op: OpReplace, Path: "/itens", Value: []interface{}{2.0}, OldValue: []interface{}{1.0, 2.0}}},
+		},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			actual := Diff(cenario.before, cenario.after)
+			assert.Equal(t, cenario.expected, actual, cenario.name)
+		}
+	})
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		op       Operation
+		expected string
+	}{
+		{name: "add", op: Operation{Op: OpAdd, Path: "/a", Value: 1.0}, expected: `{"op":"add","path":"/a","value":1}`},
+		{name: "remove", op: Operation{Op: OpRemove, Path: "/a", OldValue: 1.0}, expected: `{"op":"remove","path":"/a","oldValue":1}`},
+		{name: "replace com null", op: Operation{Op: OpReplace, Path: "/a", Value: nil, OldValue: 1.0}, expected: `{"op":"replace","path":"/a","value":null,"oldValue":1}`},
+		{name: "com origem", op: Operation{Op: OpAdd, Path: "/a", Value: "x", Policy: "P", RuleIndex: 0}, expected: `{"op":"add","path":"/a","value":"x","policy":"P","ruleIndex":0}`},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			actual, err := json.Marshal(cenario.op)
+			assert.NoError(t, err, cenario.name)
+			assert.JSONEq(t, cenario.expected, string(actual), cenario.name)
+		}
+	})
+}
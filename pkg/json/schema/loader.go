@@ -71,7 +71,7 @@ func (l *ssmLoader) Load() (*Schema, error) {
 		withDecryption = true
 	)
 
-	data, err := loader.GetParameter(l.loader.Client, l.loader.Path, withDecryption)
+	data, err := loader.GetParameter(l.loader.Client, loader.ParseSSMPath(l.loader.Path), withDecryption)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateDependencies verifica se todas as dependências declaradas existem e se não há ciclos.
+func ValidateDependencies(policies map[string]PolicyDefinition) error {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var missing []string
+	for _, name := range names {
+		for _, dep := range policies[name].DependsOn {
+			if _, exists := policies[dep]; !exists {
+				missing = append(missing, fmt.Sprintf("'%s' (requerida por '%s')", dep, name))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("dependências de política não definidas: %s", strings.Join(missing, ", "))
+	}
+
+	_, err := ResolveOrder(policies, names)
+	return err
+}
+
+// ResolveOrder ordena as políticas solicitadas de forma que cada uma execute depois das
+// suas dependências, incluindo automaticamente as dependências não solicitadas.
+// A ordem da requisição é preservada sempre que as dependências permitirem.
+// Nomes não definidos são mantidos na posição em que aparecem, para que a execução
+// reporte o erro da política ausente.
+func ResolveOrder(policies map[string]PolicyDefinition, names []string) ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var ordered []string
+
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependência circular entre políticas: %s", strings.Join(append(stack, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dep := range policies[name].DependsOn {
+			if _, exists := policies[dep]; !exists {
+				return fmt.Errorf("política '%s' depende de '%s', que não está definida", name, dep)
+			}
+			if err := visit(dep, append(stack, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var dependencyPolicies = map[string]PolicyDefinition{
+	"ValidarIdade":     {Name: "ValidarIdade", Rules: []string{`$.idade >= 18`}},
+	"CalcularDesconto": {Name: "CalcularDesconto", Rules: []string{`SET $.desconto = 10`}},
+	"AplicarImpostos":  {Name: "AplicarImpostos", DependsOn: []string{"CalcularDesconto"}},
+	"Fechamento":       {Name: "Fechamento", DependsOn: []string{"AplicarImpostos", "ValidarIdade"}},
+}
+
+func TestResolveOrder(t *testing.T) {
+	all_cases := []struct {
+		name      string
+		requested []string
+		expected  []string
+	}{
+		{name: "sem dependências", requested: []string{"ValidarIdade"}, expected: []string{"ValidarIdade"}},
+		{name: "ordem invertida", requested: []string{"AplicarImpostos", "CalcularDesconto"}, expected: []string{"CalcularDesconto", "AplicarImpostos"}},
+		{name: "dependências incluídas", requested: []string{"Fechamento"}, expected: []string{"CalcularDesconto", "AplicarImpostos", "ValidarIdade", "Fechamento"}},
+		{name: "política não definida mantida", requested: []string{"Inexistente", "ValidarIdade"}, expected: []string{"Inexistente", "ValidarIdade"}},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			actual, err := ResolveOrder(dependencyPolicies, cenario.requested)
+			assert.NoError(t, err, cenario.name)
+			assert.Equal(t, cenario.expected, actual, cenario.name)
+		}
+	})
+}
+
+func TestParseDependencies(t *testing.T) {
+	t.Run("formatos lista e mapa", func(t *testing.T) {
+		policies, err := Parse([]byte(`
+CalcularDesconto:
+- $.valor > 100
+AplicarImpostos:
+  dependsOn: [CalcularDesconto]
+  rules:
+  - SET $.impostos.iss = EXP($.valor * 0.05)
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"$.valor > 100"}, policies["CalcularDesconto"].Rules)
+		assert.Equal(t, []string{"CalcularDesconto"}, policies["AplicarImpostos"].DependsOn)
+		assert.Equal(t, "AplicarImpostos", policies["AplicarImpostos"].Name)
+	})
+
+	t.Run("dependência ausente", func(t *testing.T) {
+		_, err := Parse([]byte(`
+AplicarImpostos:
+  dependsOn: [CalcularDesconto]
+  rules: []
+`))
+		assert.ErrorContains(t, err, "'CalcularDesconto' (requerida por 'AplicarImpostos')")
+	})
+
+	t.Run("ciclo", func(t *testing.T) {
+		_, err := Parse([]byte(`
+A:
+  dependsOn: [B]
+B:
+  dependsOn: [A]
+`))
+		assert.ErrorContains(t, err, "dependência circular entre políticas: A -> B -> A")
+	})
+}
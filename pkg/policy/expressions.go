@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+// ValidateExpressions verifica as expressões nomeadas do arquivo de políticas: nomes que
+// podem ser lidos como "@nome", valores que podem ser interpretados e referências entre
+// expressões sem ciclos.
+func ValidateExpressions(expressions map[string]string) error {
+	names := make([]string, 0, len(expressions))
+	refs := make(map[string][]string, len(expressions))
+	for name, expr := range expressions {
+		root, segments, err := rules.ParsePath(rules.RootVariables + name)
+		if err != nil || root != rules.RootVariables || len(segments) != 1 {
+			return fmt.Errorf("nome de expressão inválido: '%s'", name)
+		}
+		operand, err := rules.ParseOperand(expr)
+		if err != nil {
+			return fmt.Errorf("expressão '%s' inválida: %v", name, err)
+		}
+		names = append(names, name)
+		refs[name] = variableRefs(operand)
+	}
+	sort.Strings(names)
+
+	done := make(map[string]bool)
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		for i, pending := range stack {
+			if pending == name {
+				return fmt.Errorf("ciclo entre expressões nomeadas: %v", append(stack[i:], name))
+			}
+		}
+		if done[name] {
+			return nil
+		}
+		for _, ref := range refs[name] {
+			if _, isExpr := expressions[ref]; isExpr {
+				if err := visit(ref, append(stack, name)); err != nil {
+					return err
+				}
+			}
+		}
+		done[name] = true
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variableRefs retorna os nomes das variáveis ("@nome") lidas pelo operando.
+func variableRefs(operand *rules.Operand) []string {
+	var names []string
+	operand.Walk(func(o *rules.Operand) {
+		if o.Kind != rules.OperandPath {
+			return
+		}
+		if root, segments, err := rules.ParsePath(o.Text); err == nil && root == rules.RootVariables && len(segments) > 0 {
+			names = append(names, segments[0].Key)
+		}
+	})
+	return names
+}
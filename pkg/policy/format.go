@@ -0,0 +1,218 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// entryKeyOrder é a ordem canônica das chaves de uma política declarada como mapa.
+var entryKeyOrder = []string{"dependsOn", "tags", "numeric", "params", "rules"}
+
+// Format normaliza um arquivo de políticas válido (ver ParseCatalog) no formato canônico:
+//   - uma linha em branco entre as entradas de primeiro nível, preservando sua ordem;
+//   - listas em bloco no mesmo nível da chave ("- regra"), mapas indentados com 2 espaços;
+//   - chaves das políticas na ordem dependsOn, tags, numeric, params, rules;
+//   - aspas apenas quando o YAML exige;
+//   - regras reescritas no formato canônico (ver rules.FormatRule). Regras que não podem ser
+//     interpretadas são mantidas como estão, para que o lint as aponte;
+//   - valores das expressões nomeadas reescritos no formato canônico (ver rules.FormatOperand).
+//
+// Comentários de cabeçalho e de linha são preservados.
+func Format(content []byte) ([]byte, error) {
+	if _, err := ParseCatalog(content); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("arquivo de políticas inválido: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return []byte{}, nil
+	}
+	root := doc.Content[0]
+
+	var buf bytes.Buffer
+	writeComment(&buf, doc.HeadComment, "")
+	writeComment(&buf, root.HeadComment, "")
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		switch {
+		case key.Value == ExpressionsKey:
+			formatExpressions(value)
+		case !isReservedKey(key.Value):
+			formatRules(value)
+			if value.Kind == yaml.MappingNode {
+				value = orderedEntry(value)
+			}
+		}
+		writeKeyValue(&buf, key, value, "")
+	}
+
+	writeComment(&buf, root.FootComment, "")
+	writeComment(&buf, doc.FootComment, "")
+	return buf.Bytes(), nil
+}
+
+// formatRules reescreve no formato canônico as regras de uma política (lista simples ou
+// "rules" do mapa).
+func formatRules(value *yaml.Node) {
+	if value.Kind == yaml.MappingNode {
+		value = mappingValue(value, "rules")
+	}
+	if value == nil || value.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range value.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		if formatted, err := rules.FormatRule(item.Value); err == nil {
+			item.Value = formatted
+		}
+	}
+}
+
+// formatExpressions reescreve no formato canônico os valores das expressões nomeadas.
+func formatExpressions(value *yaml.Node) {
+	if value.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 1; i < len(value.Content); i += 2 {
+		item := value.Content[i]
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		if formatted, err := rules.FormatOperand(item.Value); err == nil {
+			item.Value = formatted
+		}
+	}
+}
+
+// orderedEntry retorna uma cópia do mapa da política com as chaves conhecidas na ordem canônica
+// e as demais na ordem original.
+func orderedEntry(node *yaml.Node) *yaml.Node {
+	ordered := *node
+	ordered.Content = make([]*yaml.Node, 0, len(node.Content))
+	used := make(map[int]bool)
+	for _, name := range entryKeyOrder {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				ordered.Content = append(ordered.Content, node.Content[i], node.Content[i+1])
+				used[i] = true
+			}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !used[i] {
+			ordered.Content = append(ordered.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	return &ordered
+}
+
+func writeKeyValue(buf *bytes.Buffer, key, value *yaml.Node, indent string) {
+	writeComment(buf, key.HeadComment, indent)
+	buf.WriteString(indent + formatScalar(key.Value) + ":")
+
+	switch {
+	case value.Kind == yaml.ScalarNode || value.Kind == yaml.AliasNode:
+		buf.WriteString(" " + formatValue(value) + lineComment(value, key))
+	case len(value.Content) == 0 && value.Kind == yaml.SequenceNode:
+		buf.WriteString(" []" + lineComment(value, key))
+	case len(value.Content) == 0:
+		buf.WriteString(" {}" + lineComment(value, key))
+	case value.Kind == yaml.SequenceNode:
+		buf.WriteString(lineComment(key) + "\n")
+		writeSequence(buf, value, indent)
+	default:
+		buf.WriteString(lineComment(key) + "\n")
+		writeMapping(buf, value, indent+"  ")
+	}
+	if value.Kind == yaml.ScalarNode || value.Kind == yaml.AliasNode || len(value.Content) == 0 {
+		buf.WriteString("\n")
+	}
+	writeComment(buf, value.FootComment, indent)
+	writeComment(buf, key.FootComment, indent)
+}
+
+func writeMapping(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		writeKeyValue(buf, node.Content[i], node.Content[i+1], indent)
+	}
+}
+
+func writeSequence(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	for _, item := range node.Content {
+		writeComment(buf, item.HeadComment, indent)
+		if item.Kind == yaml.ScalarNode {
+			buf.WriteString(indent + "- " + formatScalar(item.Value) + lineComment(item) + "\n")
+			writeComment(buf, item.FootComment, indent)
+			continue
+		}
+		// Itens compostos não fazem parte do formato de políticas; são emitidos em fluxo.
+		flow := *item
+		flow.Style = yaml.FlowStyle
+		out, _ := yaml.Marshal(&flow)
+		buf.WriteString(indent + "- " + strings.TrimSpace(string(out)) + "\n")
+	}
+}
+
+// formatValue escreve o valor de uma chave. Números e booleanos escritos sem aspas (ex.: o
+// valor de uma expressão nomeada) continuam sem aspas.
+func formatValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode && node.Style == 0 {
+		switch node.Tag {
+		case "!!int", "!!float", "!!bool":
+			return node.Value
+		}
+	}
+	return formatScalar(node.Value)
+}
+
+// formatScalar escreve o valor como o YAML o serializa em uma linha (sem aspas quando possível),
+// desde que seja lido de volta como o mesmo texto; caso contrário usa aspas duplas.
+func formatScalar(value string) string {
+	if !strings.ContainsAny(value, "\n\r\t") {
+		out, err := yaml.Marshal(value)
+		if err == nil {
+			plain := strings.TrimSuffix(string(out), "\n")
+			var back string
+			if !strings.Contains(plain, "\n") && yaml.Unmarshal([]byte(plain), &back) == nil && back == value {
+				return plain
+			}
+		}
+	}
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+func lineComment(nodes ...*yaml.Node) string {
+	for _, node := range nodes {
+		if node.LineComment != "" {
+			return " " + node.LineComment
+		}
+	}
+	return ""
+}
+
+func writeComment(buf *bytes.Buffer, comment, indent string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString(indent + line + "\n")
+	}
+}
@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "listas em fluxo e indentação",
+			input:    "A: ['$.x == \"a\"', \"$.y > 1\"]\nB:\n    - $.z < 2\n",
+			expected: "A:\n- $.x == \"a\"\n- $.y > 1\n\nB:\n- $.z < 2\n",
+		},
+		{
+			name:     "ordem canônica das chaves",
+			input:    "A:\n  rules: [$.x > 1]\n  numeric: decimal\n  tags: [p]\nB:\n  rules: [$.y > 1]\n  dependsOn: [A]\n",
+			expected: "A:\n  tags:\n  - p\n  numeric: decimal\n  rules:\n  - $.x > 1\n\nB:\n  dependsOn:\n  - A\n  rules:\n  - $.y > 1\n",
+		},
+		{
+			name:     "aspas apenas quando necessárias",
+			input:    "A: {tags: [p], rules: [\"$.x > 1\", \"123\", \"true\"]}\nsets: {default: [A, \"tag:p\"]}\n",
+			expected: "A:\n  tags:\n  - p\n  rules:\n  - $.x > 1\n  - \"123\"\n  - \"true\"\n\nsets:\n  default:\n  - A\n  - tag:p\n",
+		},
+		{
+			name:     "comentários preservados",
+			input:    "# Políticas\n\n# sobre A\nA:\n- $.x > 1 # limite\n# fim\n",
+			expected: "# Políticas\n\n# sobre A\nA:\n- $.x > 1 # limite\n# fim\n",
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, err := Format([]byte(cenario.input))
+			require.NoError(t, err)
+			assert.Equal(t, cenario.expected, string(actual))
+
+			// O formato canônico é estável e preserva o catálogo
+			again, err := Format(actual)
+			require.NoError(t, err)
+			assert.Equal(t, string(actual), string(again))
+
+			before, _ := ParseCatalog([]byte(cenario.input))
+			after, _ := ParseCatalog(actual)
+			assert.Equal(t, before, after)
+		})
+	}
+
+	t.Run("arquivo de exemplo já está no formato", func(t *testing.T) {
+		content, err := os.ReadFile("../../examples/policy.yaml")
+		require.NoError(t, err)
+
+		actual, err := Format(content)
+		require.NoError(t, err)
+		assert.Equal(t, string(content), string(actual))
+	})
+
+	t.Run("regras no formato canônico", func(t *testing.T) {
+		input := "A:\n- $.x>1\n- \"if $.tipo == 'a' then set $.y = exp($.x*2)\"\n- $.z => 1\n- let t = exp(@frete*2)\nB:\n  rules: [\"$.e not in ['SP',RJ]\", \"@t>1\"]\nsets:\n  default: [A, B]\nexpressions: {frete: 'exp($.peso*2.5)'}\n"
+		expected := "A:\n- $.x > 1\n- IF $.tipo == \"a\" THEN SET $.y = EXP($.x * 2)\n- $.z => 1\n- LET t = EXP(@frete * 2)\n\nB:\n  rules:\n  - $.e NOT IN [\"SP\", \"RJ\"]\n  - '@t > 1'\n\nsets:\n  default:\n  - A\n  - B\n\nexpressions:\n  frete: EXP($.peso * 2.5)\n"
+
+		actual, err := Format([]byte(input))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(actual))
+
+		again, err := Format(actual)
+		require.NoError(t, err)
+		assert.Equal(t, string(actual), string(again))
+	})
+
+	t.Run("arquivo inválido", func(t *testing.T) {
+		_, err := Format([]byte("A:\n  dependsOn: [B]\n  rules: [$.x > 1]\n"))
+		assert.Error(t, err)
+	})
+}
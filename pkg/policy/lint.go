@@ -0,0 +1,440 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+// Severidades dos problemas apontados por Lint
+const (
+	SeverityError   = "error"   // A regra falha em tempo de execução
+	SeverityWarning = "warning" // A regra executa, mas provavelmente não como pretendido
+)
+
+// Códigos dos problemas apontados por Lint
+const (
+	LintEmptyPolicy       = "empty-policy"
+	LintDuplicateRule     = "duplicate-rule"
+	LintSyntax            = "syntax"
+	LintUnknownOperator   = "unknown-operator"
+	LintUnknownFunction   = "unknown-function"
+	LintUnknownPath       = "unknown-path"
+	LintTypeMismatch      = "type-mismatch"
+	LintShadowedSet       = "shadowed-set"
+	LintConstantCondition = "constant-condition"
+	LintUnreachableRule   = "unreachable-rule"
+	LintOptionalPath      = "optional-path"
+	LintResponseSchema    = "response-schema"
+	LintUndefinedVariable = "undefined-variable"
+	LintFunctionCall      = "function-call"
+	LintUnknownTable      = "unknown-table"
+)
+
+// Issue é um problema encontrado por Lint. Rule é -1 quando o problema é da política.
+type Issue struct {
+	Policy   string   `json:"policy"`
+	Rule     int      `json:"rule"`
+	Position Position `json:"position"`
+	Severity string   `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	location := fmt.Sprintf("política '%s'", i.Policy)
+	if i.Rule >= 0 {
+		location += fmt.Sprintf(", regra %d", i.Rule)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", i.Severity, location, i.Message, i.Code)
+}
+
+// LintOptions configura as verificações de Lint. Com os schemas, Lint também verifica os
+// tipos das regras (ver typeCheck).
+type LintOptions struct {
+	RequestSchema  *schema.Schema          // Opcional: caminhos lidos e seus tipos na requisição
+	ResponseSchema *schema.Schema          // Opcional: destinos de SET, ADD e DELETE na resposta
+	Expressions    map[string]string       // Expressões nomeadas do arquivo (ver Catalog.Expressions)
+	Functions      *rules.FunctionRegistry // Funções registradas pelo motor; nil considera apenas as nativas
+	Tables         map[string]TableSource  // Tabelas de referência (ver Catalog.Tables); nil não verifica os nomes
+}
+
+// LintContent interpreta um arquivo de políticas e aplica Lint com as posições das regras.
+func LintContent(content []byte, opts LintOptions) ([]Issue, error) {
+	catalog, err := ParseCatalog(content)
+	if err != nil {
+		return nil, err
+	}
+	sm, err := NewSourceMap(content)
+	if err != nil {
+		return nil, err
+	}
+	opts.Expressions = catalog.Expressions
+	opts.Tables = catalog.Tables
+	return Lint(catalog.Policies, sm, opts), nil
+}
+
+// Lint analisa as regras sem executá-las: erros de sintaxe, operadores e funções desconhecidos,
+// comparações entre tipos incompatíveis, SETs sobrescritos sem leitura, condições constantes,
+// regras inalcançáveis, variáveis lidas sem definição e, com o schema da requisição, caminhos inexistentes. Com os schemas,
+// verifica também os tipos dos operandos e os destinos das alterações (ver typeCheck).
+// O SourceMap é opcional; sem ele as posições ficam zeradas.
+func Lint(policies map[string]PolicyDefinition, sm *SourceMap, opts LintOptions) []Issue {
+	l := &linter{sm: sm, opts: opts}
+
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make(map[string][]*rules.Node, len(policies))
+	for _, name := range names {
+		nodes := make([]*rules.Node, len(policies[name].Rules))
+		for i, rule := range policies[name].Rules {
+			node, err := rules.Parse(rule)
+			if err != nil {
+				l.syntaxIssue(name, i, err)
+				continue
+			}
+			nodes[i] = node
+			node.Walk(func(n *rules.Node) {
+				if n.Kind == rules.NodeSet || n.Kind == rules.NodeAdd {
+					l.writes = append(l.writes, n.Target)
+				}
+			})
+		}
+		parsed[name] = nodes
+	}
+
+	for _, name := range names {
+		l.lintPolicy(name, policies[name], parsed[name])
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Policy != l.issues[j].Policy {
+			return l.issues[i].Policy < l.issues[j].Policy
+		}
+		return l.issues[i].Rule < l.issues[j].Rule
+	})
+	return l.issues
+}
+
+type linter struct {
+	sm     *SourceMap
+	opts   LintOptions
+	writes []string // Caminhos alterados por SET e ADD em qualquer política
+	issues []Issue
+}
+
+func (l *linter) report(policyName string, rule int, severity, code, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{
+		Policy:   policyName,
+		Rule:     rule,
+		Position: l.sm.Rule(policyName, rule),
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) syntaxIssue(policyName string, rule int, err error) {
+	code := LintSyntax
+	if syntaxErr, ok := err.(*rules.SyntaxError); ok && syntaxErr.Operator != "" {
+		code = LintUnknownOperator
+	}
+	l.report(policyName, rule, SeverityError, code, "%v", err)
+}
+
+func (l *linter) lintPolicy(name string, definition PolicyDefinition, nodes []*rules.Node) {
+	if len(definition.Rules) == 0 {
+		l.report(name, -1, SeverityWarning, LintEmptyPolicy, "política não possui regras")
+	}
+	seen := make(map[string]int, len(definition.Rules))
+	for i, rule := range definition.Rules {
+		if first, ok := seen[rule]; ok {
+			l.report(name, i, SeverityWarning, LintDuplicateRule, "regra duplica a regra %d: '%s'", first, rule)
+			continue
+		}
+		seen[rule] = i
+	}
+
+	var guards []string // Caminhos garantidamente presentes após as condições já avaliadas
+	defined := make(map[string]bool)
+	for i, node := range nodes {
+		if node == nil {
+			continue
+		}
+		l.lintRule(name, i, node)
+		l.checkVariables(name, i, node, defined)
+		if l.opts.RequestSchema != nil || l.opts.ResponseSchema != nil {
+			l.typeCheck(name, i, node, guards)
+		}
+		if !isActionNode(node) {
+			guards = append(guards, presentWhen(node, true)...)
+		}
+		// A política para na primeira condição não atendida
+		if value, known := constantCondition(node); known && !value && i+1 < len(nodes) {
+			l.report(name, i+1, SeverityWarning, LintUnreachableRule, "regras a partir desta nunca executam: a regra %d é sempre falsa", i)
+		}
+	}
+	l.checkShadowedSets(name, nodes)
+}
+
+func (l *linter) lintRule(name string, index int, node *rules.Node) {
+	node.Walk(func(n *rules.Node) {
+		switch n.Kind {
+		case rules.NodeCompare:
+			l.checkComparison(name, index, n)
+		case rules.NodeOr:
+			if value, known := constantCondition(n.Left); known && value {
+				l.report(name, index, SeverityWarning, LintUnreachableRule, "lado direito do OR nunca é avaliado: '%s'", n.Right.Text)
+			}
+		}
+		for _, operand := range n.Operands() {
+			operand.Walk(func(o *rules.Operand) {
+				l.checkOperand(name, index, o)
+			})
+		}
+	})
+}
+
+// checkVariables aponta leituras de variáveis ("@nome") que não são expressões nomeadas nem
+// foram definidas por LET em uma regra anterior da política, e registra as definidas pela regra.
+func (l *linter) checkVariables(name string, index int, node *rules.Node, defined map[string]bool) {
+	node.Walk(func(n *rules.Node) {
+		for _, operand := range n.Operands() {
+			for _, ref := range variableRefs(operand) {
+				if _, isExpr := l.opts.Expressions[ref]; !isExpr && !defined[ref] {
+					l.report(name, index, SeverityError, LintUndefinedVariable, "variável @%s não definida: use LET em uma regra anterior ou declare-a em %s", ref, ExpressionsKey)
+					defined[ref] = true // Reporta cada variável uma única vez
+				}
+			}
+		}
+	})
+	node.Walk(func(n *rules.Node) {
+		if n.Kind == rules.NodeLet {
+			defined[strings.TrimPrefix(n.Target, rules.RootVariables)] = true
+		}
+	})
+}
+
+func (l *linter) checkComparison(name string, index int, n *rules.Node) {
+	switch n.Op {
+	case ">", ">=", "<", "<=", "BETWEEN", "BETWEEN EXCLUSIVE":
+		for _, operand := range numericOperands(n) {
+			if operand.Kind == rules.OperandLiteral && !operand.IsNumber() {
+				l.report(name, index, SeverityError, LintTypeMismatch, "operador %s exige números, mas '%s' não é numérico", n.Op, operand.Text)
+			}
+		}
+	case "MATCHES":
+		if n.RHS.Kind == rules.OperandLiteral {
+			if _, err := regexp.Compile(fmt.Sprint(n.RHS.Value)); err != nil {
+				l.report(name, index, SeverityError, LintSyntax, "expressão regular inválida %s: %v", n.RHS.Text, err)
+			}
+		}
+	}
+	if value, known := constantCondition(n); known {
+		l.report(name, index, SeverityWarning, LintConstantCondition, "condição sempre %s: '%s'", describeBool(value), n.Text)
+	}
+}
+
+func (l *linter) checkOperand(name string, index int, o *rules.Operand) {
+	switch o.Kind {
+	case rules.OperandCall:
+		if !l.opts.Functions.IsFunction(o.Func) {
+			l.report(name, index, SeverityError, LintUnknownFunction, "função desconhecida '%s'", o.Func)
+		} else if err := l.opts.Functions.Check(o); err != nil {
+			l.report(name, index, SeverityError, LintFunctionCall, "%v", err)
+		} else {
+			l.checkTable(name, index, o)
+		}
+	case rules.OperandExpression:
+		for _, operand := range []*rules.Operand{o.Left, o.Right} {
+			if operand != nil && operand.Kind == rules.OperandLiteral && !operand.IsNumber() {
+				l.report(name, index, SeverityError, LintTypeMismatch, "operando '%s' de %s não é numérico", operand.Text, o.Text)
+			}
+		}
+	case rules.OperandPath:
+		l.checkPath(name, index, o.Text)
+	}
+}
+
+// checkTable aponta chamadas de TABLE e LOOKUP a tabelas não declaradas. Nomes que não são
+// literais só são conhecidos na avaliação.
+func (l *linter) checkTable(name string, index int, call *rules.Operand) {
+	if l.opts.Tables == nil || (call.Func != "TABLE" && call.Func != "LOOKUP") || len(call.Args) == 0 {
+		return
+	}
+	arg := call.Args[0]
+	table, ok := arg.Value.(string)
+	if arg.Kind != rules.OperandLiteral || !ok {
+		return
+	}
+	if _, declared := l.opts.Tables[table]; !declared {
+		l.report(name, index, SeverityError, LintUnknownTable, "tabela '%s' não declarada em %s", table, TablesKey)
+	}
+}
+
+// checkPath aponta caminhos lidos que não existem no schema da requisição. Caminhos
+// criados por SET ou ADD (em qualquer política) não são verificados.
+func (l *linter) checkPath(name string, index int, path string) {
+	if l.opts.RequestSchema == nil {
+		return
+	}
+	root, segments, err := rules.ParsePath(path)
+	if err != nil || root != "" {
+		return
+	}
+	for _, written := range l.writes {
+		if pathsOverlap(path, written) {
+			return
+		}
+	}
+	if !lookupSchema(*l.opts.RequestSchema, segments).exists {
+		l.report(name, index, SeverityWarning, LintUnknownPath, "caminho %s não existe no schema da requisição", path)
+	}
+}
+
+// checkShadowedSets aponta SETs sobrescritos por um SET posterior da mesma política sem que o
+// valor seja lido entre eles. Uma condição entre os dois interrompe a análise, pois pode
+// encerrar a política e manter o primeiro valor.
+func (l *linter) checkShadowedSets(name string, nodes []*rules.Node) {
+	for i, node := range nodes {
+		if node == nil || node.Kind != rules.NodeSet {
+			continue
+		}
+		for j := i + 1; j < len(nodes); j++ {
+			next := nodes[j]
+			if next == nil || !isActionNode(next) {
+				break
+			}
+			if next.Kind == rules.NodeSet && next.Target == node.Target && !readsPath(next, node.Target) {
+				l.report(name, i, SeverityWarning, LintShadowedSet, "SET %s é sobrescrito pela regra %d sem ser lido", node.Target, j)
+				break
+			}
+			if touchesPath(next, node.Target) {
+				break
+			}
+		}
+	}
+}
+
+func isActionNode(n *rules.Node) bool {
+	switch n.Kind {
+	case rules.NodeSet, rules.NodeAdd, rules.NodeDelete, rules.NodeLet, rules.NodeIf:
+		return true
+	case rules.NodeLoop:
+		return n.Quantifier == rules.LoopForEach
+	}
+	return false
+}
+
+// readsPath indica se algum operando do nó (ou de seus descendentes) lê o caminho.
+func readsPath(n *rules.Node, path string) bool {
+	reads := false
+	n.Walk(func(child *rules.Node) {
+		for _, operand := range child.Operands() {
+			operand.Walk(func(o *rules.Operand) {
+				if o.Kind == rules.OperandPath && pathsOverlap(o.Text, path) {
+					reads = true
+				}
+			})
+		}
+	})
+	return reads
+}
+
+// touchesPath indica se o nó lê ou altera o caminho.
+func touchesPath(n *rules.Node, path string) bool {
+	touches := readsPath(n, path)
+	n.Walk(func(child *rules.Node) {
+		if child.Target != "" && pathsOverlap(child.Target, path) {
+			touches = true
+		}
+	})
+	return touches
+}
+
+// pathsOverlap indica se um caminho é igual, ancestral ou descendente do outro.
+func pathsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(b, a) && (b[len(a)] == '.' || b[len(a)] == '[')
+}
+
+// constantCondition avalia estaticamente condições que não dependem dos dados: comparações
+// entre literais e comparações de um caminho com ele mesmo. OR é constante quando o lado
+// esquerdo é sempre verdadeiro ou quando ambos os lados são constantes.
+func constantCondition(n *rules.Node) (bool, bool) {
+	switch n.Kind {
+	case rules.NodeCompare:
+		if n.Quantifier == "" && n.LHS.Kind == rules.OperandPath && n.RHS.Kind == rules.OperandPath && n.LHS.Text == n.RHS.Text {
+			switch n.Op {
+			case "==", ">=", "<=":
+				return true, true
+			case "!=", ">", "<":
+				return false, true
+			}
+		}
+		if dependsOnData(n.LHS) || dependsOnData(n.RHS) {
+			return false, false
+		}
+		passed, _, err := rules.EvaluateRule(n.Text, map[string]interface{}{})
+		if err != nil {
+			return false, false
+		}
+		return passed, true
+	case rules.NodeOr:
+		left, leftKnown := constantCondition(n.Left)
+		if leftKnown && left {
+			return true, true
+		}
+		right, rightKnown := constantCondition(n.Right)
+		if leftKnown && rightKnown {
+			return right, true
+		}
+	}
+	return false, false
+}
+
+// numericOperands retorna os operandos de uma comparação de ordem (>, <, BETWEEN...) que
+// precisam ser números. Se algum operando é uma chamada de função, que pode resultar em
+// data, texto e datas também são aceitos e nenhum operando é retornado.
+func numericOperands(n *rules.Node) []*rules.Operand {
+	operands := []*rules.Operand{n.LHS, n.RHS}
+	if n.RHS.Kind == rules.OperandRange {
+		operands = []*rules.Operand{n.LHS, n.RHS.Left, n.RHS.Right}
+	}
+	for _, operand := range operands {
+		if operand.Kind == rules.OperandCall {
+			return nil
+		}
+	}
+	return operands
+}
+
+// dependsOnData indica se o operando contém caminhos ou chamadas de função.
+func dependsOnData(operand *rules.Operand) bool {
+	depends := false
+	operand.Walk(func(o *rules.Operand) {
+		if o.Kind == rules.OperandPath || o.Kind == rules.OperandCall {
+			depends = true
+		}
+	})
+	return depends
+}
+
+func describeBool(value bool) string {
+	if value {
+		return "verdadeira"
+	}
+	return "falsa"
+}
@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+)
+
+func TestLint(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rules    []string
+		expected []string // Código e regra de cada problema, na ordem
+	}{
+		{name: "sem problemas", rules: []string{`$.idade >= 18`, `SET $.desconto = EXP($.valor * 0.1)`, `$.moeda == "BRL" OR $.moeda == "USD"`}},
+		{name: "política vazia", rules: nil, expected: []string{"empty-policy:-1"}},
+		{name: "regra duplicada", rules: []string{`$.a > 1`, `$.a > 1`}, expected: []string{"duplicate-rule:1"}},
+		{name: "erro de sintaxe", rules: []string{`SET $.x = EXP($.a * )`}, expected: []string{"syntax:0"}},
+		{name: "operador desconhecido", rules: []string{`$.nome LIKE "a%"`}, expected: []string{"unknown-operator:0"}},
+		{name: "função desconhecida", rules: []string{`COUNT($.itens) < 3`}, expected: []string{"unknown-function:0"}},
+		{name: "função com argumentos demais", rules: []string{`UPPER($.nome, $.sobrenome) == "A"`, `ROUND($.valor) > 1`}, expected: []string{"function-call:0"}},
+		{name: "expressão regular inválida", rules: []string{`$.nome MATCHES "(a"`, `$.nome MATCHES "^a"`}, expected: []string{"syntax:0"}},
+		{name: "funções de texto", rules: []string{`UPPER(TRIM($.nome)) STARTS WITH "A"`, `SET $.nome = CONCAT($.a, "-", $.b)`}},
+		{name: "funções de verificação e conversão", rules: []string{`EXISTS($.email)`, `IF IS_EMPTY($.tags) THEN SET $.tags = []`, `TO_NUMBER($.quantidade, 0) > 1`}},
+		{name: "datas comparadas", rules: []string{`$.vencimento < NOW()`, `DATE($.inicio) BETWEEN "2024-01-01" AND NOW()`}},
+		{name: "BETWEEN com string", rules: []string{`$.valor BETWEEN 1 AND "dez"`}, expected: []string{"type-mismatch:0"}},
+		{
+			name:     "variáveis lidas sem LET",
+			rules:    []string{`LET base = EXP($.valor * 2)`, `@base > 10`, `IF @base > 1 THEN LET faixa = "alta"`, `@faixa == "alta"`, `@outra > 1`, `@outra.x > 2`},
+			expected: []string{"undefined-variable:4"},
+		},
+		{name: "BETWEEN EXCLUSIVE com string", rules: []string{`$.valor BETWEEN EXCLUSIVE "um" AND 10`}, expected: []string{"type-mismatch:0"}},
+		{name: "quantificadores e listas", rules: []string{`ANY $.itens[*].valor > 100`, `ALL $.tags == $.tags`, `$.estado IN $.estados`, `$.tags INTERSECTS ["vip"]`}},
+		{
+			name:     "laços",
+			rules:    []string{`FOREACH item IN $.itens DO LET ultimo = item.valor`, `ALL item IN $.itens: item.valor > @ultimo`, `ANY item IN $.itens: item.nome > "a"`},
+			expected: []string{"type-mismatch:2"},
+		},
+		{name: "string comparada com >", rules: []string{`$.nome > "abc"`, `$.valor > "10"`}, expected: []string{"type-mismatch:0"}},
+		{name: "literal não numérico em EXP", rules: []string{`SET $.x = EXP($.a * abc)`}, expected: []string{"type-mismatch:0"}},
+		{
+			name:     "SET sobrescrito sem leitura",
+			rules:    []string{`SET $.x = 1`, `SET $.y = 2`, `SET $.x = 3`},
+			expected: []string{"shadowed-set:0"},
+		},
+		{
+			name:  "SET lido antes de ser sobrescrito",
+			rules: []string{`SET $.x = 1`, `SET $.y = $.x`, `SET $.x = 3`, `SET $.x = EXP($.x + 1)`},
+		},
+		{
+			name:  "condição entre os SETs",
+			rules: []string{`SET $.x = 1`, `$.valor > 0`, `SET $.x = 3`},
+		},
+		{
+			name:     "condição sempre falsa",
+			rules:    []string{`1 > 2`, `SET $.x = 1`},
+			expected: []string{"constant-condition:0", "unreachable-rule:1"},
+		},
+		{
+			name:     "caminho comparado com ele mesmo",
+			rules:    []string{`IF $.a == $.a THEN SET $.b = 1`},
+			expected: []string{"constant-condition:0"},
+		},
+		{
+			name:     "OR com lado esquerdo sempre verdadeiro",
+			rules:    []string{`"a" == "a" OR $.b > 1`},
+			expected: []string{"unreachable-rule:0", "constant-condition:0"},
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			issues := Lint(map[string]PolicyDefinition{"P": {Name: "P", Rules: cenario.rules}}, nil, LintOptions{})
+			var codes []string
+			for _, issue := range issues {
+				codes = append(codes, fmt.Sprintf("%s:%d", issue.Code, issue.Rule))
+			}
+			assert.Equal(t, cenario.expected, codes)
+		})
+	}
+}
+
+func TestLintContent(t *testing.T) {
+	requestSchema := schema.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"valor": map[string]interface{}{"type": "number"},
+			"itens": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"preco": map[string]interface{}{"type": "number"}}, "required": []interface{}{"preco"}},
+			},
+			"extras": map[string]interface{}{"type": "object"},
+		},
+		"required": []interface{}{"valor", "itens", "extras"},
+	}
+	content := []byte(`A:
+- $.valor > 0
+- $.itens[0].preco > 0
+- $.itens[0].quantidade > 0
+- $.extras.qualquer == 1
+- SET $.total = EXP($.valor * 2)
+- $.total.bruto > 0
+B:
+  rules:
+    - $.ctx > 1
+    - $ctx.userId == "u1"
+C:
+- SET $.frete = EXP(@frete + 1)
+expressions:
+  frete: EXP($.valor * 0.1)
+`)
+
+	issues, err := LintContent(content, LintOptions{RequestSchema: &requestSchema})
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, Issue{
+		Policy:   "A",
+		Rule:     2,
+		Position: Position{Line: 4, Column: 3},
+		Severity: SeverityWarning,
+		Code:     LintUnknownPath,
+		Message:  "caminho $.itens[0].quantidade não existe no schema da requisição",
+	}, issues[0])
+	assert.Equal(t, Position{Line: 10, Column: 7}, issues[1].Position)
+	assert.Equal(t, "warning: política 'B', regra 0: caminho $.ctx não existe no schema da requisição [unknown-path]", issues[1].String())
+
+	_, err = LintContent([]byte("A: {dependsOn: [B]}\n"), LintOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core/loader"
+)
+
+type (
+	// PolicyLoader carrega um arquivo de políticas (ver ParseCatalog) de uma origem local, S3 ou SSM.
+	PolicyLoader interface {
+		Load() (*Catalog, error)
+	}
+
+	localLoader struct {
+		loader *loader.LocalLoader
+	}
+
+	s3Loader struct {
+		loader *loader.S3Loader
+	}
+
+	ssmLoader struct {
+		loader *loader.SSMLoader
+	}
+)
+
+// NewLoader escolhe o loader pela origem: "s3://bucket/chave", "ssm://parametro" ou caminho local.
+func NewLoader(source string) (PolicyLoader, error) {
+	ld, err := loader.NewLoader(source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := ld.(type) {
+	case *loader.LocalLoader:
+		return &localLoader{
+			loader: v,
+		}, nil
+	case *loader.S3Loader:
+		return &s3Loader{
+			loader: v,
+		}, nil
+	case *loader.SSMLoader:
+		return &ssmLoader{
+			loader: v,
+		}, nil
+	default:
+		return nil, fmt.Errorf("tipo de loader não suportado para políticas: %T", v)
+	}
+}
+
+func (l *localLoader) Load() (*Catalog, error) {
+	data, err := os.ReadFile(l.loader.Path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de políticas: %v", err)
+	}
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+	catalog.resolveTables(filepath.Dir(l.loader.Path))
+	return catalog, nil
+}
+
+func (l *ssmLoader) Load() (*Catalog, error) {
+	data, err := loader.GetParameter(l.loader.Client, loader.ParseSSMPath(l.loader.Path), true)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+func (l *s3Loader) Load() (*Catalog, error) {
+	bucket, key := loader.ParseS3Path(l.loader.Path)
+
+	data, err := loader.GetObject(l.loader.Client, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core/loader"
+)
+
+type fakeSSMClient struct {
+	parameters map[string]string
+}
+
+func (c *fakeSSMClient) GetParameter(_ context.Context, params *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	value, ok := c.parameters[*params.Name]
+	if !ok {
+		return nil, &types.ParameterNotFound{}
+	}
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{Name: params.Name, Value: &value}}, nil
+}
+
+const loaderPolicies = "ValidarIdade:\n- $.idade >= 18\nsets:\n  default: [ValidarIdade]\n"
+
+func TestLoader(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(loaderPolicies), 0o644))
+
+		ld, err := NewLoader(path)
+		require.NoError(t, err)
+		catalog, err := ld.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"$.idade >= 18"}, catalog.Policies["ValidarIdade"].Rules)
+		assert.Equal(t, []string{"ValidarIdade"}, catalog.Sets[DefaultSet])
+
+		_, err = (&localLoader{loader: &loader.LocalLoader{Path: path + ".inexistente"}}).Load()
+		assert.Error(t, err)
+	})
+
+	t.Run("tabelas relativas ao arquivo local", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "policy.yaml")
+		content := loaderPolicies + "tables:\n  estados: tabelas/estados.json\n  aliquotas: s3://bucket/aliquotas.csv\n  faixas: /dados/faixas.yaml\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		ld, err := NewLoader(path)
+		require.NoError(t, err)
+		catalog, err := ld.Load()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "tabelas", "estados.json"), catalog.Tables["estados"].Source)
+		assert.Equal(t, "s3://bucket/aliquotas.csv", catalog.Tables["aliquotas"].Source)
+		assert.Equal(t, "/dados/faixas.yaml", catalog.Tables["faixas"].Source)
+	})
+
+	t.Run("ssm", func(t *testing.T) {
+		client := &fakeSSMClient{parameters: map[string]string{"/app/politicas": loaderPolicies}}
+
+		for _, source := range []string{"ssm:///app/politicas", "ssm://app/politicas"} {
+			catalog, err := (&ssmLoader{loader: &loader.SSMLoader{Path: source, Client: client}}).Load()
+			require.NoError(t, err, source)
+			assert.Contains(t, catalog.Policies, "ValidarIdade", source)
+		}
+
+		_, err := (&ssmLoader{loader: &loader.SSMLoader{Path: "ssm://outro", Client: client}}).Load()
+		assert.Error(t, err)
+	})
+}
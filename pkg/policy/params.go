@@ -0,0 +1,246 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+	"gopkg.in/yaml.v3"
+)
+
+var paramNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// paramRefRe e quotedRe localizam ":nome" no texto de regras que rules.Parse não aceita,
+// ignorando o conteúdo de literais entre aspas.
+var (
+	paramRefRe = regexp.MustCompile(`(?:^|[\s(,\[]):([A-Za-z_][A-Za-z0-9_]*)`)
+	quotedRe   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+)
+
+// paramTypes são os tipos aceitos na declaração de parâmetros.
+var paramTypes = map[string]bool{rules.TypeString: true, rules.TypeNumber: true, rules.TypeBoolean: true, rules.TypeArray: true, rules.TypeObject: true}
+
+// Param é um parâmetro declarado por uma política e lido nas regras como ":nome". Sem
+// Default, o valor deve vir de um tenant ou da requisição.
+type Param struct {
+	Type    string      `json:"type"`
+	Default interface{} `json:"default,omitempty"`
+}
+
+// UnmarshalYAML aceita o valor padrão, com o tipo deduzido dele ("taxaDesconto: 0.1"), ou o
+// mapa com "type" e, opcionalmente, "default" ("limite: {type: number}").
+func (p *Param) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode && mappingValue(node, "type") != nil {
+		var spec struct {
+			Type    string      `yaml:"type"`
+			Default interface{} `yaml:"default"`
+		}
+		if err := node.Decode(&spec); err != nil {
+			return err
+		}
+		p.Type, p.Default = spec.Type, normalizeValue(spec.Default)
+		return nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	p.Default = normalizeValue(value)
+	p.Type = valueType(p.Default)
+	return nil
+}
+
+// ParamOverrides são valores de parâmetros por política: nome da política -> parâmetro -> valor.
+type ParamOverrides map[string]map[string]interface{}
+
+// UnmarshalYAML normaliza os valores como os de uma requisição JSON (ex.: números como float64).
+func (o *ParamOverrides) UnmarshalYAML(node *yaml.Node) error {
+	var values map[string]map[string]interface{}
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	for _, params := range values {
+		for name, value := range params {
+			params[name] = normalizeValue(value)
+		}
+	}
+	*o = values
+	return nil
+}
+
+// normalizeValue converte um valor decodificado do YAML para os tipos de um JSON decodificado
+// (números como float64, objetos como map[string]interface{}).
+func normalizeValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(content, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// valueType retorna o tipo de parâmetro do valor ("" para null ou tipos não suportados).
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return rules.TypeString
+	case float64, float32, int, int64:
+		return rules.TypeNumber
+	case bool:
+		return rules.TypeBoolean
+	case []interface{}:
+		return rules.TypeArray
+	case map[string]interface{}:
+		return rules.TypeObject
+	}
+	return ""
+}
+
+// check verifica se o valor tem o tipo declarado do parâmetro.
+func (p Param) check(name string, value interface{}) error {
+	if actual := valueType(value); actual != p.Type {
+		if value == nil {
+			actual = "null"
+		} else if actual == "" {
+			actual = fmt.Sprintf("%T", value)
+		}
+		return fmt.Errorf("parâmetro :%s exige %s, recebeu %s", name, p.Type, actual)
+	}
+	return nil
+}
+
+// ValidateParams verifica os parâmetros das políticas: nomes, tipos, valores padrão do tipo
+// declarado e regras que só leem parâmetros declarados pela própria política.
+func ValidateParams(policies map[string]PolicyDefinition) error {
+	for _, name := range sortedNames(policies) {
+		def := policies[name]
+		for _, paramName := range sortedKeys(def.Params) {
+			param := def.Params[paramName]
+			switch {
+			case !paramNameRe.MatchString(paramName):
+				return fmt.Errorf("política '%s': nome de parâmetro inválido: '%s'", name, paramName)
+			case !paramTypes[param.Type]:
+				return fmt.Errorf("política '%s': parâmetro :%s com tipo inválido '%s'", name, paramName, param.Type)
+			}
+			if param.Default != nil {
+				if err := param.check(paramName, param.Default); err != nil {
+					return fmt.Errorf("política '%s': valor padrão inválido: %v", name, err)
+				}
+			}
+		}
+		for _, rule := range def.Rules {
+			var refs []string
+			if node, err := rules.Parse(rule); err == nil {
+				refs = paramRefs(node)
+			} else {
+				// A execução não usa rules.Parse: uma regra fora da gramática da AST ainda pode
+				// ler parâmetros, então eles são procurados no texto
+				refs = paramRefsText(rule)
+			}
+			for _, ref := range refs {
+				if _, declared := def.Params[ref]; !declared {
+					return fmt.Errorf("política '%s': parâmetro :%s não declarado em params (regra '%s')", name, ref, rule)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateOverrides verifica valores de parâmetros (de um tenant ou de uma requisição): a
+// política existe, declara o parâmetro e o valor tem o tipo declarado.
+func ValidateOverrides(policies map[string]PolicyDefinition, overrides ParamOverrides) error {
+	for _, policyName := range sortedKeys(overrides) {
+		def, exists := policies[policyName]
+		if !exists {
+			return fmt.Errorf("parâmetros para a política '%s', que não existe", policyName)
+		}
+		values := overrides[policyName]
+		for _, name := range sortedKeys(values) {
+			param, declared := def.Params[name]
+			if !declared {
+				return fmt.Errorf("política '%s' não declara o parâmetro :%s", policyName, name)
+			}
+			if err := param.check(name, values[name]); err != nil {
+				return fmt.Errorf("política '%s': %v", policyName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveParams retorna os valores dos parâmetros da política: os padrões, substituídos pelos
+// valores de cada camada, na ordem (ex.: tenant e depois requisição). Um parâmetro sem padrão
+// e sem valor em nenhuma camada é um erro.
+func (p PolicyDefinition) ResolveParams(layers ...ParamOverrides) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(p.Params))
+	for name, param := range p.Params {
+		if param.Default != nil {
+			values[name] = param.Default
+		}
+	}
+	for _, layer := range layers {
+		for name, value := range layer[p.Name] {
+			values[name] = value
+		}
+	}
+	for _, name := range sortedKeys(p.Params) {
+		if _, exists := values[name]; !exists {
+			return nil, fmt.Errorf("política '%s': parâmetro :%s sem valor padrão nem informado", p.Name, name)
+		}
+	}
+	return values, nil
+}
+
+// paramRefs retorna os nomes dos parâmetros (":nome") lidos pela regra.
+func paramRefs(node *rules.Node) []string {
+	var names []string
+	node.Walk(func(n *rules.Node) {
+		for _, operand := range n.Operands() {
+			operand.Walk(func(o *rules.Operand) {
+				if o.Kind != rules.OperandPath {
+					return
+				}
+				if root, segments, err := rules.ParsePath(o.Text); err == nil && root == rules.RootParams && len(segments) > 0 {
+					names = append(names, segments[0].Key)
+				}
+			})
+		}
+	})
+	return names
+}
+
+// paramRefsText retorna os nomes dos parâmetros que aparecem no texto da regra.
+func paramRefsText(rule string) []string {
+	var names []string
+	for _, match := range paramRefRe.FindAllStringSubmatch(quotedRe.ReplaceAllString(rule, `""`), -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func sortedNames(policies map[string]PolicyDefinition) []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParams(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(`
+CalcularDesconto:
+  params:
+    taxaDesconto: 0.1
+    moedas: [BRL, USD]
+    faixa: {type: object, default: {minimo: 100}}
+    limite: {type: number}
+    ativo: true
+  rules:
+  - $.moeda IN :moedas
+  - $.valor <= :limite
+  - SET $.desconto = EXP($.valor * :taxaDesconto)
+tenants:
+  lojaA:
+    CalcularDesconto: {taxaDesconto: 0.12, limite: 1000}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Param{
+		"taxaDesconto": {Type: "number", Default: 0.1},
+		"moedas":       {Type: "array", Default: []interface{}{"BRL", "USD"}},
+		"faixa":        {Type: "object", Default: map[string]interface{}{"minimo": 100.0}},
+		"limite":       {Type: "number"},
+		"ativo":        {Type: "boolean", Default: true},
+	}, catalog.Policies["CalcularDesconto"].Params)
+	assert.Equal(t, map[string]ParamOverrides{
+		"lojaA": {"CalcularDesconto": {"taxaDesconto": 0.12, "limite": 1000.0}},
+	}, catalog.Tenants)
+	assert.NotContains(t, catalog.Policies, TenantsKey)
+
+	all_errors := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "nome inválido", content: "A:\n  params: {taxa-pis: 1}\n  rules: [$.a > 1]\n", message: "política 'A': nome de parâmetro inválido: 'taxa-pis'"},
+		{name: "tipo inválido", content: "A:\n  params: {taxa: {type: decimal}}\n  rules: [$.a > 1]\n", message: "política 'A': parâmetro :taxa com tipo inválido 'decimal'"},
+		{name: "padrão null sem tipo", content: "A:\n  params: {taxa: null}\n  rules: [$.a > 1]\n", message: "política 'A': parâmetro :taxa com tipo inválido ''"},
+		{name: "padrão de outro tipo", content: "A:\n  params: {taxa: {type: number, default: dez}}\n  rules: [$.a > 1]\n", message: "política 'A': valor padrão inválido: parâmetro :taxa exige number, recebeu string"},
+		{name: "parâmetro não declarado", content: "A:\n- $.a > :minimo\n", message: "política 'A': parâmetro :minimo não declarado em params (regra '$.a > :minimo')"},
+		{name: "parâmetro não declarado em regra fora da gramática", content: "A:\n- $.a >> :minimo\n", message: "política 'A': parâmetro :minimo não declarado em params (regra '$.a >> :minimo')"},
+		{name: "parâmetro de outra política", content: "A:\n  params: {minimo: 1}\n  rules: [$.a > :minimo]\nB:\n- $.b > :minimo\n", message: "política 'B': parâmetro :minimo não declarado"},
+		{name: "tenant com política inexistente", content: "A: [$.a > 1]\ntenants:\n  lojaA: {B: {x: 1}}\n", message: "tenant 'lojaA': parâmetros para a política 'B', que não existe"},
+		{name: "tenant com parâmetro não declarado", content: "A: [$.a > 1]\ntenants:\n  lojaA: {A: {x: 1}}\n", message: "tenant 'lojaA': política 'A' não declara o parâmetro :x"},
+		{name: "tenant com valor de outro tipo", content: "A:\n  params: {x: 1}\n  rules: [$.a > :x]\ntenants:\n  lojaA: {A: {x: [1]}}\n", message: "tenant 'lojaA': política 'A': parâmetro :x exige number, recebeu array"},
+		{name: "tenants em formato inválido", content: "A: [$.a > 1]\ntenants: [lojaA]\n", message: "tenants inválidos"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, err := ParseCatalog([]byte(cenario.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	def := PolicyDefinition{
+		Name: "CalcularDesconto",
+		Params: map[string]Param{
+			"taxa":   {Type: "number", Default: 0.1},
+			"moedas": {Type: "array", Default: []interface{}{"BRL"}},
+			"limite": {Type: "number"},
+		},
+	}
+	tenant := ParamOverrides{"CalcularDesconto": {"taxa": 0.12, "limite": 500.0}, "Outra": {"taxa": 1.0}}
+	request := ParamOverrides{"CalcularDesconto": {"limite": 800.0}}
+
+	values, err := def.ResolveParams(tenant, request)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"taxa": 0.12, "moedas": []interface{}{"BRL"}, "limite": 800.0}, values)
+
+	_, err = def.ResolveParams(nil, ParamOverrides{"CalcularDesconto": {"taxa": 0.2}})
+	assert.EqualError(t, err, "política 'CalcularDesconto': parâmetro :limite sem valor padrão nem informado")
+
+	values, err = PolicyDefinition{Name: "A"}.ResolveParams(tenant)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestValidateOverrides(t *testing.T) {
+	policies := map[string]PolicyDefinition{
+		"A": {Name: "A", Params: map[string]Param{"moedas": {Type: "array"}, "nome": {Type: "string"}}},
+	}
+	assert.NoError(t, ValidateOverrides(policies, ParamOverrides{"A": {"moedas": []interface{}{"EUR"}, "nome": "x"}}))
+	assert.NoError(t, ValidateOverrides(policies, nil))
+	assert.EqualError(t, ValidateOverrides(policies, ParamOverrides{"A": {"nome": nil}}), "política 'A': parâmetro :nome exige string, recebeu null")
+	assert.EqualError(t, ValidateOverrides(policies, ParamOverrides{"A": {"moedas": "EUR"}}), "política 'A': parâmetro :moedas exige array, recebeu string")
+}
+
+func TestParamRefsText(t *testing.T) {
+	assert.Equal(t, []string{"minimo", "moedas"}, paramRefsText(`$.a >> :minimo AND ($.m IN :moedas)`))
+	assert.Empty(t, paramRefsText(`$.hora == "10:30" AND $.rotulo == 'a :b'`))
+}
@@ -0,0 +1,238 @@
+package policy
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Chaves reservadas do arquivo de políticas
+const (
+	SetsKey        = "sets"        // Conjuntos de políticas
+	ExpressionsKey = "expressions" // Expressões nomeadas, lidas nas regras como "@nome"
+	TablesKey      = "tables"      // Tabelas de referência, lidas nas regras por TABLE e LOOKUP
+	TenantsKey     = "tenants"     // Valores dos parâmetros das políticas por tenant
+)
+
+// isReservedKey indica se a chave de primeiro nível do arquivo não é uma política.
+func isReservedKey(key string) bool {
+	return key == SetsKey || key == ExpressionsKey || key == TablesKey || key == TenantsKey
+}
+
+// Catalog reúne o conteúdo de um arquivo de políticas: as políticas, os conjuntos nomeados,
+// as expressões nomeadas e as tabelas de referência, compartilhadas por todas as políticas,
+// e os parâmetros de cada tenant.
+type Catalog struct {
+	Policies    map[string]PolicyDefinition
+	Sets        map[string][]string       // Nome do conjunto -> seletores (política, conjunto ou "tag:<nome>")
+	Expressions map[string]string         // Nome -> valor (ex.: "frete": "EXP($.peso * 2.5)")
+	Tables      map[string]TableSource    // Nome -> origem da tabela (carregada à parte, ver LoadTables)
+	Tenants     map[string]ParamOverrides // Tenant -> valores dos parâmetros de suas políticas
+}
+
+// policyFileEntry aceita as duas formas de declarar uma política no arquivo YAML:
+// a lista simples de regras ou o mapa com "rules" e metadados (ex.: "dependsOn", "tags", "numeric",
+// "params").
+type policyFileEntry struct {
+	DependsOn []string         `yaml:"dependsOn"`
+	Tags      []string         `yaml:"tags"`
+	Numeric   string           `yaml:"numeric"`
+	Params    map[string]Param `yaml:"params"`
+	Rules     []string         `yaml:"rules"`
+}
+
+func (e *policyFileEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&e.Rules)
+	}
+	type plain policyFileEntry
+	return node.Decode((*plain)(e))
+}
+
+// Parse lê um arquivo de políticas YAML e retorna apenas as políticas (ver ParseCatalog).
+func Parse(content []byte) (map[string]PolicyDefinition, error) {
+	catalog, err := ParseCatalog(content)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Policies, nil
+}
+
+// ParseCatalog lê um arquivo de políticas YAML e valida dependências, conjuntos, expressões
+// nomeadas, tabelas, parâmetros e tenants declarados.
+//
+//	CalcularDesconto:
+//	  params:
+//	    taxaDesconto: 0.1
+//	  rules:
+//	  - SET $.desconto = EXP($.valor * :taxaDesconto)
+//	AplicarImpostos:
+//	  dependsOn: [CalcularDesconto]
+//	  tags: [pricing]
+//	  numeric: decimal
+//	  rules:
+//	  - SET $.impostos.iss = EXP($.valor * 0.05)
+//	sets:
+//	  checkout: [ValidarIdade, "tag:pricing"]
+//	  default: [checkout]
+//	expressions:
+//	  desconto: EXP($.valor * 0.1)
+//	tables:
+//	  estados_permitidos: tabelas/estados.json
+//	  aliquotas: {source: s3://bucket/aliquotas.csv, key: tipo}
+//	tenants:
+//	  lojaA:
+//	    CalcularDesconto: {taxaDesconto: 0.12}
+func ParseCatalog(content []byte) (*Catalog, error) {
+	nodes := make(map[string]yaml.Node)
+	if err := yaml.Unmarshal(content, &nodes); err != nil {
+		return nil, fmt.Errorf("arquivo de políticas inválido: %v", err)
+	}
+
+	catalog := &Catalog{
+		Policies:    make(map[string]PolicyDefinition, len(nodes)),
+		Sets:        make(map[string][]string),
+		Expressions: make(map[string]string),
+		Tables:      make(map[string]TableSource),
+		Tenants:     make(map[string]ParamOverrides),
+	}
+	for name, node := range nodes {
+		if name == SetsKey {
+			if err := node.Decode(&catalog.Sets); err != nil {
+				return nil, fmt.Errorf("conjuntos de políticas inválidos: %v", err)
+			}
+			continue
+		}
+		if name == ExpressionsKey {
+			if err := node.Decode(&catalog.Expressions); err != nil {
+				return nil, fmt.Errorf("expressões nomeadas inválidas: %v", err)
+			}
+			continue
+		}
+		if name == TablesKey {
+			if err := node.Decode(&catalog.Tables); err != nil {
+				return nil, fmt.Errorf("tabelas de referência inválidas: %v", err)
+			}
+			continue
+		}
+		if name == TenantsKey {
+			if err := node.Decode(&catalog.Tenants); err != nil {
+				return nil, fmt.Errorf("tenants inválidos: %v", err)
+			}
+			continue
+		}
+
+		var entry policyFileEntry
+		if err := node.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("política '%s' inválida: %v", name, err)
+		}
+		switch entry.Numeric {
+		case "", NumericFloat, NumericDecimal:
+		default:
+			return nil, fmt.Errorf("política '%s' inválida: numeric deve ser %s ou %s, recebeu '%s'", name, NumericFloat, NumericDecimal, entry.Numeric)
+		}
+		catalog.Policies[name] = PolicyDefinition{
+			Name:      name,
+			Rules:     entry.Rules,
+			DependsOn: entry.DependsOn,
+			Tags:      entry.Tags,
+			Numeric:   entry.Numeric,
+			Params:    entry.Params,
+		}
+	}
+
+	if err := ValidateDependencies(catalog.Policies); err != nil {
+		return nil, err
+	}
+	if err := ValidateSets(catalog.Policies, catalog.Sets); err != nil {
+		return nil, err
+	}
+	if err := ValidateExpressions(catalog.Expressions); err != nil {
+		return nil, err
+	}
+	if err := ValidateTables(catalog.Tables); err != nil {
+		return nil, err
+	}
+	if err := ValidateParams(catalog.Policies); err != nil {
+		return nil, err
+	}
+	for _, tenant := range sortedKeys(catalog.Tenants) {
+		if err := ValidateOverrides(catalog.Policies, catalog.Tenants[tenant]); err != nil {
+			return nil, fmt.Errorf("tenant '%s': %v", tenant, err)
+		}
+	}
+	return catalog, nil
+}
+
+// Position é a posição (1-based) de um elemento no arquivo de políticas.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// SourceMap guarda as posições das políticas e de suas regras no arquivo YAML.
+type SourceMap struct {
+	Policies map[string]Position   // Posição da chave de cada política
+	Rules    map[string][]Position // Posição de cada regra, na ordem de PolicyDefinition.Rules
+}
+
+// NewSourceMap localiza as políticas e regras no conteúdo de um arquivo de políticas.
+func NewSourceMap(content []byte) (*SourceMap, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("arquivo de políticas inválido: %v", err)
+	}
+	sm := &SourceMap{Policies: make(map[string]Position), Rules: make(map[string][]Position)}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return sm, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if isReservedKey(key.Value) {
+			continue
+		}
+		sm.Policies[key.Value] = Position{Line: key.Line, Column: key.Column}
+		if value.Kind == yaml.MappingNode {
+			value = mappingValue(value, "rules")
+		}
+		if value == nil || value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range value.Content {
+			sm.Rules[key.Value] = append(sm.Rules[key.Value], Position{Line: item.Line, Column: item.Column})
+		}
+	}
+	return sm, nil
+}
+
+// Rule retorna a posição da regra (ou, sem ela, a da política; zero se desconhecida).
+func (sm *SourceMap) Rule(policyName string, index int) Position {
+	if sm == nil {
+		return Position{}
+	}
+	if positions := sm.Rules[policyName]; index >= 0 && index < len(positions) {
+		return positions[index]
+	}
+	return sm.Policies[policyName]
+}
+
+// RulePositions retorna, para cada política, a posição de cada regra no arquivo YAML,
+// na mesma ordem de PolicyDefinition.Rules.
+func RulePositions(content []byte) (map[string][]Position, error) {
+	sm, err := NewSourceMap(content)
+	if err != nil {
+		return nil, err
+	}
+	return sm.Rules, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
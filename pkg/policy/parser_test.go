@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulePositions(t *testing.T) {
+	content := []byte(`# Políticas
+ValidarIdade:
+- $.idade >= 18
+- $.tipo == "adulto"
+
+CalcularDesconto:
+  tags: [pricing]
+  rules:
+    - $.valor > 100
+    - SET $.desconto = EXP($.valor * 0.1)
+
+Vazia: []
+
+sets:
+  default: [ValidarIdade]
+`)
+
+	positions, err := RulePositions(content)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]Position{
+		"ValidarIdade":     {{Line: 3, Column: 3}, {Line: 4, Column: 3}},
+		"CalcularDesconto": {{Line: 9, Column: 7}, {Line: 10, Column: 7}},
+	}, positions)
+
+	_, err = RulePositions([]byte("A: [\n"))
+	assert.Error(t, err)
+}
+
+func TestSourceMap(t *testing.T) {
+	sm, err := NewSourceMap([]byte("A:\n- $.a > 1\nB: []\n"))
+	require.NoError(t, err)
+	assert.Equal(t, Position{Line: 2, Column: 3}, sm.Rule("A", 0))
+	assert.Equal(t, Position{Line: 3, Column: 1}, sm.Rule("B", 0))
+	assert.Equal(t, Position{Line: 1, Column: 1}, sm.Rule("A", -1))
+	assert.Equal(t, Position{}, (*SourceMap)(nil).Rule("A", 0))
+}
+
+func TestParseNumericMode(t *testing.T) {
+	policies, err := Parse([]byte(`
+AplicarImpostos:
+  numeric: decimal
+  rules:
+  - SET $.impostos.pis = EXP($.valor * 0.0165)
+ValidarIdade:
+- $.idade >= 18
+`))
+	require.NoError(t, err)
+	assert.True(t, policies["AplicarImpostos"].Decimal())
+	assert.False(t, policies["ValidarIdade"].Decimal())
+
+	_, err = Parse([]byte("A:\n  numeric: exato\n  rules: []\n"))
+	assert.ErrorContains(t, err, "numeric deve ser float ou decimal, recebeu 'exato'")
+}
+
+func TestParseExpressions(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(`
+CalcularDesconto:
+- LET base = EXP($.valor - @frete)
+- SET $.desconto = EXP(@base * 0.1)
+expressions:
+  frete: EXP($.peso * 2.5)
+  total: EXP($.valor + @frete)
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"frete": "EXP($.peso * 2.5)", "total": "EXP($.valor + @frete)"}, catalog.Expressions)
+	assert.NotContains(t, catalog.Policies, ExpressionsKey)
+
+	sm, err := NewSourceMap([]byte("A: [$.a > 1]\nexpressions:\n  x: 1\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, sm.Policies, ExpressionsKey)
+
+	all_errors := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "nome inválido", content: "expressions:\n  valor-total: 1\n", message: "nome de expressão inválido: 'valor-total'"},
+		{name: "valor inválido", content: "expressions:\n  x: EXP($.a * )\n", message: "expressão 'x' inválida"},
+		{name: "ciclo", content: "expressions:\n  a: EXP(@b + 1)\n  b: EXP(@a + 1)\n", message: "ciclo entre expressões nomeadas: [a b a]"},
+		{name: "referência a si mesma", content: "expressions:\n  a: COALESCE($.a, @a)\n", message: "ciclo entre expressões nomeadas: [a a]"},
+		{name: "formato inválido", content: "expressions: [a]\n", message: "expressões nomeadas inválidas"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, err := ParseCatalog([]byte(cenario.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
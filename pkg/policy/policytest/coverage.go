@@ -0,0 +1,142 @@
+package policytest
+
+import (
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+// Coverage acumula a cobertura das regras de um arquivo de políticas ao longo dos testes:
+// quantas vezes cada regra executou e quantas vezes cada ramo (lados de OR, IF verdadeiro
+// ou falso) foi exercitado.
+type Coverage struct {
+	Source   string            `json:"source,omitempty"`
+	Policies []*PolicyCoverage `json:"policies"`
+
+	byName map[string]*PolicyCoverage
+}
+
+// PolicyCoverage é a cobertura das regras de uma política.
+type PolicyCoverage struct {
+	Name  string          `json:"name"`
+	Rules []*RuleCoverage `json:"rules"`
+}
+
+// RuleCoverage é a cobertura de uma regra e de seus ramos.
+type RuleCoverage struct {
+	Index    int               `json:"index"`
+	Rule     string            `json:"rule"`
+	Hits     int               `json:"hits"`
+	Branches []*BranchCoverage `json:"branches,omitempty"`
+}
+
+// BranchCoverage é a cobertura de um ramo de uma regra.
+type BranchCoverage struct {
+	rules.Branch
+	Hits int `json:"hits"`
+}
+
+// CoverageSummary totaliza regras e ramos cobertos.
+type CoverageSummary struct {
+	Rules           int     `json:"rules"`
+	RulesCovered    int     `json:"rulesCovered"`
+	Branches        int     `json:"branches"`
+	BranchesCovered int     `json:"branchesCovered"`
+	Percent         float64 `json:"percent"` // Regras e ramos cobertos sobre o total
+}
+
+// NewCoverage prepara a cobertura de todas as regras das políticas, em ordem de nome.
+func NewCoverage(source string, policies map[string]policy.PolicyDefinition) *Coverage {
+	c := &Coverage{Source: source, byName: make(map[string]*PolicyCoverage, len(policies))}
+	for _, name := range sortedKeys(policies) {
+		pc := &PolicyCoverage{Name: name}
+		for i, rule := range policies[name].Rules {
+			rc := &RuleCoverage{Index: i, Rule: rule}
+			for _, branch := range rules.Branches(rule) {
+				rc.Branches = append(rc.Branches, &BranchCoverage{Branch: branch})
+			}
+			pc.Rules = append(pc.Rules, rc)
+		}
+		c.Policies = append(c.Policies, pc)
+		c.byName[name] = pc
+	}
+	return c
+}
+
+// Record contabiliza uma execução a partir dos traces das políticas (ExecutionOptions.Explain).
+func (c *Coverage) Record(results []policy.PolicyExecutionResult) {
+	for _, res := range results {
+		pc, known := c.byName[res.PolicyName]
+		if !known || res.Trace == nil {
+			continue
+		}
+		// Os filhos do trace da política são as regras executadas, na ordem
+		for i, ruleNode := range res.Trace.Children {
+			if i >= len(pc.Rules) {
+				break
+			}
+			rc := pc.Rules[i]
+			rc.Hits++
+			for _, id := range rules.CoveredBranches(ruleNode) {
+				for _, branch := range rc.Branches {
+					if branch.ID == id {
+						branch.Hits++
+					}
+				}
+			}
+		}
+	}
+}
+
+// Covered indica se a regra executou e todos os seus ramos foram exercitados.
+func (r *RuleCoverage) Covered() bool {
+	if r.Hits == 0 {
+		return false
+	}
+	for _, branch := range r.Branches {
+		if branch.Hits == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary totaliza a cobertura da política.
+func (p *PolicyCoverage) Summary() CoverageSummary {
+	var s CoverageSummary
+	p.addTo(&s)
+	return s.withPercent()
+}
+
+// Summary totaliza a cobertura de todas as políticas.
+func (c *Coverage) Summary() CoverageSummary {
+	var s CoverageSummary
+	for _, pc := range c.Policies {
+		pc.addTo(&s)
+	}
+	return s.withPercent()
+}
+
+func (p *PolicyCoverage) addTo(s *CoverageSummary) {
+	for _, rc := range p.Rules {
+		s.Rules++
+		if rc.Hits > 0 {
+			s.RulesCovered++
+		}
+		for _, branch := range rc.Branches {
+			s.Branches++
+			if branch.Hits > 0 {
+				s.BranchesCovered++
+			}
+		}
+	}
+}
+
+func (s CoverageSummary) withPercent() CoverageSummary {
+	total := s.Rules + s.Branches
+	if total == 0 {
+		s.Percent = 100
+		return s
+	}
+	s.Percent = float64(s.RulesCovered+s.BranchesCovered) * 100 / float64(total)
+	return s
+}
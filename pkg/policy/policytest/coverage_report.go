@@ -0,0 +1,163 @@
+package policytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// WriteCoverageText escreve um resumo por política e lista as regras e ramos não exercitados.
+func WriteCoverageText(w io.Writer, coverages []*Coverage) error {
+	var total CoverageSummary
+	for _, c := range coverages {
+		if c.Source != "" {
+			fmt.Fprintf(w, "%s\n", c.Source)
+		}
+		for _, pc := range c.Policies {
+			s := pc.Summary()
+			fmt.Fprintf(w, "  %-30s regras %d/%d  ramos %d/%d  %5.1f%%\n", pc.Name, s.RulesCovered, s.Rules, s.BranchesCovered, s.Branches, s.Percent)
+			for _, rc := range pc.Rules {
+				if rc.Hits == 0 {
+					fmt.Fprintf(w, "    regra %d não executada: %s\n", rc.Index, rc.Rule)
+					continue
+				}
+				for _, branch := range rc.Branches {
+					if branch.Hits == 0 {
+						fmt.Fprintf(w, "    regra %d: ramo %s não exercitado: %s\n", rc.Index, branch.ID, branch.Expr)
+					}
+				}
+			}
+			pc.addTo(&total)
+		}
+	}
+	total = total.withPercent()
+	_, err := fmt.Fprintf(w, "cobertura: regras %d/%d, ramos %d/%d (%.1f%%)\n", total.RulesCovered, total.Rules, total.BranchesCovered, total.Branches, total.Percent)
+	return err
+}
+
+type coverageJSON struct {
+	Source   string               `json:"source,omitempty"`
+	Summary  CoverageSummary      `json:"summary"`
+	Policies []policyCoverageJSON `json:"policies"`
+}
+
+type policyCoverageJSON struct {
+	*PolicyCoverage
+	Summary CoverageSummary `json:"summary"`
+}
+
+// WriteCoverageJSON escreve a cobertura detalhada (por regra e ramo) com os totais de cada nível.
+func WriteCoverageJSON(w io.Writer, coverages []*Coverage) error {
+	report := make([]coverageJSON, 0, len(coverages))
+	for _, c := range coverages {
+		entry := coverageJSON{Source: c.Source, Summary: c.Summary(), Policies: []policyCoverageJSON{}}
+		for _, pc := range c.Policies {
+			entry.Policies = append(entry.Policies, policyCoverageJSON{PolicyCoverage: pc, Summary: pc.Summary()})
+		}
+		report = append(report, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// Classes das linhas no relatório HTML
+const (
+	lineHit     = "hit"     // Regra executada com todos os ramos exercitados
+	linePartial = "partial" // Regra executada com algum ramo não exercitado
+	lineMiss    = "miss"    // Regra não executada
+)
+
+type htmlFile struct {
+	Source  string
+	Summary CoverageSummary
+	Lines   []htmlLine
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	Class  string
+	Title  string
+}
+
+var coverageHTML = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Cobertura de políticas</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; font-family: monospace; width: 100%; }
+td { padding: 0 0.5em; white-space: pre; }
+td.n { color: #888; text-align: right; user-select: none; width: 3em; }
+.hit { background: #dff5dd; }
+.partial { background: #fdf3c8; }
+.miss { background: #f9d6d5; }
+.legend span { padding: 0 0.5em; margin-right: 1em; }
+</style>
+</head>
+<body>
+<p class="legend"><span class="hit">executada</span><span class="partial">ramo não exercitado</span><span class="miss">não executada</span></p>
+{{range .}}<h2>{{.Source}}: {{printf "%.1f" .Summary.Percent}}% (regras {{.Summary.RulesCovered}}/{{.Summary.Rules}}, ramos {{.Summary.BranchesCovered}}/{{.Summary.Branches}})</h2>
+<table>
+{{range .Lines}}<tr{{if .Class}} class="{{.Class}}" title="{{.Title}}"{{end}}><td class="n">{{.Number}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// WriteCoverageHTML escreve o conteúdo de cada arquivo de políticas destacando as linhas das regras
+// conforme a cobertura. 'contents' traz o conteúdo de cada arquivo indexado por Coverage.Source.
+func WriteCoverageHTML(w io.Writer, coverages []*Coverage, contents map[string][]byte) error {
+	var files []htmlFile
+	for _, c := range coverages {
+		content, ok := contents[c.Source]
+		if !ok {
+			return fmt.Errorf("conteúdo do arquivo de políticas '%s' não informado", c.Source)
+		}
+		positions, err := policy.RulePositions(content)
+		if err != nil {
+			return err
+		}
+
+		annotations := make(map[int]htmlLine)
+		for _, pc := range c.Policies {
+			for _, rc := range pc.Rules {
+				if rc.Index < len(positions[pc.Name]) {
+					annotations[positions[pc.Name][rc.Index].Line] = annotateRule(rc)
+				}
+			}
+		}
+
+		file := htmlFile{Source: c.Source, Summary: c.Summary()}
+		for i, text := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			line := annotations[i+1]
+			line.Number, line.Text = i+1, text
+			file.Lines = append(file.Lines, line)
+		}
+		files = append(files, file)
+	}
+	return coverageHTML.Execute(w, files)
+}
+
+func annotateRule(rc *RuleCoverage) htmlLine {
+	if rc.Hits == 0 {
+		return htmlLine{Class: lineMiss, Title: "não executada"}
+	}
+
+	title := []string{fmt.Sprintf("executada %d vez(es)", rc.Hits)}
+	for _, branch := range rc.Branches {
+		title = append(title, fmt.Sprintf("%s: %d", branch.ID, branch.Hits))
+	}
+	class := lineHit
+	if !rc.Covered() {
+		class = linePartial
+	}
+	return htmlLine{Class: class, Title: strings.Join(title, "; ")}
+}
@@ -0,0 +1,118 @@
+package policytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+const coveragePolicies = `ValidarIdade:
+  rules:
+  - $.idade >= 18
+  - $.tipo == "adulto"
+
+AplicarImposto:
+  rules:
+  - IF $.tipo == "servico" THEN SET $.imposto = 10
+`
+
+func newCoverageEngine(t *testing.T) *core.EngineContext {
+	policies, err := policy.Parse([]byte(coveragePolicies))
+	require.NoError(t, err)
+	return core.NewEngineContext(nil, nil, policies, "Local")
+}
+
+func TestRunWithCoverage(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		suite    string
+		expected CoverageSummary
+	}{
+		{
+			name: "política interrompida na primeira regra",
+			suite: `
+tests:
+- policies: [ValidarIdade]
+  data: {idade: 10}
+`,
+			expected: CoverageSummary{Rules: 3, RulesCovered: 1, Branches: 2, Percent: 20},
+		},
+		{
+			name: "IF apenas verdadeiro",
+			suite: `
+tests:
+- policies: [AplicarImposto]
+  data: {tipo: servico}
+`,
+			expected: CoverageSummary{Rules: 3, RulesCovered: 1, Branches: 2, BranchesCovered: 1, Percent: 40},
+		},
+		{
+			name: "todos os ramos",
+			suite: `
+tests:
+- policies: [AplicarImposto, ValidarIdade]
+  data: {tipo: servico, idade: 20}
+- policies: [AplicarImposto]
+  data: {tipo: produto}
+`,
+			expected: CoverageSummary{Rules: 3, RulesCovered: 3, Branches: 2, BranchesCovered: 2, Percent: 100},
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			ec := newCoverageEngine(t)
+			suite, err := ParseSuite([]byte(cenario.suite))
+			require.NoError(t, err)
+
+			coverage := NewCoverage("policy.yaml", ec.Policies)
+			RunWithCoverage(ec, suite, coverage)
+			assert.Equal(t, cenario.expected, coverage.Summary())
+		})
+	}
+}
+
+func TestCoverageReports(t *testing.T) {
+	ec := newCoverageEngine(t)
+	suite, err := ParseSuite([]byte("tests:\n- policies: [AplicarImposto, ValidarIdade]\n  data: {tipo: servico, idade: 10}\n"))
+	require.NoError(t, err)
+	coverage := NewCoverage("policy.yaml", ec.Policies)
+	RunWithCoverage(ec, suite, coverage)
+	coverages := []*Coverage{coverage}
+
+	var text bytes.Buffer
+	require.NoError(t, WriteCoverageText(&text, coverages))
+	assert.Contains(t, text.String(), "regra 0: ramo if.else não exercitado: $.tipo == \"servico\"")
+	assert.Contains(t, text.String(), "regra 1 não executada: $.tipo == \"adulto\"")
+	assert.Contains(t, text.String(), "cobertura: regras 2/3, ramos 1/2 (60.0%)")
+
+	var report bytes.Buffer
+	require.NoError(t, WriteCoverageJSON(&report, coverages))
+	var decoded []struct {
+		Source   string
+		Summary  CoverageSummary
+		Policies []struct {
+			Name  string
+			Rules []struct{ Hits int }
+		}
+	}
+	require.NoError(t, json.Unmarshal(report.Bytes(), &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, 2, decoded[0].Summary.RulesCovered)
+	assert.Equal(t, "AplicarImposto", decoded[0].Policies[0].Name)
+	assert.Equal(t, 1, decoded[0].Policies[0].Rules[0].Hits)
+
+	var page bytes.Buffer
+	require.NoError(t, WriteCoverageHTML(&page, coverages, map[string][]byte{"policy.yaml": []byte(coveragePolicies)}))
+	assert.Contains(t, page.String(), `<tr class="hit" title="executada 1 vez(es)"><td class="n">3</td>`)
+	assert.Contains(t, page.String(), `<tr class="miss" title="não executada"><td class="n">4</td>`)
+	assert.Contains(t, page.String(), `<tr class="partial" title="executada 1 vez(es); if.then: 1; if.else: 0"><td class="n">8</td>`)
+
+	assert.Error(t, WriteCoverageHTML(&page, coverages, nil))
+}
@@ -0,0 +1,171 @@
+package policytest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Formatos de relatório suportados por WriteReport
+const (
+	FormatText  = "text"
+	FormatJUnit = "junit"
+	FormatTAP   = "tap"
+)
+
+// WriteReport escreve os resultados no formato informado (FormatText, FormatJUnit ou FormatTAP).
+func WriteReport(w io.Writer, format string, results []SuiteResult) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(w, results)
+	case FormatJUnit:
+		return WriteJUnit(w, results)
+	case FormatTAP:
+		return WriteTAP(w, results)
+	default:
+		return fmt.Errorf("formato de relatório desconhecido: '%s'", format)
+	}
+}
+
+// WriteText escreve um resumo legível: as falhas de cada caso e uma linha por suíte.
+func WriteText(w io.Writer, results []SuiteResult) error {
+	for _, suite := range results {
+		for _, tc := range suite.Cases {
+			if tc.Passed() {
+				continue
+			}
+			fmt.Fprintf(w, "--- FALHOU: %s / %s (%.3fs)\n", suite.Name, tc.Name, tc.Duration.Seconds())
+			for _, failure := range tc.Failures {
+				fmt.Fprintf(w, "    %s\n", failure)
+			}
+		}
+		status := "ok"
+		if suite.Failed() > 0 {
+			status = "FALHOU"
+		}
+		_, err := fmt.Fprintf(w, "%-6s %s\t%d casos, %d falhas (%.3fs)\n", status, suite.Name, len(suite.Cases), suite.Failed(), suite.Duration.Seconds())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit escreve os resultados no formato JUnit XML, com uma testsuite por arquivo de testes.
+func WriteJUnit(w io.Writer, results []SuiteResult) error {
+	report := junitTestSuites{}
+	var total float64
+	for _, suite := range results {
+		js := junitTestSuite{
+			Name:     suite.Name,
+			Tests:    len(suite.Cases),
+			Failures: suite.Failed(),
+			Time:     seconds(suite.Duration.Seconds()),
+		}
+		for _, tc := range suite.Cases {
+			jc := junitTestCase{Name: tc.Name, ClassName: suite.Name, Time: seconds(tc.Duration.Seconds())}
+			if !tc.Passed() {
+				jc.Failure = &junitFailure{Message: tc.Failures[0], Text: strings.Join(tc.Failures, "\n")}
+			}
+			js.Cases = append(js.Cases, jc)
+		}
+		report.Tests += js.Tests
+		report.Failures += js.Failures
+		total += suite.Duration.Seconds()
+		report.Suites = append(report.Suites, js)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP escreve os resultados no formato TAP versão 13; as falhas vão no bloco YAML do caso.
+func WriteTAP(w io.Writer, results []SuiteResult) error {
+	total := 0
+	for _, suite := range results {
+		total += len(suite.Cases)
+	}
+
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", total)
+	n := 0
+	for _, suite := range results {
+		for _, tc := range suite.Cases {
+			n++
+			if tc.Passed() {
+				fmt.Fprintf(w, "ok %d - %s: %s\n", n, suite.Name, tc.Name)
+				continue
+			}
+			fmt.Fprintf(w, "not ok %d - %s: %s\n", n, suite.Name, tc.Name)
+			fmt.Fprintln(w, "  ---")
+			fmt.Fprintln(w, "  failures:")
+			for _, failure := range tc.Failures {
+				quoted, _ := json.Marshal(failure)
+				fmt.Fprintf(w, "    - %s\n", quoted)
+			}
+			fmt.Fprintln(w, "  ...")
+		}
+	}
+	return nil
+}
+
+func seconds(value float64) string {
+	return fmt.Sprintf("%.3f", value)
+}
+
+func toJSON(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
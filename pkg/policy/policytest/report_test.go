@@ -0,0 +1,60 @@
+package policytest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var reportResults = []SuiteResult{{
+	Name:     "pricing_test.yaml",
+	Duration: 1500 * time.Millisecond,
+	Cases: []CaseResult{
+		{Name: "premium", Duration: time.Second},
+		{Name: "comum", Duration: 500 * time.Millisecond, Failures: []string{`dados: esperado {"a":1}`, "alteração 0"}},
+	},
+}}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, FormatJUnit, reportResults))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" time="1.500">
+  <testsuite name="pricing_test.yaml" tests="2" failures="1" time="1.500">
+    <testcase name="premium" classname="pricing_test.yaml" time="1.000"></testcase>
+    <testcase name="comum" classname="pricing_test.yaml" time="0.500">
+      <failure message="dados: esperado {&#34;a&#34;:1}">dados: esperado {&#34;a&#34;:1}&#xA;alteração 0</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestWriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, FormatTAP, reportResults))
+	assert.Equal(t, `TAP version 13
+1..2
+ok 1 - pricing_test.yaml: premium
+not ok 2 - pricing_test.yaml: comum
+  ---
+  failures:
+    - "dados: esperado {\"a\":1}"
+    - "alteração 0"
+  ...
+`, buf.String())
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, FormatText, reportResults))
+	assert.Equal(t, "--- FALHOU: pricing_test.yaml / comum (0.500s)\n"+
+		"    dados: esperado {\"a\":1}\n"+
+		"    alteração 0\n"+
+		"FALHOU pricing_test.yaml\t2 casos, 1 falhas (1.500s)\n", buf.String())
+
+	assert.Error(t, WriteReport(&buf, "html", reportResults))
+}
@@ -0,0 +1,230 @@
+package policytest
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// SuiteResult é o resultado da execução de uma suíte.
+type SuiteResult struct {
+	Name     string
+	Cases    []CaseResult
+	Duration time.Duration
+}
+
+// CaseResult é o resultado de um caso. Failures lista as expectativas não atendidas.
+type CaseResult struct {
+	Name     string
+	Failures []string
+	Duration time.Duration
+}
+
+// Passed indica se todas as expectativas do caso foram atendidas.
+func (r CaseResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Failed retorna o número de casos que falharam.
+func (r SuiteResult) Failed() int {
+	failed := 0
+	for _, tc := range r.Cases {
+		if !tc.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run executa os casos da suíte no motor informado. Cada caso recebe uma cópia própria dos dados.
+func Run(ec *core.EngineContext, suite *Suite) SuiteResult {
+	return RunWithCoverage(ec, suite, nil)
+}
+
+// RunWithCoverage executa a suíte como Run e registra em 'coverage' (se não for nil)
+// as regras e os ramos exercitados pelos casos.
+func RunWithCoverage(ec *core.EngineContext, suite *Suite, coverage *Coverage) SuiteResult {
+	start := time.Now()
+	result := SuiteResult{Name: suite.Name}
+	for _, tc := range suite.Tests {
+		result.Cases = append(result.Cases, runCase(ec, tc, coverage))
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// RunCase executa um caso e compara o resultado com as expectativas.
+func RunCase(ec *core.EngineContext, tc Case) CaseResult {
+	return runCase(ec, tc, nil)
+}
+
+func runCase(ec *core.EngineContext, tc Case, coverage *Coverage) CaseResult {
+	start := time.Now()
+	if !tc.Now.IsZero() {
+		fixed := *ec
+		fixed.Clock = func() time.Time { return tc.Now }
+		ec = &fixed
+	}
+	data, _ := patch.Clone(tc.Data).(map[string]interface{})
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	results, passed := ec.ExecutePoliciesWithOptions(data, tc.Policies, core.ExecutionOptions{
+		Explain: coverage != nil,
+		Changes: tc.Expect.Changes != nil,
+		Context: tc.Context,
+		Meta:    tc.Meta,
+		Tenant:  tc.Tenant,
+		Params:  tc.Params,
+	})
+	if coverage != nil {
+		coverage.Record(results)
+	}
+
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	if tc.Expect.Passed != nil && *tc.Expect.Passed != passed {
+		fail("resultado geral: esperado passed=%v, obtido %v%s", *tc.Expect.Passed, passed, firstError(results))
+	}
+
+	byName := make(map[string]policy.PolicyExecutionResult, len(results))
+	for _, res := range results {
+		byName[res.PolicyName] = res
+	}
+	for _, name := range sortedKeys(tc.Expect.Policies) {
+		checkPolicy(name, tc.Expect.Policies[name], byName, fail)
+	}
+
+	if tc.Expect.Data != nil && !valuesEqual(normalizedValue(tc.Expect.Data), normalizedValue(data)) {
+		fail("dados: esperado %s, obtido %s", toJSON(tc.Expect.Data), toJSON(data))
+	}
+	if tc.Expect.Changes != nil {
+		checkChanges(tc.Expect.Changes, policy.CollectChanges(results), fail)
+	}
+
+	return CaseResult{Name: tc.Name, Failures: failures, Duration: time.Since(start)}
+}
+
+func checkPolicy(name string, expected PolicyExpectation, results map[string]policy.PolicyExecutionResult, fail func(string, ...interface{})) {
+	res, executed := results[name]
+	if !executed {
+		fail("política '%s': não foi executada", name)
+		return
+	}
+
+	if expected.Passed != nil && *expected.Passed != res.Passed {
+		fail("política '%s': esperado passed=%v, obtido %v%s", name, *expected.Passed, res.Passed, errorSuffix(res.Error))
+	}
+	if expected.Error != "" {
+		if res.Error == nil {
+			fail("política '%s': esperado erro contendo '%s', nenhum erro obtido", name, expected.Error)
+		} else if !strings.Contains(res.Error.Error(), expected.Error) {
+			fail("política '%s': esperado erro contendo '%s', obtido '%v'", name, expected.Error, res.Error)
+		}
+	}
+	for _, index := range sortedKeys(expected.Rules) {
+		if index < 0 || index >= len(res.RuleResults) {
+			fail("política '%s': regra %d não foi executada", name, index)
+			continue
+		}
+		rule := res.RuleResults[index]
+		if rule.Passed != expected.Rules[index] {
+			fail("política '%s': regra %d ('%s'): esperado passed=%v, obtido %v (%s)", name, index, rule.Rule, expected.Rules[index], rule.Passed, rule.Details)
+		}
+	}
+}
+
+func checkChanges(expected []ExpectedChange, actual []patch.Operation, fail func(string, ...interface{})) {
+	if len(expected) != len(actual) {
+		fail("alterações: esperadas %d, obtidas %d: %s", len(expected), len(actual), toJSON(actual))
+		return
+	}
+	for i, exp := range expected {
+		op := actual[i]
+		value := op.Value
+		if op.Op == patch.OpRemove {
+			value = nil
+		}
+		matches := exp.Op == op.Op && exp.Path == op.Path && valuesEqual(exp.Value, normalizedValue(value))
+		if exp.Policy != "" && exp.Policy != op.Policy {
+			matches = false
+		}
+		if !matches {
+			fail("alteração %d: esperada %s, obtida %s", i, toJSON(exp), toJSON(op))
+		}
+	}
+}
+
+// floatTolerance é a diferença relativa aceita entre números, para que resultados como
+// 200 * 0.0165 (3.3000000000000003) correspondam ao valor esperado 3.3.
+const floatTolerance = 1e-9
+
+// valuesEqual compara valores JSON recursivamente, aceitando a tolerância floatTolerance em números.
+func valuesEqual(expected, actual interface{}) bool {
+	switch exp := expected.(type) {
+	case float64:
+		act, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		scale := math.Max(1, math.Max(math.Abs(exp), math.Abs(act)))
+		return math.Abs(exp-act) <= floatTolerance*scale
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok || len(exp) != len(act) {
+			return false
+		}
+		for key, value := range exp {
+			other, exists := act[key]
+			if !exists || !valuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok || len(exp) != len(act) {
+			return false
+		}
+		for i := range exp {
+			if !valuesEqual(exp[i], act[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(expected, actual)
+	}
+}
+
+// normalizedValue garante que valores de operações (que podem conter tipos Go) sejam comparáveis aos esperados.
+func normalizedValue(value interface{}) interface{} {
+	normalize(&value)
+	return value
+}
+
+func firstError(results []policy.PolicyExecutionResult) string {
+	for _, res := range results {
+		if res.Error != nil {
+			return errorSuffix(res.Error)
+		}
+	}
+	return ""
+}
+
+func errorSuffix(err error) string {
+	if err == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%v)", err)
+}
@@ -0,0 +1,299 @@
+package policytest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+func newTestEngine() *core.EngineContext {
+	policies := map[string]policy.PolicyDefinition{
+		"ValidarIdade": {Name: "ValidarIdade", Rules: []string{`$.idade >= 18`, `$.tipo == "adulto"`}},
+		"CalcularDesconto": {Name: "CalcularDesconto", Rules: []string{
+			`$.valor > 100`,
+			`SET $.desconto = EXP($.valor * 0.1)`,
+			`SET $.usuario = $ctx.userId`,
+		}},
+		"ValidarMaioridade": {Name: "ValidarMaioridade", Rules: []string{
+			`AGE($.nascimento) >= 18`,
+			`SET $.verificadoEm = NOW()`,
+		}},
+	}
+	return core.NewEngineContext(nil, nil, policies, "Local")
+}
+
+func TestParseSuite(t *testing.T) {
+	suite, err := ParseSuite([]byte(`
+tests:
+- policies: [CalcularDesconto]
+  data: {valor: 150, itens: [1, 2]}
+  expect:
+    changes:
+    - {op: add, path: /desconto, value: 15}
+`))
+	require.NoError(t, err)
+	require.Len(t, suite.Tests, 1)
+
+	tc := suite.Tests[0]
+	assert.Equal(t, "caso 1", tc.Name)
+	assert.Equal(t, map[string]interface{}{"valor": 150.0, "itens": []interface{}{1.0, 2.0}}, tc.Data)
+	assert.Equal(t, 15.0, tc.Expect.Changes[0].Value)
+
+	_, err = ParseSuite([]byte("policies: policy.yaml\n"))
+	assert.Error(t, err)
+}
+
+func TestRunCase(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		suite    string
+		failures []string
+	}{
+		{
+			name: "expectativas atendidas",
+			suite: `
+tests:
+- policies: [CalcularDesconto]
+  context: {userId: u1}
+  data: {valor: 150}
+  expect:
+    passed: true
+    policies:
+      CalcularDesconto: {passed: true, rules: {0: true, 1: true}}
+    data: {valor: 150, desconto: 15, usuario: u1}
+    changes:
+    - {op: add, path: /desconto, value: 15, policy: CalcularDesconto}
+    - {op: add, path: /usuario, value: u1}
+`,
+		},
+		{
+			name: "resultado geral e da política",
+			suite: `
+tests:
+- policies: [ValidarIdade]
+  data: {idade: 10, tipo: adulto}
+  expect:
+    passed: true
+    policies:
+      ValidarIdade: {passed: true, error: tipo}
+`,
+			failures: []string{
+				"resultado geral: esperado passed=true, obtido false",
+				"política 'ValidarIdade': esperado passed=true, obtido false",
+				"política 'ValidarIdade': esperado erro contendo 'tipo'",
+			},
+		},
+		{
+			name: "regras",
+			suite: `
+tests:
+- policies: [ValidarIdade]
+  data: {idade: 10, tipo: adulto}
+  expect:
+    policies:
+      ValidarIdade: {rules: {0: true, 1: true}}
+      CalcularDesconto: {passed: true}
+`,
+			failures: []string{
+				"política 'CalcularDesconto': não foi executada",
+				"política 'ValidarIdade': regra 0 ('$.idade >= 18'): esperado passed=true, obtido false",
+				"política 'ValidarIdade': regra 1 não foi executada",
+			},
+		},
+		{
+			name: "dados e alterações",
+			suite: `
+tests:
+- policies: [CalcularDesconto]
+  data: {valor: 200}
+  expect:
+    data: {valor: 200, desconto: 15}
+    changes:
+    - {op: add, path: /desconto, value: 20, policy: AplicarImpostos}
+    - {op: replace, path: /usuario}
+`,
+			failures: []string{
+				`dados: esperado {"desconto":15,"valor":200}`,
+				"alteração 0: esperada",
+				"alteração 1: esperada",
+			},
+		},
+		{
+			name: "relógio fixo",
+			suite: `
+tests:
+- policies: [ValidarMaioridade]
+  now: 2024-05-01T12:00:00Z
+  data: {nascimento: "2006-05-02"}
+  expect:
+    passed: false
+- policies: [ValidarMaioridade]
+  now: 2024-05-02T00:00:00Z
+  data: {nascimento: "2006-05-02"}
+  expect:
+    data: {nascimento: "2006-05-02", verificadoEm: "2024-05-02T00:00:00Z"}
+`,
+		},
+	}
+
+	ec := newTestEngine()
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			suite, err := ParseSuite([]byte(cenario.suite))
+			require.NoError(t, err)
+
+			for _, tc := range suite.Tests {
+				result := RunCase(ec, tc)
+				require.Len(t, result.Failures, len(cenario.failures), result.Failures)
+				for i, expected := range cenario.failures {
+					assert.Contains(t, result.Failures[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	suite, err := LoadSuite("../../../examples/policy_test.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "policy_test.yaml", suite.Name)
+	assert.Equal(t, "../../../examples/policy.yaml", suite.Policies)
+
+	ld, err := policy.NewLoader(suite.Policies)
+	require.NoError(t, err)
+	catalog, err := ld.Load()
+	require.NoError(t, err)
+
+	ec := core.NewEngineContext(nil, nil, catalog.Policies, "Local")
+	ec.PolicySets = catalog.Sets
+	ec.Expressions = catalog.Expressions
+	ec.TableSources = catalog.Tables
+	ec.Tenants = catalog.Tenants
+	require.NoError(t, ec.ReloadTables())
+
+	result := Run(ec, suite)
+	assert.Len(t, result.Cases, len(suite.Tests))
+	assert.Zero(t, result.Failed(), result.Cases)
+}
+
+func TestValuesEqual(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		a, b     interface{}
+		expected bool
+	}{
+		{name: "float com erro de arredondamento", a: 3.3, b: 200 * 0.0165, expected: true},
+		{name: "float diferente", a: 3.3, b: 3.31, expected: false},
+		{name: "objetos aninhados", a: map[string]interface{}{"a": []interface{}{1.0, "x"}}, b: map[string]interface{}{"a": []interface{}{1.0, "x"}}, expected: true},
+		{name: "chave a mais", a: map[string]interface{}{"a": 1.0}, b: map[string]interface{}{"a": 1.0, "b": nil}, expected: false},
+		{name: "tipos diferentes", a: "1", b: 1.0, expected: false},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			assert.Equal(t, cenario.expected, valuesEqual(cenario.a, cenario.b), cenario.name)
+		}
+	})
+}
+
+func TestExecutePoliciesChanges(t *testing.T) {
+	data := map[string]interface{}{"valor": 150.0}
+	results, passed := newTestEngine().ExecutePolicies(data, []string{"CalcularDesconto"})
+	require.True(t, passed, results)
+
+	assert.Equal(t, []patch.Operation{
+		{Op: patch.OpAdd, Path: "/desconto", Value: 15.0, Policy: "CalcularDesconto", RuleIndex: 1},
+		{Op: patch.OpAdd, Path: "/usuario", Value: nil, Policy: "CalcularDesconto", RuleIndex: 2},
+	}, results[0].Changes)
+}
+
+// TestRuleCorpus garante que a gramática de rules.Parse (usada por lint, fmt e validação de
+// parâmetros) aceita todas as regras dos exemplos e fixtures e que a forma canônica gerada a
+// partir da AST é avaliada exatamente como o texto original.
+func TestRuleCorpus(t *testing.T) {
+	files, err := filepath.Glob("../../../cmd/testdata/*.yaml")
+	require.NoError(t, err)
+	files = append(files, "../../../examples/policy.yaml")
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		catalog, err := policy.ParseCatalog(content)
+		require.NoError(t, err, file)
+		for name, def := range catalog.Policies {
+			for i, rule := range def.Rules {
+				_, err := rules.Parse(rule)
+				assert.NoError(t, err, "%s: política '%s', regra %d", file, name, i)
+			}
+		}
+		for name, expr := range catalog.Expressions {
+			_, err := rules.ParseOperand(expr)
+			assert.NoError(t, err, "%s: expressão '%s'", file, name)
+		}
+	}
+
+	suite, err := LoadSuite("../../../examples/policy_test.yaml")
+	require.NoError(t, err)
+	ld, err := policy.NewLoader(suite.Policies)
+	require.NoError(t, err)
+	catalog, err := ld.Load()
+	require.NoError(t, err)
+
+	newEngine := func(format bool) *core.EngineContext {
+		policies := make(map[string]policy.PolicyDefinition, len(catalog.Policies))
+		for name, def := range catalog.Policies {
+			if format {
+				formatted := make([]string, len(def.Rules))
+				for i, rule := range def.Rules {
+					formatted[i], err = rules.FormatRule(rule)
+					require.NoError(t, err, rule)
+				}
+				def.Rules = formatted
+			}
+			policies[name] = def
+		}
+		ec := core.NewEngineContext(nil, nil, policies, "Local")
+		ec.PolicySets = catalog.Sets
+		ec.Expressions = catalog.Expressions
+		ec.Tenants = catalog.Tenants
+		ec.TableSources = catalog.Tables
+		ec.Clock = func() time.Time { return time.Date(2025, 4, 25, 12, 0, 0, 0, time.UTC) }
+		require.NoError(t, ec.ReloadTables())
+		return ec
+	}
+	original, canonical := newEngine(false), newEngine(true)
+
+	for _, tc := range suite.Tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			execute := func(ec *core.EngineContext) (map[string]interface{}, []policy.PolicyExecutionResult, bool) {
+				data := patch.Clone(tc.Data).(map[string]interface{})
+				results, passed := ec.ExecutePoliciesWithOptions(data, tc.Policies, core.ExecutionOptions{
+					Context: tc.Context, Meta: tc.Meta, Tenant: tc.Tenant, Params: tc.Params,
+				})
+				return data, results, passed
+			}
+			expectedData, expectedResults, expectedPassed := execute(original)
+			actualData, actualResults, actualPassed := execute(canonical)
+
+			assert.Equal(t, expectedPassed, actualPassed)
+			assert.Equal(t, expectedData, actualData)
+			require.Len(t, actualResults, len(expectedResults))
+			for i, expected := range expectedResults {
+				assert.Equal(t, expected.Passed, actualResults[i].Passed, expected.PolicyName)
+				require.Len(t, actualResults[i].RuleResults, len(expected.RuleResults), expected.PolicyName)
+				for j, rule := range expected.RuleResults {
+					assert.Equal(t, rule.Passed, actualResults[i].RuleResults[j].Passed, "%s: %s", expected.PolicyName, rule.Rule)
+				}
+			}
+		})
+	}
+}
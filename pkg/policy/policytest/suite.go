@@ -0,0 +1,146 @@
+// Package policytest executa testes de políticas descritos em YAML.
+//
+//	policies: policy.yaml            # relativo ao arquivo de testes (opcional)
+//	tests:
+//	- name: cliente premium recebe 15%
+//	  policies: [CalcularDesconto]
+//	  context: {userId: u1}          # exposto como $ctx (opcional)
+//	  tenant: lojaA                  # valores de parâmetros do tenant (opcional)
+//	  params: {CalcularDesconto: {taxaPremium: 0.2}}  # valores de parâmetros por política (opcional)
+//	  now: 2024-05-01T12:00:00Z      # relógio fixo para NOW() e AGE() (opcional)
+//	  data: {valor: 150, cliente: {tipo: premium}}
+//	  expect:
+//	    passed: true
+//	    policies:
+//	      CalcularDesconto:
+//	        passed: true
+//	        rules: {0: true, 2: true}  # resultado esperado por índice de regra
+//	    data: {valor: 150, desconto: 22.5, cliente: {tipo: premium}}
+//	    changes:
+//	    - {op: add, path: /desconto, value: 15}
+//	    - {op: replace, path: /desconto, value: 22.5}
+//
+// Todas as expectativas são opcionais; apenas as informadas são verificadas.
+package policytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// Suite é um arquivo de testes de políticas.
+type Suite struct {
+	Name     string `yaml:"name"`
+	Policies string `yaml:"policies"` // Origem do arquivo de políticas (caminho local, s3:// ou ssm://)
+	Tests    []Case `yaml:"tests"`
+}
+
+// Case é um caso de teste: os dados de entrada, as políticas aplicadas e o resultado esperado.
+type Case struct {
+	Name     string                 `yaml:"name"`
+	Policies []string               `yaml:"policies"` // Políticas, conjuntos ou tags; vazio usa o conjunto padrão
+	Data     map[string]interface{} `yaml:"data"`
+	Context  map[string]interface{} `yaml:"context"`
+	Meta     map[string]interface{} `yaml:"meta"`
+	Tenant   string                 `yaml:"tenant"`
+	Params   policy.ParamOverrides  `yaml:"params"`
+	Now      time.Time              `yaml:"now"` // Horário usado pelas funções de data; zero usa o relógio do motor
+	Expect   Expectation            `yaml:"expect"`
+}
+
+// Expectation descreve o resultado esperado de um caso.
+type Expectation struct {
+	Passed   *bool                        `yaml:"passed"`   // Resultado geral (todas as políticas passaram)
+	Policies map[string]PolicyExpectation `yaml:"policies"` // Resultado por política
+	Data     map[string]interface{}       `yaml:"data"`     // Dados completos após a execução
+	Changes  []ExpectedChange             `yaml:"changes"`  // Alterações (JSON Patch), na ordem de execução
+}
+
+// PolicyExpectation descreve o resultado esperado de uma política.
+type PolicyExpectation struct {
+	Passed *bool        `yaml:"passed"`
+	Rules  map[int]bool `yaml:"rules"` // Índice da regra -> passou
+	Error  string       `yaml:"error"` // Trecho esperado na mensagem de erro da política
+}
+
+// ExpectedChange é uma operação de JSON Patch esperada. Policy é verificada apenas se informada.
+type ExpectedChange struct {
+	Op     string      `yaml:"op" json:"op"`
+	Path   string      `yaml:"path" json:"path"`
+	Value  interface{} `yaml:"value" json:"value,omitempty"`
+	Policy string      `yaml:"policy" json:"policy,omitempty"`
+}
+
+// LoadSuite lê um arquivo de testes. Sem nome declarado, a suíte recebe o nome do arquivo, e
+// uma origem de políticas local relativa é resolvida a partir do diretório do arquivo.
+func LoadSuite(path string) (*Suite, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := ParseSuite(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if suite.Name == "" {
+		suite.Name = filepath.Base(path)
+	}
+	if suite.Policies != "" && !isRemoteSource(suite.Policies) && !filepath.IsAbs(suite.Policies) {
+		suite.Policies = filepath.Join(filepath.Dir(path), suite.Policies)
+	}
+	return suite, nil
+}
+
+// ParseSuite lê o conteúdo YAML de uma suíte. Os valores são normalizados para os tipos
+// produzidos por encoding/json (ex.: números como float64), como os dados de uma requisição.
+func ParseSuite(content []byte) (*Suite, error) {
+	var suite Suite
+	if err := yaml.Unmarshal(content, &suite); err != nil {
+		return nil, fmt.Errorf("arquivo de testes inválido: %v", err)
+	}
+	if len(suite.Tests) == 0 {
+		return nil, fmt.Errorf("arquivo de testes sem casos ('tests')")
+	}
+
+	for i := range suite.Tests {
+		tc := &suite.Tests[i]
+		if tc.Name == "" {
+			tc.Name = fmt.Sprintf("caso %d", i+1)
+		}
+		if err := normalize(&tc.Data, &tc.Context, &tc.Meta, &tc.Expect.Data); err != nil {
+			return nil, fmt.Errorf("caso '%s': %v", tc.Name, err)
+		}
+		for j := range tc.Expect.Changes {
+			if err := normalize(&tc.Expect.Changes[j].Value); err != nil {
+				return nil, fmt.Errorf("caso '%s': %v", tc.Name, err)
+			}
+		}
+	}
+	return &suite, nil
+}
+
+// normalize converte os valores decodificados do YAML passando-os por JSON.
+func normalize(values ...interface{}) error {
+	for _, value := range values {
+		content, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("valor não representável em JSON: %v", err)
+		}
+		if err := json.Unmarshal(content, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "s3://") || strings.HasPrefix(source, "ssm://")
+}
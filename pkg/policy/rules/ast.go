@@ -0,0 +1,650 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tipos de nó da árvore sintática (AST) de uma regra
+const (
+	NodeOr      = "or"
+	NodeIf      = "if"
+	NodeSet     = "set"
+	NodeAdd     = "add"
+	NodeDelete  = "delete"
+	NodeLet     = "let" // LET nome = valor; Target guarda a variável como "@nome"
+	NodeCompare = "compare"
+	NodeCheck   = "check" // Condição formada por uma função booleana, ex.: EXISTS($.email)
+	NodeLoop    = "loop"  // FOREACH, ALL ou ANY sobre os itens de um array
+)
+
+// Tipos de operando
+const (
+	OperandPath       = "path"
+	OperandLiteral    = "literal"
+	OperandList       = "list"       // Lista literal de IN, NOT IN, SUBSET OF e INTERSECTS
+	OperandExpression = "expression" // EXP(a op b)
+	OperandCall       = "call"       // FUNCAO(args)
+	OperandRange      = "range"      // Limites de BETWEEN: <mínimo> AND <máximo>
+)
+
+// comparisonOperators são os operadores simbólicos aceitos nas comparações.
+var comparisonOperators = map[string]bool{">=": true, "<=": true, "==": true, "!=": true, ">": true, "<": true}
+
+// wordOperators são os operadores de comparação escritos como palavras, separados dos
+// operandos por espaços. Operadores que começam com outro (BETWEEN EXCLUSIVE) vêm antes dele.
+var wordOperators = []string{"IN", "NOT IN", "CONTAINS", "STARTS WITH", "ENDS WITH", "MATCHES",
+	"BETWEEN EXCLUSIVE", "BETWEEN", "SUBSET OF", "INTERSECTS"}
+
+// Node é um nó da AST de uma regra, usada em análises estáticas (lint). A decomposição
+// segue a mesma ordem da avaliação: laços, OR, SET, ADD, DELETE, LET, IF e, por fim, a comparação.
+type Node struct {
+	Kind   string
+	Text   string   // Trecho da regra correspondente ao nó
+	Left   *Node    // OR: lado esquerdo; IF: condição
+	Right  *Node    // OR: lado direito; IF: ação; laço: corpo, lendo o item como $.lista[0]
+	Target string   // SET, ADD e DELETE: caminho alterado; LET: variável ("@nome")
+	Value  *Operand // SET, ADD e LET: valor atribuído ou acrescentado; laço: a lista
+	Op     string   // Comparação: operador
+	LHS    *Operand // Comparação: operando esquerdo; verificação: a chamada da função
+	RHS    *Operand // Comparação: operando direito
+	// Comparação: ANY ou ALL quando a comparação se aplica aos itens do array do LHS;
+	// laço: FOREACH, ALL ou ANY
+	Quantifier string
+	Item       string // Laço: nome do item no corpo
+
+	template *Node // Laço: corpo com o item como loopItemRoot+nome, usado na formatação
+}
+
+// loopItemRoot prefixa o nome do item no corpo usado para formatar o laço ("$.#item.valor").
+const loopItemRoot = "$.#"
+
+// Operand é um operando de uma regra: caminho, literal, lista, EXP(...) ou chamada de função.
+type Operand struct {
+	Kind   string
+	Text   string
+	Value  interface{} // Literal: valor convertido; lista: []interface{}
+	Quoted bool        // Literal string entre aspas
+	Op     string      // Expressão: operador matemático ("" para um único operando)
+	Left   *Operand    // Expressão: primeiro operando; intervalo: mínimo
+	Right  *Operand    // Expressão: segundo operando; intervalo: máximo
+	Func   string      // Chamada: nome da função
+	Args   []*Operand  // Chamada: argumentos
+}
+
+// SyntaxError descreve um trecho de regra que não pode ser interpretado.
+// Operator é preenchido quando o problema é um operador desconhecido.
+type SyntaxError struct {
+	Rule     string
+	Message  string
+	Operator string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: '%s'", e.Message, e.Rule)
+}
+
+func syntaxError(text, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{Rule: text, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse interpreta uma regra sem avaliá-la e retorna sua AST ou um *SyntaxError.
+func Parse(rule string) (*Node, error) {
+	return parseNode(strings.TrimSpace(rule))
+}
+
+// ParseOperand interpreta um valor isolado, como o de uma expressão nomeada
+// (ex.: "EXP($.valor * 0.1)"), e retorna seu operando ou um *SyntaxError.
+func ParseOperand(text string) (*Operand, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, syntaxError(text, "valor vazio")
+	}
+	return parseOperandNode(text)
+}
+
+func parseNode(text string) (*Node, error) {
+	if text == "" {
+		return nil, syntaxError(text, "regra vazia")
+	}
+
+	if l, matched, err := splitLoop(text); matched {
+		if err != nil {
+			return nil, syntaxError(text, "%v", err)
+		}
+		return parseLoop(text, l)
+	}
+
+	if strings.Contains(text, " OR ") && !isOperatorProtected(text, " OR ") {
+		parts := strings.SplitN(text, " OR ", 2)
+		left, err := parseNode(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		right, err := parseNode(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: NodeOr, Text: text, Left: left, Right: right}, nil
+	}
+
+	switch {
+	case strings.HasPrefix(text, "SET "):
+		return parseSet(text)
+	case strings.HasPrefix(text, "ADD "):
+		return parseAdd(text)
+	case strings.HasPrefix(text, "DELETE "):
+		target := strings.TrimSpace(strings.TrimPrefix(text, "DELETE "))
+		if err := checkTarget(text, target); err != nil {
+			return nil, err
+		}
+		return &Node{Kind: NodeDelete, Text: text, Target: target}, nil
+	case strings.HasPrefix(text, "LET "):
+		return parseLet(text)
+	case strings.HasPrefix(text, "IF "):
+		parts := ifRuleRe.FindStringSubmatch(text)
+		if len(parts) != 3 {
+			return nil, syntaxError(text, "regra IF...THEN inválida")
+		}
+		condition, err := parseNode(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		action, err := parseNode(strings.TrimSpace(parts[2]))
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: NodeIf, Text: text, Left: condition, Right: action}, nil
+	}
+	return parseComparison(text)
+}
+
+// parseLoop interpreta o corpo do laço duas vezes: com o item como o primeiro elemento da
+// lista, para as análises, e com o nome do item preservado, para a formatação.
+func parseLoop(text string, l loop) (*Node, error) {
+	collection, err := parsePathOperand(l.collection)
+	if err != nil {
+		return nil, err
+	}
+	body, err := parseNode(l.bind(l.itemPath(0)))
+	if err != nil {
+		return nil, err
+	}
+	if body.Kind == NodeDelete && body.Target == l.itemPath(0) {
+		return nil, syntaxError(text, "FOREACH não pode remover o próprio item")
+	}
+	template, err := parseNode(l.bind(loopItemRoot + l.item))
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: NodeLoop, Text: text, Quantifier: l.kind, Item: l.item, Value: collection, Right: body, template: template}, nil
+}
+
+func parseSet(text string) (*Node, error) {
+	parts := strings.SplitN(strings.TrimPrefix(text, "SET "), "=", 2)
+	if len(parts) != 2 {
+		return nil, syntaxError(text, "regra SET inválida")
+	}
+	target, valueStr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if err := checkTarget(text, target); err != nil {
+		return nil, err
+	}
+	if valueStr == "" {
+		return nil, syntaxError(text, "valor ausente no SET")
+	}
+	value, err := parseOperandNode(valueStr)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: NodeSet, Text: text, Target: target, Value: value}, nil
+}
+
+func parseLet(text string) (*Node, error) {
+	parts := strings.SplitN(strings.TrimPrefix(text, "LET "), "=", 2)
+	if len(parts) != 2 {
+		return nil, syntaxError(text, "regra LET inválida")
+	}
+	name, valueStr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if !variableNameRe.MatchString(name) {
+		return nil, syntaxError(text, "nome de variável inválido: '%s'", name)
+	}
+	if valueStr == "" {
+		return nil, syntaxError(text, "valor ausente no LET")
+	}
+	value, err := parseOperandNode(valueStr)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: NodeLet, Text: text, Target: RootVariables + name, Value: value}, nil
+}
+
+func parseAdd(text string) (*Node, error) {
+	idx := strings.LastIndex(text, " TO ")
+	if idx == -1 {
+		return nil, syntaxError(text, "regra ADD inválida")
+	}
+	valueStr := strings.TrimSpace(strings.TrimPrefix(text[:idx], "ADD "))
+	target := strings.TrimSpace(text[idx+len(" TO "):])
+	if err := checkTarget(text, target); err != nil {
+		return nil, err
+	}
+	if valueStr == "" {
+		return nil, syntaxError(text, "valor ausente no ADD")
+	}
+
+	var value *Operand
+	var item interface{}
+	if isPath(valueStr) {
+		path, err := parsePathOperand(valueStr)
+		if err != nil {
+			return nil, err
+		}
+		value = path
+	} else if err := json.Unmarshal([]byte(valueStr), &item); err == nil {
+		value = &Operand{Kind: OperandLiteral, Text: valueStr, Value: item, Quoted: strings.HasPrefix(valueStr, `"`)}
+	} else {
+		value = literalOperand(valueStr)
+	}
+	return &Node{Kind: NodeAdd, Text: text, Target: target, Value: value}, nil
+}
+
+// checkTarget valida o caminho alterado por SET, ADD e DELETE, que deve estar nos dados ("$.").
+func checkTarget(text, target string) error {
+	if target == "" {
+		return syntaxError(text, "caminho de destino ausente")
+	}
+	root, _, err := ParsePath(target)
+	if err != nil {
+		return syntaxError(text, "%v", err)
+	}
+	if root == RootVariables {
+		return syntaxError(text, "variável %s só pode ser definida com LET", target)
+	}
+	if root == RootParams {
+		return syntaxError(text, "parâmetro %s é somente leitura", target)
+	}
+	if root != "" {
+		return syntaxError(text, "caminho %s é somente leitura", target)
+	}
+	return nil
+}
+
+func parseComparison(text string) (*Node, error) {
+	start, end, op, err := findComparisonOperator(text)
+	if err != nil {
+		return nil, err
+	}
+	if start == -1 {
+		if _, _, isCall := splitCall(text); isCall {
+			call, err := parseOperandNode(text)
+			if err != nil {
+				return nil, err
+			}
+			if call.Kind == OperandCall {
+				return &Node{Kind: NodeCheck, Text: text, LHS: call}, nil
+			}
+		}
+		return nil, syntaxError(text, "regra de condição inválida")
+	}
+	lhsStr, rhsStr := strings.TrimSpace(text[:start]), strings.TrimSpace(text[end:])
+	if lhsStr == "" || rhsStr == "" {
+		return nil, syntaxError(text, "operando ausente na comparação %s", op)
+	}
+
+	quantifier, lhsStr := splitQuantifier(lhsStr)
+	var lhs *Operand
+	if quantifier != "" && isPath(lhsStr) && strings.Contains(lhsStr, wildcardIndex) {
+		lhs, err = parseWildcardPath(lhsStr)
+	} else {
+		lhs, err = parseOperandNode(lhsStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rhs *Operand
+	switch op {
+	case "IN", "NOT IN", "SUBSET OF", "INTERSECTS":
+		rhs, err = parseList(text, op, rhsStr)
+	case "BETWEEN", "BETWEEN EXCLUSIVE":
+		rhs, err = parseRange(text, op, rhsStr)
+	default:
+		rhs, err = parseOperandNode(rhsStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Kind: NodeCompare, Text: text, Op: op, LHS: lhs, RHS: rhs, Quantifier: quantifier}, nil
+}
+
+// findComparisonOperator localiza o operador de comparação fora de aspas, parênteses e
+// colchetes. Sequências de símbolos (ex.: "=>") e palavras em maiúsculas entre operandos
+// (ex.: "LIKE") que não são operadores conhecidos resultam em erro.
+func findComparisonOperator(text string) (int, int, string, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth > 0:
+		case strings.IndexByte("=!<>", c) >= 0:
+			j := i
+			for j < len(text) && strings.IndexByte("=!<>", text[j]) >= 0 {
+				j++
+			}
+			op := text[i:j]
+			if !comparisonOperators[op] {
+				return 0, 0, "", &SyntaxError{Rule: text, Message: fmt.Sprintf("operador desconhecido '%s'", op), Operator: op}
+			}
+			return i, j, op, nil
+		case i > 0 && text[i-1] == ' ' && isUpper(c):
+			j := i
+			for j < len(text) && isUpper(text[j]) {
+				j++
+			}
+			if j < len(text) && text[j] != ' ' {
+				i = j - 1 // Parte de outro token (ex.: "EXP(")
+				continue
+			}
+			for _, op := range wordOperators {
+				if strings.HasPrefix(text[i:], op) && (i+len(op) == len(text) || text[i+len(op)] == ' ') {
+					return i, i + len(op), op, nil
+				}
+			}
+			if word := text[i:j]; len(word) > 1 {
+				return 0, 0, "", &SyntaxError{Rule: text, Message: fmt.Sprintf("operador desconhecido '%s'", word), Operator: word}
+			}
+			i = j - 1
+		}
+	}
+	return -1, -1, "", nil
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+// parseList interpreta a lista dos operadores de lista: literal ([...]) ou um caminho ou
+// chamada de função que resulte em array.
+func parseList(text, op, listStr string) (*Operand, error) {
+	if strings.HasPrefix(listStr, "[") && strings.HasSuffix(listStr, "]") {
+		return &Operand{Kind: OperandList, Text: listStr, Value: literalListItems(listStr)}, nil
+	}
+	if operand, err := parseOperandNode(listStr); err != nil || operand.Kind == OperandPath || operand.Kind == OperandCall {
+		return operand, err
+	}
+	return nil, syntaxError(text, "lista para %s deve ser [...] ou um caminho", op)
+}
+
+// parseWildcardPath valida o caminho com [*] do operando de ANY e ALL.
+func parseWildcardPath(text string) (*Operand, error) {
+	if _, _, err := ParsePath(strings.ReplaceAll(text, wildcardIndex, "[0]")); err != nil {
+		return nil, syntaxError(text, "%v", err)
+	}
+	return &Operand{Kind: OperandPath, Text: text}, nil
+}
+
+func parseRange(text, op, rangeStr string) (*Operand, error) {
+	lowStr, highStr, ok := splitRange(rangeStr)
+	if !ok {
+		return nil, syntaxError(text, "%s exige '<mínimo> AND <máximo>'", op)
+	}
+	low, err := parseOperandNode(lowStr)
+	if err != nil {
+		return nil, err
+	}
+	high, err := parseOperandNode(highStr)
+	if err != nil {
+		return nil, err
+	}
+	return &Operand{Kind: OperandRange, Text: rangeStr, Left: low, Right: high}, nil
+}
+
+// splitRange separa os limites "<mínimo> AND <máximo>" de BETWEEN, ignorando " AND " entre
+// aspas, parênteses e colchetes.
+func splitRange(text string) (string, string, bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(text[i:], " AND "):
+			low, high := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+len(" AND "):])
+			return low, high, low != "" && high != ""
+		}
+	}
+	return "", "", false
+}
+
+// parseOperandNode interpreta um operando de comparação ou valor de SET.
+func parseOperandNode(text string) (*Operand, error) {
+	if expr, isExpr := extractExpression(text); isExpr {
+		return parseExpression(text, expr)
+	}
+	if isPath(text) {
+		return parsePathOperand(text)
+	}
+	if name, args, isCall := splitCall(text); isCall {
+		call := &Operand{Kind: OperandCall, Text: text, Func: name}
+		for _, arg := range args {
+			parsed, err := parseOperandNode(arg)
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, parsed)
+		}
+		return call, nil
+	}
+	return literalOperand(text), nil
+}
+
+// parseExpression interpreta o conteúdo de EXP(...) como a avaliação faz: o primeiro
+// operador (+, -, *, /) fora de aspas divide a expressão em dois operandos.
+func parseExpression(text, expr string) (*Operand, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, syntaxError(text, "expressão vazia")
+	}
+	for _, op := range []string{"+", "-", "*", "/"} {
+		if !strings.Contains(expr, op) || isOperatorProtected(expr, op) {
+			continue
+		}
+		parts := strings.SplitN(expr, op, 2)
+		left, right := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if left == "" && op == "-" {
+			continue // Menos unário (ex.: EXP(-5))
+		}
+		if left == "" || right == "" {
+			return nil, syntaxError(text, "operando ausente na expressão '%s'", expr)
+		}
+		leftOperand, err := parseExpressionOperand(text, left)
+		if err != nil {
+			return nil, err
+		}
+		rightOperand, err := parseExpressionOperand(text, right)
+		if err != nil {
+			return nil, err
+		}
+		return &Operand{Kind: OperandExpression, Text: text, Op: op, Left: leftOperand, Right: rightOperand}, nil
+	}
+	single, err := parseExpressionOperand(text, expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Operand{Kind: OperandExpression, Text: text, Left: single}, nil
+}
+
+func parseExpressionOperand(text, operand string) (*Operand, error) {
+	if _, isExpr := extractExpression(operand); isExpr {
+		return nil, syntaxError(text, "EXP aninhado não é suportado")
+	}
+	return parseOperandNode(operand)
+}
+
+func parsePathOperand(text string) (*Operand, error) {
+	if _, _, err := ParsePath(text); err != nil {
+		return nil, syntaxError(text, "%v", err)
+	}
+	return &Operand{Kind: OperandPath, Text: text}, nil
+}
+
+// literalOperand converte um literal como a avaliação: null, número, booleano ou string.
+func literalOperand(text string) *Operand {
+	quoted := len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0]
+	operand := &Operand{Kind: OperandLiteral, Text: text, Quoted: quoted}
+	if quoted {
+		operand.Value = text[1 : len(text)-1]
+		return operand
+	}
+	operand.Value, _ = parseLiteral(text)
+	return operand
+}
+
+// splitCall separa "NOME(arg1, arg2)" em nome e argumentos. O nome deve estar em maiúsculas.
+func splitCall(text string) (string, []string, bool) {
+	open := strings.Index(text, "(")
+	if open <= 0 || !strings.HasSuffix(text, ")") {
+		return "", nil, false
+	}
+	name := text[:open]
+	for i := 0; i < len(name); i++ {
+		if !isUpper(name[i]) && name[i] != '_' && !(i > 0 && name[i] >= '0' && name[i] <= '9') {
+			return "", nil, false
+		}
+	}
+	content := strings.TrimSpace(text[open+1 : len(text)-1])
+	if content == "" {
+		return name, nil, true
+	}
+	return name, splitArguments(content), true
+}
+
+// splitArguments divide uma lista separada por vírgulas ignorando vírgulas entre aspas,
+// parênteses e colchetes.
+func splitArguments(content string) []string {
+	var args []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(content[start:i]))
+			start = i + 1
+		}
+	}
+	return append(args, strings.TrimSpace(content[start:]))
+}
+
+// PathSegment é um trecho de um caminho: uma chave de objeto ou um índice de array.
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// ParsePath valida um caminho ("$.campo", "$<raiz>.campo", "@variavel.campo" ou ":parametro.campo")
+// e retorna a raiz (vazia para os dados, RootVariables para variáveis, RootParams para parâmetros)
+// e os segmentos, que para variáveis e parâmetros começam pelo nome.
+func ParsePath(path string) (string, []PathSegment, error) {
+	if !isPath(path) {
+		return "", nil, fmt.Errorf("caminho inválido: %s", path)
+	}
+	if strings.ContainsAny(path, " \t") {
+		return "", nil, fmt.Errorf("caminho inválido: %s", path)
+	}
+	root, rest := splitRoot(path)
+	if rest == "$" {
+		return root, nil, nil
+	}
+	segments, err := splitPath(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	result := make([]PathSegment, len(segments))
+	for i, segment := range segments {
+		result[i] = PathSegment{Key: segment.key, Index: segment.index, IsIndex: segment.isIndex}
+	}
+	return root, result, nil
+}
+
+// Walk percorre o nó e seus descendentes em pré-ordem.
+func (n *Node) Walk(fn func(*Node)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	n.Left.Walk(fn)
+	n.Right.Walk(fn)
+}
+
+// Operands retorna os operandos do próprio nó (sem os dos descendentes).
+func (n *Node) Operands() []*Operand {
+	var operands []*Operand
+	for _, operand := range []*Operand{n.Value, n.LHS, n.RHS} {
+		if operand != nil {
+			operands = append(operands, operand)
+		}
+	}
+	return operands
+}
+
+// Walk percorre o operando e seus operandos internos (expressões e argumentos) em pré-ordem.
+func (o *Operand) Walk(fn func(*Operand)) {
+	if o == nil {
+		return
+	}
+	fn(o)
+	o.Left.Walk(fn)
+	o.Right.Walk(fn)
+	for _, arg := range o.Args {
+		arg.Walk(fn)
+	}
+}
+
+// IsNumber indica se o operando é um literal numérico (ou string numérica, que a
+// avaliação converte em comparações e expressões).
+func (o *Operand) IsNumber() bool {
+	if o.Kind != OperandLiteral {
+		return false
+	}
+	switch v := o.Value.(type) {
+	case float64:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	}
+	return false
+}
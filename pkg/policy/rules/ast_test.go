@@ -0,0 +1,211 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected *Node
+	}{
+		{
+			name: "comparação com literal",
+			rule: `$.idade >= 18`,
+			expected: &Node{Kind: NodeCompare, Text: `$.idade >= 18`, Op: ">=",
+				LHS: &Operand{Kind: OperandPath, Text: "$.idade"},
+				RHS: &Operand{Kind: OperandLiteral, Text: "18", Value: 18.0}},
+		},
+		{
+			name: "NOT IN com lista",
+			rule: `$.estado NOT IN ["SP", 'RJ']`,
+			expected: &Node{Kind: NodeCompare, Text: `$.estado NOT IN ["SP", 'RJ']`, Op: "NOT IN",
+				LHS: &Operand{Kind: OperandPath, Text: "$.estado"},
+				RHS: &Operand{Kind: OperandList, Text: `["SP", 'RJ']`, Value: []interface{}{"SP", "RJ"}}},
+		},
+		{
+			name: "IF com SET e EXP",
+			rule: `IF $.tipo == "servico" THEN SET $.pis = EXP($.valor * 0.0165)`,
+			expected: &Node{Kind: NodeIf, Text: `IF $.tipo == "servico" THEN SET $.pis = EXP($.valor * 0.0165)`,
+				Left: &Node{Kind: NodeCompare, Text: `$.tipo == "servico"`, Op: "==",
+					LHS: &Operand{Kind: OperandPath, Text: "$.tipo"},
+					RHS: &Operand{Kind: OperandLiteral, Text: `"servico"`, Value: "servico", Quoted: true}},
+				Right: &Node{Kind: NodeSet, Text: `SET $.pis = EXP($.valor * 0.0165)`, Target: "$.pis",
+					Value: &Operand{Kind: OperandExpression, Text: "EXP($.valor * 0.0165)", Op: "*",
+						Left:  &Operand{Kind: OperandPath, Text: "$.valor"},
+						Right: &Operand{Kind: OperandLiteral, Text: "0.0165", Value: 0.0165}}}},
+		},
+		{
+			name: "chamada de função",
+			rule: `SET $.total = SUM($.itens, 2)`,
+			expected: &Node{Kind: NodeSet, Text: `SET $.total = SUM($.itens, 2)`, Target: "$.total",
+				Value: &Operand{Kind: OperandCall, Text: "SUM($.itens, 2)", Func: "SUM", Args: []*Operand{
+					{Kind: OperandPath, Text: "$.itens"},
+					{Kind: OperandLiteral, Text: "2", Value: 2.0},
+				}}},
+		},
+		{
+			name: "operador de duas palavras com função",
+			rule: `LOWER($.email) ENDS WITH ".br"`,
+			expected: &Node{Kind: NodeCompare, Text: `LOWER($.email) ENDS WITH ".br"`, Op: "ENDS WITH",
+				LHS: &Operand{Kind: OperandCall, Text: "LOWER($.email)", Func: "LOWER", Args: []*Operand{
+					{Kind: OperandPath, Text: "$.email"},
+				}},
+				RHS: &Operand{Kind: OperandLiteral, Text: `".br"`, Value: ".br", Quoted: true}},
+		},
+		{
+			name: "BETWEEN",
+			rule: `$.idade BETWEEN 18 AND 65`,
+			expected: &Node{Kind: NodeCompare, Text: `$.idade BETWEEN 18 AND 65`, Op: "BETWEEN",
+				LHS: &Operand{Kind: OperandPath, Text: "$.idade"},
+				RHS: &Operand{Kind: OperandRange, Text: "18 AND 65",
+					Left:  &Operand{Kind: OperandLiteral, Text: "18", Value: 18.0},
+					Right: &Operand{Kind: OperandLiteral, Text: "65", Value: 65.0}}},
+		},
+		{
+			name: "ANY com caminho curinga",
+			rule: `ANY $.itens[*].valor BETWEEN EXCLUSIVE 0 AND 100`,
+			expected: &Node{Kind: NodeCompare, Text: `ANY $.itens[*].valor BETWEEN EXCLUSIVE 0 AND 100`, Op: "BETWEEN EXCLUSIVE", Quantifier: "ANY",
+				LHS: &Operand{Kind: OperandPath, Text: "$.itens[*].valor"},
+				RHS: &Operand{Kind: OperandRange, Text: "0 AND 100",
+					Left:  &Operand{Kind: OperandLiteral, Text: "0", Value: 0.0},
+					Right: &Operand{Kind: OperandLiteral, Text: "100", Value: 100.0}}},
+		},
+		{
+			name: "lista de caminho",
+			rule: `$.tags SUBSET OF $.permitidas`,
+			expected: &Node{Kind: NodeCompare, Text: `$.tags SUBSET OF $.permitidas`, Op: "SUBSET OF",
+				LHS: &Operand{Kind: OperandPath, Text: "$.tags"},
+				RHS: &Operand{Kind: OperandPath, Text: "$.permitidas"}},
+		},
+		{
+			name: "condição com função booleana",
+			rule: `EXISTS($.cliente.email)`,
+			expected: &Node{Kind: NodeCheck, Text: `EXISTS($.cliente.email)`,
+				LHS: &Operand{Kind: OperandCall, Text: "EXISTS($.cliente.email)", Func: "EXISTS", Args: []*Operand{
+					{Kind: OperandPath, Text: "$.cliente.email"},
+				}}},
+		},
+		{
+			name: "LET e leitura de variável",
+			rule: `LET desconto = EXP(@base * 0.1)`,
+			expected: &Node{Kind: NodeLet, Text: `LET desconto = EXP(@base * 0.1)`, Target: "@desconto",
+				Value: &Operand{Kind: OperandExpression, Text: "EXP(@base * 0.1)", Op: "*",
+					Left:  &Operand{Kind: OperandPath, Text: "@base"},
+					Right: &Operand{Kind: OperandLiteral, Text: "0.1", Value: 0.1}}},
+		},
+		{
+			name:     "DELETE",
+			rule:     `  DELETE $.itens[0]  `,
+			expected: &Node{Kind: NodeDelete, Text: `DELETE $.itens[0]`, Target: "$.itens[0]"},
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			node, err := Parse(cenario.rule)
+			require.NoError(t, err)
+			assert.Equal(t, cenario.expected, node)
+		})
+	}
+}
+
+func TestParseOr(t *testing.T) {
+	node, err := Parse(`$.moeda == "BRL" OR $.moeda == "USD" OR $.moeda == 'A OR B'`)
+	require.NoError(t, err)
+
+	var kinds []string
+	node.Walk(func(n *Node) { kinds = append(kinds, n.Kind) })
+	assert.Equal(t, []string{NodeOr, NodeCompare, NodeOr, NodeCompare, NodeCompare}, kinds)
+	assert.Equal(t, `$.moeda == 'A OR B'`, node.Right.Right.Text)
+}
+
+func TestParseLoop(t *testing.T) {
+	node, err := Parse(`FOREACH item IN $.transacoes DO IF item.valor > 0 THEN SET item.taxa = EXP(item.valor * 0.02)`)
+	require.NoError(t, err)
+	assert.Equal(t, NodeLoop, node.Kind)
+	assert.Equal(t, LoopForEach, node.Quantifier)
+	assert.Equal(t, "item", node.Item)
+	assert.Equal(t, &Operand{Kind: OperandPath, Text: "$.transacoes"}, node.Value)
+
+	// O corpo é analisado com o item no primeiro elemento da lista
+	var kinds, targets []string
+	node.Walk(func(n *Node) {
+		kinds = append(kinds, n.Kind)
+		if n.Target != "" {
+			targets = append(targets, n.Target)
+		}
+	})
+	assert.Equal(t, []string{NodeLoop, NodeIf, NodeCompare, NodeSet}, kinds)
+	assert.Equal(t, []string{"$.transacoes[0].taxa"}, targets)
+
+	node, err = Parse(`ALL p IN $.pedidos: ANY i IN p.itens: i.qtd > 1 OR i.brinde == true`)
+	require.NoError(t, err)
+	assert.Equal(t, QuantifierAll, node.Quantifier)
+	assert.Equal(t, "$.pedidos[0].itens", node.Right.Value.Text)
+	assert.Equal(t, NodeOr, node.Right.Right.Kind)
+	assert.Equal(t, "$.pedidos[0].itens[0].qtd", node.Right.Right.Left.LHS.Text)
+}
+
+func TestParseErrors(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		message  string
+		operator string
+	}{
+		{name: "operando ausente em EXP", rule: `SET $.x = EXP($.a * )`, message: "operando ausente na expressão '$.a *'"},
+		{name: "operador simbólico desconhecido", rule: `$.a => 1`, message: "operador desconhecido '=>'", operator: "=>"},
+		{name: "operador por extenso desconhecido", rule: `$.nome LIKE "a%"`, message: "operador desconhecido 'LIKE'", operator: "LIKE"},
+		{name: "sem operador", rule: `$.ativo`, message: "regra de condição inválida"},
+		{name: "EXP sem operador", rule: `EXP($.a + 1)`, message: "regra de condição inválida"},
+		{name: "operando ausente na comparação", rule: `$.a >=`, message: "operando ausente na comparação >="},
+		{name: "IN sem lista", rule: `$.a IN "SP"`, message: "lista para IN deve ser [...] ou um caminho"},
+		{name: "BETWEEN sem AND", rule: `$.idade BETWEEN 18`, message: "BETWEEN exige '<mínimo> AND <máximo>'"},
+		{name: "curinga inválido com quantificador", rule: `ALL $.itens[*]..valor > 1`, message: "invalid path: $.itens[0]..valor"},
+		{name: "IF sem THEN", rule: `IF $.a > 1 SET $.b = 2`, message: "regra IF...THEN inválida"},
+		{name: "SET em raiz somente leitura", rule: `SET $ctx.userId = 1`, message: "caminho $ctx.userId é somente leitura"},
+		{name: "LET com nome inválido", rule: `LET $.x = 1`, message: "nome de variável inválido: '$.x'"},
+		{name: "LET sem valor", rule: `LET x =`, message: "valor ausente no LET"},
+		{name: "SET em variável", rule: `SET @total = 1`, message: "variável @total só pode ser definida com LET"},
+		{name: "SET em parâmetro", rule: `SET :taxa = 1`, message: "parâmetro :taxa é somente leitura"},
+		{name: "índice inválido", rule: `$.itens[*].valor > 1`, message: "invalid array index: *"},
+		{name: "FOREACH com condição", rule: `FOREACH i IN $.itens DO i.valor > 1`, message: "FOREACH exige uma ação (SET, ADD, DELETE, LET, IF ou FOREACH): 'i.valor > 1'"},
+		{name: "ANY sem lista", rule: `ANY i IN itens: i.valor > 1`, message: "ANY exige o caminho de um array, sem [*]: 'itens'"},
+		{name: "FOREACH que remove o item", rule: `FOREACH i IN $.itens DO DELETE i`, message: "FOREACH não pode remover o próprio item"},
+		{name: "SET no item de uma variável", rule: `FOREACH i IN @itens DO SET i.x = 1`, message: "variável @itens[0].x só pode ser definida com LET"},
+		{name: "EXP aninhado", rule: `SET $.x = EXP($.a * EXP($.b))`, message: "EXP aninhado não é suportado"},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, err := Parse(cenario.rule)
+			require.Error(t, err)
+			syntaxErr, ok := err.(*SyntaxError)
+			require.True(t, ok, "erro %T", err)
+			assert.Equal(t, cenario.message, syntaxErr.Message)
+			assert.Equal(t, cenario.operator, syntaxErr.Operator)
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	root, segments, err := ParsePath("$ctx.pedido.itens[1].valor")
+	require.NoError(t, err)
+	assert.Equal(t, "ctx", root)
+	assert.Equal(t, []PathSegment{{Key: "pedido"}, {Key: "itens"}, {Index: 1, IsIndex: true}, {Key: "valor"}}, segments)
+
+	root, segments, err = ParsePath(":limites.max")
+	require.NoError(t, err)
+	assert.Equal(t, RootParams, root)
+	assert.Equal(t, []PathSegment{{Key: "limites"}, {Key: "max"}}, segments)
+
+	for _, path := range []string{"idade", "$.", "$.a b", "$.itens[x]", ":", "::taxa"} {
+		_, _, err := ParsePath(path)
+		assert.Error(t, err, path)
+	}
+}
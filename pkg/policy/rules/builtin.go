@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// native cria a entrada de uma função nativa: os argumentos são TypeAny, pois a própria
+// função os converte, e o tipo do resultado informa as verificações estáticas.
+// maxArgs -1 indica quantidade variável.
+func native(fn Function, returns string, minArgs, maxArgs int) function {
+	signature := Signature{Returns: returns}
+	count := maxArgs
+	if maxArgs < 0 {
+		count, signature.Variadic = minArgs, true
+	}
+	for i := 0; i < count; i++ {
+		signature.Args = append(signature.Args, TypeAny)
+	}
+	signature.Optional = count - minArgs
+	return function{signature: signature, fn: fn}
+}
+
+// acceptingMissing marca a função como capaz de receber caminhos ausentes nos dados: eles
+// chegam como 'missing' em vez de null ou erro, distinguindo o campo ausente do null.
+func (f function) acceptingMissing() function {
+	f.acceptsMissing = true
+	return f
+}
+
+// builtins são as funções nativas que podem ser chamadas nas regras (EXP é sintaxe própria),
+// presentes em todo registro de funções (ver NewFunctionRegistry).
+var builtins = &FunctionRegistry{functions: map[string]function{
+	"UPPER":   native(upperFunc, TypeString, 1, 1),
+	"LOWER":   native(lowerFunc, TypeString, 1, 1),
+	"TRIM":    native(trimFunc, TypeString, 1, 1),
+	"LEN":     native(lenFunc, TypeNumber, 1, 1),
+	"SUBSTR":  native(substrFunc, TypeString, 2, 3),
+	"CONCAT":  native(concatFunc, TypeString, 1, -1),
+	"REPLACE": native(replaceFunc, TypeString, 3, 3),
+	"SPLIT":   native(splitFunc, TypeArray, 2, 2),
+	"JOIN":    native(joinFunc, TypeString, 2, 2),
+	"PAD":     native(padFunc, TypeString, 2, 4),
+	"ROUND":   native(roundFunc, TypeNumber, 1, 3),
+
+	"NOW":         native(nowFunc, TypeDate, 0, 1),
+	"DATE":        native(dateFunc, TypeDate, 1, 2),
+	"PARSE_DATE":  native(parseDateFunc, TypeDate, 2, 3),
+	"DATE_ADD":    native(dateAddFunc, TypeDate, 3, 3),
+	"DATE_DIFF":   native(dateDiffFunc, TypeNumber, 3, 3),
+	"AGE":         native(ageFunc, TypeNumber, 1, 1),
+	"DAY_OF_WEEK": native(dayOfWeekFunc, TypeNumber, 1, 1),
+
+	"EXISTS":    native(existsFunc, TypeBoolean, 1, 1).acceptingMissing(),
+	"IS_STRING": native(typeCheckFunc(isString), TypeBoolean, 1, 1).acceptingMissing(),
+	"IS_NUMBER": native(typeCheckFunc(isNumber), TypeBoolean, 1, 1).acceptingMissing(),
+	"IS_ARRAY":  native(typeCheckFunc(isArray), TypeBoolean, 1, 1).acceptingMissing(),
+	"IS_OBJECT": native(typeCheckFunc(isObject), TypeBoolean, 1, 1).acceptingMissing(),
+	"IS_EMPTY":  native(isEmptyFunc, TypeBoolean, 1, 1).acceptingMissing(),
+	"COALESCE":  native(coalesceFunc, TypeAny, 1, -1).acceptingMissing(),
+	"DEFAULT":   native(defaultFunc, TypeAny, 2, 2).acceptingMissing(),
+	"TO_NUMBER": native(conversionFunc(toNumber), TypeAny, 1, 2), // O padrão pode ter outro tipo
+	"TO_STRING": native(conversionFunc(toString), TypeAny, 1, 2),
+	"TO_BOOL":   native(conversionFunc(toBool), TypeAny, 1, 2),
+
+	"TABLE":  native(tableFunc, TypeArray, 1, 1),
+	"LOOKUP": native(lookupFunc, TypeAny, 2, 3),
+}}
+
+// IsFunction indica se a função nativa pode ser chamada nas regras.
+func IsFunction(name string) bool {
+	return builtins.IsFunction(name)
+}
+
+// AcceptsMissingPaths indica se a função aceita caminhos ausentes nos argumentos sem erro
+// (ex.: EXISTS, COALESCE), que por isso não precisam ser verificados antes da chamada.
+func AcceptsMissingPaths(name string) bool {
+	f, _ := builtins.lookup(name)
+	return f.acceptsMissing
+}
+
+// evaluateCall avalia os argumentos da chamada 'call' (ex.: "UPPER($.nome)") e executa a
+// função, registrando no trace os argumentos resolvidos e o resultado.
+func evaluateCall(call, name string, args []string, ctx *Context, node *TraceNode) (interface{}, error) {
+	function, exists := ctx.functions().lookup(name)
+	if !exists {
+		return nil, fmt.Errorf("função não implementada: %s", name)
+	}
+	if !function.signature.acceptsCount(len(args)) {
+		return nil, fmt.Errorf("função %s espera %s, recebeu %d", name, function.signature.describeArity(), len(args))
+	}
+
+	callNode := node.Child(TraceCall, call)
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if function.acceptsMissing && isPath(arg) {
+			if found, err := ctx.exists(arg); err == nil && !found {
+				callNode.Child(TracePath, arg).Finish(nil, nil)
+				values[i] = missing
+				continue
+			}
+		}
+		value, _, err := evaluateOperand(arg, ctx, callNode)
+		if err != nil {
+			callNode.Finish(nil, err)
+			return nil, err
+		}
+		values[i] = plainValue(value) // Funções recebem números como float64
+	}
+	result, err := function.call(ctx, values)
+	if err != nil {
+		err = fmt.Errorf("%s: %v", name, err)
+	}
+	callNode.Finish(result, err)
+	return result, err
+}
+
+// textValue converte um argumento em texto: strings como estão, números, booleanos e datas
+// (RFC 3339) formatados. null, arrays e objetos resultam em erro.
+func textValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case nil:
+		return "", fmt.Errorf("argumento nulo")
+	}
+	if num, ok := convertToFloat64(value); ok {
+		return strconv.FormatFloat(num, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("argumento %v (%T) não é texto", value, value)
+}
+
+// intValue converte um argumento numérico inteiro (ex.: posição ou tamanho).
+func intValue(value interface{}) (int, error) {
+	num, ok := convertToFloat64(value)
+	if !ok || num != float64(int(num)) {
+		return 0, fmt.Errorf("argumento %v não é um número inteiro", value)
+	}
+	return int(num), nil
+}
@@ -0,0 +1,178 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quantificadores de comparação sobre arrays
+const (
+	QuantifierAny = "ANY" // Verdadeira se a comparação vale para algum item (false para array vazio)
+	QuantifierAll = "ALL" // Verdadeira se a comparação vale para todos os itens (true para array vazio)
+)
+
+// wildcardIndex é o índice que seleciona todos os itens de um array (ex.: $.itens[*].valor),
+// aceito apenas no operando de ANY e ALL.
+const wildcardIndex = "[*]"
+
+// splitQuantifier separa o quantificador do operando esquerdo ("ANY $.itens[*].valor").
+func splitQuantifier(lhs string) (string, string) {
+	for _, quantifier := range []string{QuantifierAny, QuantifierAll} {
+		if rest, found := strings.CutPrefix(lhs, quantifier+" "); found {
+			return quantifier, strings.TrimSpace(rest)
+		}
+	}
+	return "", lhs
+}
+
+// evaluateItems avalia o operando de ANY/ALL como uma lista de itens: um caminho com [*]
+// é expandido; qualquer outro operando deve resultar em um array (null é um array vazio).
+func evaluateItems(quantifier, operand string, ctx *Context, node *TraceNode) (interface{}, string, error) {
+	if isPath(operand) && strings.Contains(operand, wildcardIndex) {
+		child := node.Child(TracePath, operand)
+		items, err := ctx.expand(operand)
+		child.Finish(items, err)
+		if err != nil {
+			return nil, "path " + operand, err
+		}
+		return items, fmt.Sprintf("path %s = %v", operand, items), nil
+	}
+	value, details, err := evaluateOperand(operand, ctx, node)
+	if err != nil {
+		return nil, details, err
+	}
+	items, err := listValue(quantifier, value)
+	return items, details, err
+}
+
+// expand resolve um caminho com [*], retornando o valor de cada item selecionado. Itens sem o
+// campo final resultam em null, como em getValue.
+func (c *Context) expand(path string) ([]interface{}, error) {
+	prefix, rest, _ := strings.Cut(path, wildcardIndex)
+	value, err := c.resolve(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return []interface{}{}, nil
+	}
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %s is not an array", prefix)
+	}
+
+	items := []interface{}{}
+	for i, item := range arr {
+		if rest == "" {
+			items = append(items, item)
+			continue
+		}
+		itemPath := fmt.Sprintf("%s[%d]%s", prefix, i, rest)
+		if strings.Contains(rest, wildcardIndex) {
+			nested, err := c.expand(itemPath)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, nested...)
+			continue
+		}
+		value, err := c.resolve(itemPath)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+// evaluateList avalia a lista dos operadores IN, NOT IN, SUBSET OF e INTERSECTS: uma lista
+// literal ([...]) ou um operando (caminho ou função) que resulte em array.
+func evaluateList(op, listStr string, ctx *Context, node *TraceNode) (interface{}, string, error) {
+	if strings.HasPrefix(listStr, "[") && strings.HasSuffix(listStr, "]") {
+		items := literalListItems(listStr)
+		traceLiteral(node, listStr, items)
+		return items, fmt.Sprintf("lista %v", items), nil
+	}
+	if !isPath(listStr) {
+		if _, _, isCall := splitCall(listStr); !isCall {
+			return nil, "", fmt.Errorf("lista para %s deve ser [...] ou um caminho: %s", op, listStr)
+		}
+	}
+	value, details, err := evaluateOperand(listStr, ctx, node)
+	if err != nil {
+		return nil, fmt.Sprintf("Erro ao avaliar lista '%s': %s. Detalhes: %v", listStr, details, err), err
+	}
+	items, err := listValue(op, value)
+	if err != nil {
+		return nil, fmt.Sprintf("%s -> ERRO: %v", details, err), err
+	}
+	return items, details, nil
+}
+
+// literalListItems separa os itens de uma lista literal ("[SP, 'RJ', "MG"]") sem as aspas.
+func literalListItems(listStr string) []interface{} {
+	items := []interface{}{}
+	if content := strings.Trim(listStr, "[] "); content != "" {
+		for _, el := range splitArguments(content) {
+			items = append(items, strings.Trim(el, "\"'"))
+		}
+	}
+	return items
+}
+
+// listValue converte o valor de um operando em lista; null é uma lista vazia.
+func listValue(op string, value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s exige um array, recebeu %v (%T)", op, value, value)
+}
+
+// listContains indica se algum item da lista é igual ao valor (ver compareEquals).
+func listContains(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if compareEquals(value, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareSets implementa SUBSET OF (todos os itens do array estão na lista) e INTERSECTS
+// (algum item do array está na lista). null é tratado como array vazio.
+func compareSets(op string, lhs interface{}, list []interface{}) (bool, error) {
+	items, err := listValue(op, lhs)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		found := listContains(list, item)
+		if op == "INTERSECTS" && found {
+			return true, nil
+		}
+		if op == "SUBSET OF" && !found {
+			return false, nil
+		}
+	}
+	return op == "SUBSET OF", nil
+}
+
+// quantify aplica a comparação a cada item conforme o quantificador ANY ou ALL.
+func quantify(quantifier, op string, items []interface{}, rhsValue interface{}, ctx *Context) (bool, error) {
+	for i, item := range items {
+		passed, err := applyOperator(op, item, rhsValue, ctx)
+		if err != nil {
+			return false, fmt.Errorf("item %d: %v", i, err)
+		}
+		if quantifier == QuantifierAny && passed {
+			return true, nil
+		}
+		if quantifier == QuantifierAll && !passed {
+			return false, nil
+		}
+	}
+	return quantifier == QuantifierAll, nil
+}
@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectionPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"idade":      30.0,
+		"estado":     "SP",
+		"codigo":     10.0,
+		"estados":    []interface{}{"SP", "RJ"},
+		"codigos":    []interface{}{10.0, 20.0},
+		"tags":       []interface{}{"vip", "pj"},
+		"permitidas": []interface{}{"vip", "pj", "pf"},
+		"bloqueadas": []interface{}{"fraude"},
+		"vazio":      []interface{}{},
+		"itens": []interface{}{
+			map[string]interface{}{"valor": 50.0, "tags": []interface{}{"a"}},
+			map[string]interface{}{"valor": 150.0, "tags": []interface{}{"b", "c"}},
+		},
+	}
+}
+
+func TestCollectionOperators(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "BETWEEN inclusivo", rule: `$.idade BETWEEN 30 AND 65`, expected: true},
+		{name: "BETWEEN EXCLUSIVE no limite", rule: `$.idade BETWEEN EXCLUSIVE 30 AND 65`, expected: false},
+		{name: "BETWEEN EXCLUSIVE", rule: `$.idade BETWEEN EXCLUSIVE 18 AND 65`, expected: true},
+		{name: "IN com caminho", rule: `$.estado IN $.estados`, expected: true},
+		{name: "NOT IN com caminho", rule: `$.estado NOT IN $.estados`, expected: false},
+		{name: "IN com números", rule: `$.codigo IN $.codigos`, expected: true},
+		{name: "IN com lista literal de números", rule: `$.codigo IN [5, 10]`, expected: true},
+		{name: "IN com vírgula entre aspas", rule: `"a, b" IN ["a, b", "c"]`, expected: true},
+		{name: "IN com função", rule: `"b" IN SPLIT("a,b", ",")`, expected: true},
+		{name: "IN com caminho ausente", rule: `$.estado IN $.ausente`, expected: false},
+		{name: "NOT IN com caminho ausente", rule: `$.estado NOT IN $.ausente`, expected: true},
+		{name: "SUBSET OF", rule: `$.tags SUBSET OF $.permitidas`, expected: true},
+		{name: "SUBSET OF com lista literal", rule: `$.tags SUBSET OF ["vip"]`, expected: false},
+		{name: "SUBSET OF vazio", rule: `$.vazio SUBSET OF []`, expected: true},
+		{name: "INTERSECTS", rule: `$.tags INTERSECTS ["pf", "pj"]`, expected: true},
+		{name: "INTERSECTS sem itens em comum", rule: `$.tags INTERSECTS $.bloqueadas`, expected: false},
+		{name: "ANY com curinga", rule: `ANY $.itens[*].valor > 100`, expected: true},
+		{name: "ALL com curinga", rule: `ALL $.itens[*].valor > 100`, expected: false},
+		{name: "ALL com BETWEEN", rule: `ALL $.itens[*].valor BETWEEN 50 AND 150`, expected: true},
+		{name: "ANY em array", rule: `ANY $.tags == "pj"`, expected: true},
+		{name: "ANY com IN", rule: `ANY $.tags IN $.bloqueadas`, expected: false},
+		{name: "ALL com IN", rule: `ALL $.tags IN $.permitidas`, expected: true},
+		{name: "curingas aninhados", rule: `ANY $.itens[*].tags[*] == "c"`, expected: true},
+		{name: "ANY vazio", rule: `ANY $.vazio > 0`, expected: false},
+		{name: "ALL vazio", rule: `ALL $.vazio > 0`, expected: true},
+		{name: "ALL ausente", rule: `ALL $.ausente > 0`, expected: true},
+		{name: "ANY no OR", rule: `$.idade < 18 OR ANY $.itens[*].valor >= 150`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, collectionPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "IN com texto", rule: `$.estado IN "SP"`, message: "lista para IN deve ser [...] ou um caminho"},
+		{name: "IN com caminho que não é array", rule: `$.estado IN $.idade`, message: "IN exige um array"},
+		{name: "SUBSET OF sem array", rule: `$.estado SUBSET OF $.estados`, message: "SUBSET OF exige um array"},
+		{name: "ANY sem array", rule: `ANY $.idade > 1`, message: "ANY exige um array"},
+		{name: "item não numérico", rule: `ALL $.tags > 1`, message: "item 0: não numérico"},
+		{name: "curinga sem quantificador", rule: `$.itens[*].valor > 1`, message: "invalid array index: *"},
+		{name: "curinga em valor que não é array", rule: `ANY $.estado[*] == "S"`, message: "path $.estado is not an array"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRule(cenario.rule, collectionPayload())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
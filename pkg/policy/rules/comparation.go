@@ -14,6 +14,10 @@ func compareEquals(a, b interface{}) bool {
 		return false
 	}
 
+	if aTime, bTime, isTime := timeOperands(a, b); isTime {
+		return aTime.Equal(bTime)
+	}
+
 	aNum, aIsNum := convertToFloat64(a)
 	bNum, bIsNum := convertToFloat64(b)
 	if aIsNum && bIsNum {
@@ -6,9 +6,12 @@ import (
 	"strings"
 )
 
-func ifCondition(trimmedRule string, data map[string]interface{}) RuleExecutionResult {
+// ifRuleRe separa a condição e a ação de "IF <condição> THEN <ação>".
+var ifRuleRe = regexp.MustCompile(`^IF\s+(.+?)\s+THEN\s+(.+)$`)
+
+func ifCondition(trimmedRule string, ctx *Context, node *TraceNode) RuleExecutionResult {
 	if strings.HasPrefix(trimmedRule, "IF ") {
-		parts := regexp.MustCompile(`^IF\s+(.+?)\s+THEN\s+(.+)$`).FindStringSubmatch(trimmedRule)
+		parts := ifRuleRe.FindStringSubmatch(trimmedRule)
 		if len(parts) != 3 {
 			return RuleExecutionResult{
 				Executed: true,
@@ -18,7 +21,9 @@ func ifCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 			}
 		}
 		conditionStr, actionStr := strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
-		conditionMet, condDetails, errCond := EvaluateRule(conditionStr, data)
+		condNode := node.Child(TraceIfCond, conditionStr)
+		conditionMet, condDetails, errCond := evaluateRule(conditionStr, ctx, condNode)
+		condNode.Finish(conditionMet, errCond)
 		if errCond != nil {
 			return RuleExecutionResult{
 				Executed: true,
@@ -28,7 +33,9 @@ func ifCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 			}
 		}
 		if conditionMet {
-			actionPassed, actionDetails, actionErr := EvaluateRule(actionStr, data) // Ação pode ser SET com EXP
+			actionNode := node.Child(TraceIfThen, actionStr)
+			actionPassed, actionDetails, actionErr := evaluateRule(actionStr, ctx, actionNode) // Ação pode ser SET com EXP
+			actionNode.Finish(actionPassed, actionErr)
 			if actionErr != nil {
 				return RuleExecutionResult{
 					Executed: true,
@@ -44,6 +51,7 @@ func ifCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 				Err:      nil,
 			}
 		}
+		node.Skip(TraceIfThen, actionStr)
 		return RuleExecutionResult{
 			Executed: true,
 			Passed:   true,
@@ -57,12 +65,14 @@ func ifCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 	}
 }
 
-func orCondition(trimmedRule string, data map[string]interface{}) RuleExecutionResult {
+func orCondition(trimmedRule string, ctx *Context, node *TraceNode) RuleExecutionResult {
 	if strings.Contains(trimmedRule, " OR ") && !isOperatorProtected(trimmedRule, " OR ") {
 		parts := strings.SplitN(trimmedRule, " OR ", 2)
 		if len(parts) == 2 {
 			leftRule, rightRule := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
-			leftPassed, leftDetails, leftErr := EvaluateRule(leftRule, data)
+			leftNode := node.Child(TraceOrLeft, leftRule)
+			leftPassed, leftDetails, leftErr := evaluateRule(leftRule, ctx, leftNode)
+			leftNode.Finish(leftPassed, leftErr)
 			if leftErr != nil {
 				return RuleExecutionResult{
 					Executed: true,
@@ -72,6 +82,7 @@ func orCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 				}
 			}
 			if leftPassed {
+				node.Skip(TraceOrRight, rightRule)
 				return RuleExecutionResult{
 					Executed: true,
 					Passed:   true,
@@ -79,7 +90,9 @@ func orCondition(trimmedRule string, data map[string]interface{}) RuleExecutionR
 					Err:      nil,
 				}
 			}
-			rightPassed, rightDetails, rightErr := EvaluateRule(rightRule, data)
+			rightNode := node.Child(TraceOrRight, rightRule)
+			rightPassed, rightDetails, rightErr := evaluateRule(rightRule, ctx, rightNode)
+			rightNode.Finish(rightPassed, rightErr)
 			if rightErr != nil {
 				return RuleExecutionResult{
 					Executed: true,
@@ -0,0 +1,277 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
+)
+
+// Raízes somente leitura disponíveis para as regras
+const (
+	RootContext = "ctx"  // Contexto da requisição (ex.: $ctx.userId, $ctx.source)
+	RootMeta    = "meta" // Metadados da requisição (ex.: $meta.id, $meta.timestamp)
+	RootEnv     = "env"  // Valores de ambiente fornecidos pelo motor (ex.: $env.stage)
+)
+
+// RootVariables é a raiz das variáveis de LET e das expressões nomeadas, lidas como
+// "@nome" (ou "@nome.campo"). A raiz é retornada por ParsePath como "@".
+const RootVariables = "@"
+
+// RootParams é a raiz dos parâmetros da política, lidos como ":nome" (ou ":nome.campo").
+// A raiz é retornada por ParsePath como ":".
+const RootParams = ":"
+
+var (
+	rootPathRe     = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)(.*)$`)
+	variablePathRe = regexp.MustCompile(`^@([A-Za-z_][A-Za-z0-9_]*)([.\[].*)?$`)
+	paramPathRe    = regexp.MustCompile(`^:([A-Za-z_][A-Za-z0-9_]*)([.\[].*)?$`)
+	variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Context agrupa o estado de uma avaliação de regras: os dados da requisição, que as
+// regras podem alterar, as raízes somente leitura acessíveis como "$<raiz>.campo" e as
+// variáveis ("@nome") e parâmetros (":nome"), que nunca fazem parte dos dados.
+type Context struct {
+	Data        map[string]interface{}
+	Vars        map[string]interface{} // Variáveis definidas por LET
+	Expressions map[string]string      // Expressões nomeadas, avaliadas a cada leitura de "@nome"
+	Params      map[string]interface{} // Parâmetros da política, lidos como ":nome" (somente leitura)
+	Roots       map[string]map[string]interface{}
+	Functions   *FunctionRegistry // Funções chamáveis nas regras; nil usa apenas as nativas
+	Tables      *TableSet         // Tabelas de referência lidas por TABLE e LOOKUP
+	Clock       func() time.Time  // Relógio de NOW() e AGE(); nil usa o horário atual
+	Decimal     bool              // EXP e comparações numéricas em decimal (ver decimalArithmetic)
+	// Itens percorridos por cada FOREACH, ALL ou ANY; 0 usa DefaultMaxIterations
+	MaxIterations int
+
+	expanding map[string]bool // Expressões nomeadas em avaliação, para detectar ciclos
+}
+
+// NewContext cria um contexto de avaliação para os dados informados.
+func NewContext(data map[string]interface{}) *Context {
+	return &Context{Data: data}
+}
+
+// WithRoot registra uma raiz somente leitura (ex.: WithRoot(RootContext, {"userId": "u1"})).
+func (c *Context) WithRoot(name string, values map[string]interface{}) *Context {
+	if c.Roots == nil {
+		c.Roots = make(map[string]map[string]interface{})
+	}
+	c.Roots[name] = values
+	return c
+}
+
+// now retorna o horário atual segundo o relógio do contexto.
+func (c *Context) now() time.Time {
+	if c == nil || c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock()
+}
+
+// functions retorna o registro de funções da avaliação (nil: apenas as funções nativas).
+func (c *Context) functions() *FunctionRegistry {
+	if c == nil {
+		return nil
+	}
+	return c.Functions
+}
+
+// decimal indica se a aritmética e as comparações numéricas usam decimal.
+func (c *Context) decimal() bool {
+	return c != nil && c.Decimal
+}
+
+// isPath indica se o operando é um caminho ("$.campo", "$<raiz>.campo", "@variavel" ou
+// ":parametro") em vez de um literal.
+func isPath(operand string) bool {
+	return strings.HasPrefix(operand, "$.") || rootPathRe.MatchString(operand) || variablePathRe.MatchString(operand) || paramPathRe.MatchString(operand)
+}
+
+// splitRoot separa "$ctx.userId" em ("ctx", "$.userId"), "@total.valor" em ("@", "$.total.valor")
+// e ":taxa" em (":", "$.taxa"). Caminhos "$." retornam raiz vazia.
+func splitRoot(path string) (string, string) {
+	if strings.HasPrefix(path, "$.") {
+		return "", path
+	}
+	for _, root := range []string{RootVariables, RootParams} {
+		if strings.HasPrefix(path, root) {
+			return root, "$." + strings.TrimPrefix(path, root)
+		}
+	}
+	matches := rootPathRe.FindStringSubmatch(path)
+	if matches == nil {
+		return "", path
+	}
+	return matches[1], "$" + matches[2]
+}
+
+// resolve obtém o valor de um caminho nos dados ou em uma das raízes somente leitura.
+func (c *Context) resolve(path string) (interface{}, error) {
+	root, rest := splitRoot(path)
+	if root == "" {
+		return getValue(c.Data, path)
+	}
+	if root == RootVariables {
+		values, err := c.variable(path)
+		if err != nil {
+			return nil, err
+		}
+		return getValue(values, rest)
+	}
+	if root == RootParams {
+		if err := c.checkParam(path); err != nil {
+			return nil, err
+		}
+		return getValue(c.Params, rest)
+	}
+	values, exists := c.Roots[root]
+	if !exists {
+		return nil, fmt.Errorf("raiz desconhecida '$%s' no caminho %s", root, path)
+	}
+	if rest == "$" {
+		return values, nil
+	}
+	return getValue(values, rest)
+}
+
+// exists indica se o caminho existe nos dados ou na raiz, mesmo que com valor null.
+func (c *Context) exists(path string) (bool, error) {
+	root, rest := splitRoot(path)
+	if root == "" {
+		return hasValue(c.Data, path)
+	}
+	if root == RootVariables {
+		if !c.defines(variableName(path)) {
+			return false, nil
+		}
+		values, err := c.variable(path)
+		if err != nil {
+			return false, err
+		}
+		return hasValue(values, rest)
+	}
+	if root == RootParams {
+		return hasValue(c.Params, rest)
+	}
+	values, exists := c.Roots[root]
+	if !exists {
+		return false, fmt.Errorf("raiz desconhecida '$%s' no caminho %s", root, path)
+	}
+	if rest == "$" {
+		return true, nil
+	}
+	return hasValue(values, rest)
+}
+
+// Lookup obtém o valor de um caminho ("$.campo" ou "$<raiz>.campo") como as regras o enxergam.
+func (c *Context) Lookup(path string) (interface{}, error) {
+	value, err := c.resolve(path)
+	return plainValue(value), err
+}
+
+// assign define o valor de um caminho nos dados. Raízes somente leitura não podem ser alteradas.
+// O valor é copiado, para que objetos lidos de uma raiz, variável ou parâmetro (ex.: $ctx) não
+// sejam alterados por regras seguintes através dos dados.
+func (c *Context) assign(path string, value interface{}) error {
+	if err := c.checkWritable(path); err != nil {
+		return err
+	}
+	return setValue(c.Data, path, patch.Clone(value))
+}
+
+// remove apaga um caminho dos dados. Raízes somente leitura não podem ser alteradas.
+func (c *Context) remove(path string) (bool, error) {
+	if err := c.checkWritable(path); err != nil {
+		return false, err
+	}
+	return deleteValue(c.Data, path)
+}
+
+func (c *Context) checkWritable(path string) error {
+	root, _ := splitRoot(path)
+	if root == RootVariables {
+		return fmt.Errorf("variável %s só pode ser definida com LET", path)
+	}
+	if root == RootParams {
+		return fmt.Errorf("parâmetro %s é somente leitura", path)
+	}
+	if root != "" {
+		return fmt.Errorf("caminho %s é somente leitura", path)
+	}
+	return nil
+}
+
+// variableName retorna o nome da variável de um caminho "@nome..." ("" se não for variável).
+func variableName(path string) string {
+	matches := variablePathRe.FindStringSubmatch(path)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// checkParam verifica se o parâmetro lido pelo caminho foi declarado pela política.
+func (c *Context) checkParam(path string) error {
+	matches := paramPathRe.FindStringSubmatch(path)
+	if matches == nil {
+		return fmt.Errorf("caminho inválido: %s", path)
+	}
+	if _, exists := c.Params[matches[1]]; !exists {
+		return fmt.Errorf("parâmetro :%s não definido", matches[1])
+	}
+	return nil
+}
+
+// defines indica se a variável foi definida por LET ou é uma expressão nomeada.
+func (c *Context) defines(name string) bool {
+	_, isVar := c.Vars[name]
+	_, isExpr := c.Expressions[name]
+	return isVar || isExpr
+}
+
+// variable retorna, como um objeto com uma única chave, o valor da variável lida pelo
+// caminho: o definido por LET ou, sem ele, o resultado da expressão nomeada, avaliada no
+// estado atual dos dados.
+func (c *Context) variable(path string) (map[string]interface{}, error) {
+	name := variableName(path)
+	if value, exists := c.Vars[name]; exists {
+		return map[string]interface{}{name: value}, nil
+	}
+	expr, exists := c.Expressions[name]
+	if !exists {
+		return nil, fmt.Errorf("variável @%s não definida", name)
+	}
+	if c.expanding[name] {
+		return nil, fmt.Errorf("expressão nomeada @%s depende de si mesma", name)
+	}
+	if c.expanding == nil {
+		c.expanding = make(map[string]bool)
+	}
+	c.expanding[name] = true
+	defer delete(c.expanding, name)
+
+	value, _, err := evaluateOperand(expr, c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("expressão nomeada @%s: %v", name, err)
+	}
+	return map[string]interface{}{name: value}, nil
+}
+
+// define atribui o valor de uma variável de LET. Expressões nomeadas não podem ser redefinidas.
+func (c *Context) define(name string, value interface{}) error {
+	if !variableNameRe.MatchString(name) {
+		return fmt.Errorf("nome de variável inválido: '%s'", name)
+	}
+	if _, exists := c.Expressions[name]; exists {
+		return fmt.Errorf("LET não pode redefinir a expressão nomeada @%s", name)
+	}
+	if c.Vars == nil {
+		c.Vars = make(map[string]interface{})
+	}
+	c.Vars[name] = value
+	return nil
+}
@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateRuleWithContextRoots(t *testing.T) {
+	newCtx := func() *Context {
+		return NewContext(map[string]interface{}{"valor": 150.0}).
+			WithRoot(RootContext, map[string]interface{}{"userId": "user-123", "source": "web"}).
+			WithRoot(RootMeta, map[string]interface{}{"id": "req-001", "timestamp": "2025-04-25T12:00:00Z"}).
+			WithRoot(RootEnv, map[string]interface{}{"stage": "prod", "limite": 100.0})
+	}
+
+	all_rules := []struct {
+		name     string
+		rule     string
+		passed   bool
+		hasError bool
+	}{
+		{name: "ctx string", rule: `$ctx.source == "web"`, passed: true},
+		{name: "meta id", rule: `$meta.id != null`, passed: true},
+		{name: "env comparado com dados", rule: `$.valor > $env.limite`, passed: true},
+		{name: "env em expressão", rule: `EXP($env.limite * 2) > $.valor`, passed: true},
+		{name: "campo ausente", rule: `$ctx.tenant == null`, passed: true},
+		{name: "if com ctx", rule: `IF $ctx.source == "web" THEN SET $.canal = $ctx.source`, passed: true},
+		{name: "raiz desconhecida", rule: `$foo.bar == 1`, hasError: true},
+		{name: "set em raiz somente leitura", rule: `SET $ctx.userId = "outro"`, hasError: true},
+		{name: "delete em raiz somente leitura", rule: `DELETE $meta.id`, hasError: true},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_rules {
+			ctx := newCtx()
+			passed, _, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+
+			if cenario.hasError {
+				assert.Error(t, err, cenario.name)
+				continue
+			}
+			assert.NoError(t, err, cenario.name)
+			assert.Equal(t, cenario.passed, passed, cenario.name)
+			assert.Equal(t, "user-123", ctx.Roots[RootContext]["userId"], cenario.name)
+		}
+	})
+
+	t.Run("set copia valor da raiz para os dados", func(t *testing.T) {
+		ctx := newCtx()
+		_, _, err := EvaluateRuleWithContext(`SET $.origem = $ctx.source`, ctx, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "web", ctx.Data["origem"])
+	})
+
+	t.Run("set de raiz inteira copia o valor", func(t *testing.T) {
+		ctx := newCtx()
+		for _, rule := range []string{`SET $.c = $ctx`, `SET $.c.userId = "outro"`, `ADD $ctx TO $.ids`, `SET $.ids[0].source = "api"`} {
+			_, details, err := EvaluateRuleWithContext(rule, ctx, nil)
+			require.NoError(t, err, details)
+		}
+
+		assert.Equal(t, "outro", ctx.Data["c"].(map[string]interface{})["userId"])
+		assert.Equal(t, map[string]interface{}{"userId": "user-123", "source": "web"}, ctx.Roots[RootContext])
+	})
+}
+
+func TestVariables(t *testing.T) {
+	newCtx := func() *Context {
+		ctx := NewContext(map[string]interface{}{"valor": 200.0, "frete": 15.0, "itens": []interface{}{10.0, 20.0}})
+		ctx.Expressions = map[string]string{
+			"desconto": "EXP($.valor * 0.1)",
+			"total":    "EXP($.valor + $.frete)",
+			"ciclo":    "@ciclo",
+		}
+		return ctx
+	}
+
+	all_rules := []struct {
+		name    string
+		rules   []string
+		passed  bool
+		message string
+	}{
+		{name: "LET lido em comparação", rules: []string{`LET limite = 150`, `$.valor > @limite`}, passed: true},
+		{name: "LET com EXP", rules: []string{`LET base = EXP($.valor - $.frete)`, `EXP(@base * 2) == 370`}, passed: true},
+		{name: "LET com função", rules: []string{`LET nome = UPPER("ana")`, `@nome == "ANA"`}, passed: true},
+		{name: "LET com objeto", rules: []string{`LET cliente = $.cliente`, `@cliente == null`}, passed: true},
+		{name: "LET redefinido", rules: []string{`LET x = 1`, `LET x = EXP(@x + 1)`, `@x == 2`}, passed: true},
+		{name: "LET em IF", rules: []string{`IF $.valor > 100 THEN LET faixa = "alta"`, `@faixa == "alta"`}, passed: true},
+		{name: "campo de variável", rules: []string{`LET lista = $.itens`, `@lista[1] == 20`}, passed: true},
+		{name: "IN com variável", rules: []string{`LET lista = $.itens`, `20 IN @lista`}, passed: true},
+		{name: "expressão nomeada", rules: []string{`@desconto == 20`}, passed: true},
+		{name: "expressão nomeada reavaliada", rules: []string{`SET $.valor = 100`, `@desconto == 10`}, passed: true},
+		{name: "expressão nomeada em LET", rules: []string{`LET pagar = EXP(@total - @desconto)`, `@pagar == 195`}, passed: true},
+		{name: "EXISTS de variável", rules: []string{`EXISTS(@x) == false`, `LET x = null`, `EXISTS(@x)`}, passed: true},
+		{name: "variável não definida", rules: []string{`@x > 1`}, message: "variável @x não definida"},
+		{name: "expressão nomeada cíclica", rules: []string{`@ciclo == 1`}, message: "expressão nomeada @ciclo depende de si mesma"},
+		{name: "LET sobre expressão nomeada", rules: []string{`LET total = 1`}, message: "LET não pode redefinir a expressão nomeada @total"},
+		{name: "LET com nome inválido", rules: []string{`LET $.x = 1`}, message: "nome de variável inválido"},
+		{name: "SET em variável", rules: []string{`SET @x = 1`}, message: "variável @x só pode ser definida com LET"},
+	}
+
+	for _, cenario := range all_rules {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := newCtx()
+			var passed bool
+			var details string
+			var err error
+			for _, rule := range cenario.rules {
+				if passed, details, err = EvaluateRuleWithContext(rule, ctx, nil); err != nil {
+					break
+				}
+			}
+
+			if cenario.message != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), cenario.message)
+				return
+			}
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.passed, passed, details)
+		})
+	}
+
+	t.Run("variáveis não alteram os dados", func(t *testing.T) {
+		ctx := newCtx()
+		for _, rule := range []string{`LET taxa = EXP($.valor * 0.05)`, `SET $.taxa = @taxa`} {
+			_, details, err := EvaluateRuleWithContext(rule, ctx, nil)
+			require.NoError(t, err, details)
+		}
+		assert.Equal(t, map[string]interface{}{"taxa": 10.0}, ctx.Vars)
+		assert.Equal(t, 10.0, ctx.Data["taxa"])
+		assert.NotContains(t, ctx.Data, "desconto")
+		assert.Len(t, ctx.Data, 4)
+	})
+}
+
+func TestParams(t *testing.T) {
+	newCtx := func() *Context {
+		ctx := NewContext(map[string]interface{}{"valor": 200.0, "moeda": "BRL", "itens": []interface{}{map[string]interface{}{"preco": 10.0}}})
+		ctx.Params = map[string]interface{}{
+			"taxa":    0.1,
+			"moedas":  []interface{}{"BRL", "USD"},
+			"limites": map[string]interface{}{"max": 500.0},
+			"vazio":   nil,
+		}
+		return ctx
+	}
+
+	all_rules := []struct {
+		name    string
+		rule    string
+		passed  bool
+		message string
+	}{
+		{name: "parâmetro em EXP", rule: `EXP($.valor * :taxa) == 20`, passed: true},
+		{name: "IN com parâmetro", rule: `$.moeda IN :moedas`, passed: true},
+		{name: "campo de parâmetro", rule: `$.valor < :limites.max`, passed: true},
+		{name: "parâmetro em função", rule: `LEN(:moedas) == 2`, passed: true},
+		{name: "parâmetro null", rule: `:vazio == null`, passed: true},
+		{name: "laço sobre parâmetro", rule: `ANY m IN :moedas: m == "USD"`, passed: true},
+		{name: "parâmetro no corpo do laço", rule: `FOREACH item IN $.itens DO SET item.taxa = EXP(item.preco * :taxa)`, passed: true},
+		{name: "EXISTS de parâmetro", rule: `EXISTS(:taxa)`, passed: true},
+		{name: "EXISTS de parâmetro não definido", rule: `EXISTS(:outro)`, passed: false},
+		{name: "parâmetro não definido", rule: `$.valor > :minimo`, message: "parâmetro :minimo não definido"},
+		{name: "SET em parâmetro", rule: `SET :taxa = 1`, message: "parâmetro :taxa é somente leitura"},
+		{name: "SET no item de um parâmetro", rule: `FOREACH m IN :moedas DO SET m = "EUR"`, message: "parâmetro :moedas[0] é somente leitura"},
+	}
+
+	for _, cenario := range all_rules {
+		t.Run(cenario.name, func(t *testing.T) {
+			passed, details, err := EvaluateRuleWithContext(cenario.rule, newCtx(), nil)
+			if cenario.message != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), cenario.message)
+				return
+			}
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.passed, passed, details)
+		})
+	}
+}
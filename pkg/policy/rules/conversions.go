@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// missingValue é o argumento recebido pelas funções que aceitam caminhos ausentes
+// (ver AcceptsMissingPaths) no lugar de um caminho que não existe nos dados. Ele permite
+// distinguir o campo ausente do campo presente com null.
+type missingValue struct{}
+
+var missing = missingValue{}
+
+// isAbsent indica se o argumento é null ou um caminho ausente.
+func isAbsent(value interface{}) bool {
+	return value == nil || value == missing
+}
+
+// existsFunc implementa EXISTS(caminho): true se o caminho existe, mesmo que com null.
+func existsFunc(_ *Context, args []interface{}) (interface{}, error) {
+	return args[0] != missing, nil
+}
+
+// typeCheckFunc cria as funções IS_STRING, IS_NUMBER, IS_ARRAY e IS_OBJECT. Caminhos
+// ausentes e null resultam em false.
+func typeCheckFunc(matches func(value interface{}) bool) func(*Context, []interface{}) (interface{}, error) {
+	return func(_ *Context, args []interface{}) (interface{}, error) {
+		return !isAbsent(args[0]) && matches(args[0]), nil
+	}
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+// isNumber aceita apenas valores numéricos; strings numéricas não são números (ver TO_NUMBER).
+func isNumber(value interface{}) bool {
+	if _, ok := value.(string); ok {
+		return false
+	}
+	_, ok := convertToFloat64(value)
+	return ok
+}
+
+func isArray(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+func isObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// isEmptyFunc implementa IS_EMPTY(valor): true para caminho ausente, null, texto vazio e
+// array ou objeto sem itens. Números e booleanos nunca são vazios.
+func isEmptyFunc(_ *Context, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil, missingValue:
+		return true, nil
+	case string:
+		return v == "", nil
+	case []interface{}:
+		return len(v) == 0, nil
+	case map[string]interface{}:
+		return len(v) == 0, nil
+	}
+	return false, nil
+}
+
+// coalesceFunc implementa COALESCE(a, b, ...): o primeiro argumento que não é null nem um
+// caminho ausente, ou null se não houver.
+func coalesceFunc(_ *Context, args []interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if !isAbsent(arg) {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
+
+// defaultFunc implementa DEFAULT(caminho, valor): o valor do caminho ou, se ele estiver
+// ausente ou for null, o valor padrão.
+func defaultFunc(_ *Context, args []interface{}) (interface{}, error) {
+	if !isAbsent(args[0]) {
+		return args[0], nil
+	}
+	if args[1] == missing {
+		return nil, nil
+	}
+	return args[1], nil
+}
+
+// conversionFunc cria as funções TO_NUMBER, TO_STRING e TO_BOOL. Um valor que não pode ser
+// convertido é um erro da regra, a menos que um valor padrão seja informado como segundo
+// argumento (ex.: TO_NUMBER($.quantidade, 0)).
+func conversionFunc(convert func(value interface{}) (interface{}, error)) func(*Context, []interface{}) (interface{}, error) {
+	return func(_ *Context, args []interface{}) (interface{}, error) {
+		result, err := convert(args[0])
+		if err != nil && len(args) > 1 {
+			return args[1], nil
+		}
+		return result, err
+	}
+}
+
+// toNumber converte números, strings numéricas ("12.5", " 3 ") e booleanos (1 e 0).
+func toNumber(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("argumento nulo")
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fmt.Errorf("'%s' não é um número", v)
+		}
+		return num, nil
+	}
+	if num, ok := convertToFloat64(value); ok {
+		return num, nil
+	}
+	return nil, fmt.Errorf("valor %v (%T) não pode ser convertido em número", value, value)
+}
+
+// toString converte valores simples como em textValue e arrays e objetos em JSON compacto.
+func toString(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(encoded), nil
+	}
+	return textValue(value)
+}
+
+// toBool converte booleanos, os textos "true"/"false" (sem diferenciar maiúsculas) e
+// "1"/"0", e os números 1 e 0.
+func toBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("argumento nulo")
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("'%s' não é um booleano", v)
+	}
+	if num, ok := convertToFloat64(value); ok && (num == 0 || num == 1) {
+		return num == 1, nil
+	}
+	return nil, fmt.Errorf("valor %v (%T) não pode ser convertido em booleano", value, value)
+}
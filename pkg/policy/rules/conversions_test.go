@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func conversionPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"nome":       "Maria",
+		"apelido":    nil,
+		"vazio":      "",
+		"idade":      30.0,
+		"quantidade": " 12 ",
+		"ativo":      "TRUE",
+		"tags":       []interface{}{},
+		"cliente":    map[string]interface{}{"tipo": "premium", "limite": nil},
+		"itens":      []interface{}{map[string]interface{}{"valor": 10.0}},
+	}
+}
+
+func TestTypeFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "EXISTS", rule: `EXISTS($.nome)`, expected: true},
+		{name: "EXISTS com null", rule: `EXISTS($.apelido)`, expected: true},
+		{name: "EXISTS ausente", rule: `EXISTS($.email)`, expected: false},
+		{name: "EXISTS com objeto intermediário ausente", rule: `EXISTS($.endereco.cep)`, expected: false},
+		{name: "EXISTS em objeto aninhado", rule: `EXISTS($.cliente.limite)`, expected: true},
+		{name: "EXISTS com índice", rule: `EXISTS($.itens[0].valor)`, expected: true},
+		{name: "EXISTS com índice fora do array", rule: `EXISTS($.itens[3].valor)`, expected: false},
+		{name: "EXISTS em raiz", rule: `EXISTS($ctx.userId)`, expected: true},
+		{name: "EXISTS comparado", rule: `EXISTS($.email) == false`, expected: true},
+		{name: "IS_STRING", rule: `IS_STRING($.nome)`, expected: true},
+		{name: "IS_STRING com null", rule: `IS_STRING($.apelido)`, expected: false},
+		{name: "IS_NUMBER", rule: `IS_NUMBER($.idade)`, expected: true},
+		{name: "IS_NUMBER com string numérica", rule: `IS_NUMBER($.quantidade)`, expected: false},
+		{name: "IS_ARRAY", rule: `IS_ARRAY($.tags)`, expected: true},
+		{name: "IS_OBJECT", rule: `IS_OBJECT($.cliente)`, expected: true},
+		{name: "IS_OBJECT ausente", rule: `IS_OBJECT($.endereco)`, expected: false},
+		{name: "IS_EMPTY texto vazio", rule: `IS_EMPTY($.vazio)`, expected: true},
+		{name: "IS_EMPTY array vazio", rule: `IS_EMPTY($.tags)`, expected: true},
+		{name: "IS_EMPTY ausente", rule: `IS_EMPTY($.endereco.cep)`, expected: true},
+		{name: "IS_EMPTY número", rule: `IS_EMPTY($.idade)`, expected: false},
+		{name: "condição no IF", rule: `IF EXISTS($.email) THEN SET $.x = 1`, expected: true},
+		{name: "condição no OR", rule: `EXISTS($.email) OR IS_STRING($.nome)`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := NewContext(conversionPayload()).WithRoot(RootContext, map[string]interface{}{"userId": "u1"})
+			actual, details, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+}
+
+func TestNullFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "COALESCE", rule: `COALESCE($.email, $.apelido, $.nome) == "Maria"`, expected: true},
+		{name: "COALESCE com objeto ausente", rule: `COALESCE($.endereco.cidade, "SP") == "SP"`, expected: true},
+		{name: "COALESCE sem valores", rule: `COALESCE($.email, $.apelido) == null`, expected: true},
+		{name: "DEFAULT ausente", rule: `DEFAULT($.email, "sem email") == "sem email"`, expected: true},
+		{name: "DEFAULT com null", rule: `DEFAULT($.cliente.limite, 100) == 100`, expected: true},
+		{name: "DEFAULT presente", rule: `DEFAULT($.idade, 0) == 30`, expected: true},
+		{name: "DEFAULT em EXP", rule: `EXP(DEFAULT($.desconto, 0) + $.idade) == 30`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, conversionPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+}
+
+func TestConversionFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "TO_NUMBER de texto", rule: `TO_NUMBER($.quantidade) > 10`, expected: true},
+		{name: "TO_NUMBER de booleano", rule: `TO_NUMBER(true) == 1`, expected: true},
+		{name: "TO_NUMBER com padrão", rule: `TO_NUMBER($.nome, 0) == 0`, expected: true},
+		{name: "TO_NUMBER de ausente com padrão", rule: `TO_NUMBER($.email, -1) == -1`, expected: true},
+		{name: "TO_STRING de número", rule: `TO_STRING($.idade) == "30"`, expected: true},
+		{name: "TO_STRING de objeto", rule: `TO_STRING($.cliente) == '{"limite":null,"tipo":"premium"}'`, expected: true},
+		{name: "TO_STRING de null com padrão", rule: `TO_STRING($.apelido, "-") == "-"`, expected: true},
+		{name: "TO_BOOL de texto", rule: `TO_BOOL($.ativo) == true`, expected: true},
+		{name: "TO_BOOL de número", rule: `TO_BOOL(0) == false`, expected: true},
+		{name: "TO_BOOL como condição", rule: `TO_BOOL($.ativo)`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, conversionPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "TO_NUMBER inválido", rule: `TO_NUMBER($.nome) > 1`, message: "TO_NUMBER: 'Maria' não é um número"},
+		{name: "TO_NUMBER de ausente", rule: `TO_NUMBER($.email) > 1`, message: "TO_NUMBER: argumento nulo"},
+		{name: "TO_NUMBER de array", rule: `TO_NUMBER($.tags) > 1`, message: "não pode ser convertido em número"},
+		{name: "TO_BOOL inválido", rule: `TO_BOOL($.nome)`, message: "TO_BOOL: 'Maria' não é um booleano"},
+		{name: "TO_BOOL de número", rule: `TO_BOOL(2)`, message: "não pode ser convertido em booleano"},
+		{name: "TO_STRING de null", rule: `TO_STRING($.apelido) == ""`, message: "TO_STRING: argumento nulo"},
+		{name: "condição que não é booleana", rule: `UPPER($.nome)`, message: "não resulta em booleano"},
+		{name: "função desconhecida como condição", rule: `COUNT($.tags)`, message: "função não implementada: COUNT"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRule(cenario.rule, conversionPayload())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestSetWithNullFunctions(t *testing.T) {
+	data := conversionPayload()
+
+	passed, details, err := EvaluateRule(`SET $.contato = COALESCE($.email, $.nome)`, data)
+	require.NoError(t, err, details)
+	assert.True(t, passed)
+	assert.Equal(t, "Maria", data["contato"])
+
+	passed, details, err = EvaluateRule(`SET $.quantidade = TO_NUMBER($.quantidade)`, data)
+	require.NoError(t, err, details)
+	assert.True(t, passed)
+	assert.Equal(t, 12.0, data["quantidade"])
+
+	passed, _, err = EvaluateRule(`SET $.quantidade = TO_NUMBER($.nome)`, data)
+	assert.Error(t, err)
+	assert.False(t, passed)
+	assert.Equal(t, 12.0, data["quantidade"])
+}
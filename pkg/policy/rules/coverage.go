@@ -0,0 +1,87 @@
+package rules
+
+import "strings"
+
+// BranchIfElse identifica o ramo de um IF em que a condição é falsa e a ação é ignorada.
+const BranchIfElse = "if.else"
+
+// Branch é um ramo de uma regra para fins de cobertura: um lado de um OR (TraceOrLeft,
+// TraceOrRight) ou um resultado da condição de um IF (TraceIfThen, BranchIfElse).
+// O ID é o caminho de tipos desde a raiz da regra (ex.: "or.right/if.then").
+type Branch struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Expr string `json:"expr"`
+}
+
+// Branches lista os ramos de uma regra seguindo a mesma decomposição da avaliação:
+// laços, OR e IF, com o corpo, os lados e a ação decompostos recursivamente. Os ramos do
+// corpo de um laço ficam sob "item/" e são cobertos por qualquer item.
+func Branches(rule string) []Branch {
+	return collectBranches(rule, "", nil)
+}
+
+func collectBranches(rule, prefix string, branches []Branch) []Branch {
+	rule = strings.TrimSpace(rule)
+
+	if l, matched, err := splitLoop(rule); matched {
+		if err != nil {
+			return branches
+		}
+		return collectBranches(l.body, prefix+TraceItem+"/", branches)
+	}
+
+	if strings.Contains(rule, " OR ") && !isOperatorProtected(rule, " OR ") {
+		parts := strings.SplitN(rule, " OR ", 2)
+		left, right := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		branches = append(branches, Branch{ID: prefix + TraceOrLeft, Kind: TraceOrLeft, Expr: left})
+		branches = collectBranches(left, prefix+TraceOrLeft+"/", branches)
+		branches = append(branches, Branch{ID: prefix + TraceOrRight, Kind: TraceOrRight, Expr: right})
+		return collectBranches(right, prefix+TraceOrRight+"/", branches)
+	}
+
+	if strings.HasPrefix(rule, "IF ") {
+		parts := ifRuleRe.FindStringSubmatch(rule)
+		if len(parts) != 3 {
+			return branches
+		}
+		condition, action := strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+		branches = collectBranches(condition, prefix+TraceIfCond+"/", branches)
+		branches = append(branches, Branch{ID: prefix + TraceIfThen, Kind: TraceIfThen, Expr: action})
+		branches = collectBranches(action, prefix+TraceIfThen+"/", branches)
+		return append(branches, Branch{ID: prefix + BranchIfElse, Kind: BranchIfElse, Expr: condition})
+	}
+	return branches
+}
+
+// CoveredBranches retorna os IDs (ver Branch) dos ramos exercitados no trace de uma regra.
+func CoveredBranches(node *TraceNode) []string {
+	return collectCovered(node, "", nil)
+}
+
+func collectCovered(node *TraceNode, prefix string, covered []string) []string {
+	if node == nil {
+		return covered
+	}
+	for _, child := range node.Children {
+		switch child.Kind {
+		case TraceOrLeft, TraceOrRight:
+			if !child.Skipped {
+				covered = append(covered, prefix+child.Kind)
+				covered = collectCovered(child, prefix+child.Kind+"/", covered)
+			}
+		case TraceIfCond:
+			covered = collectCovered(child, prefix+TraceIfCond+"/", covered)
+		case TraceItem:
+			covered = collectCovered(child, prefix+TraceItem+"/", covered)
+		case TraceIfThen:
+			if child.Skipped {
+				covered = append(covered, prefix+BranchIfElse)
+			} else {
+				covered = append(covered, prefix+TraceIfThen)
+				covered = collectCovered(child, prefix+TraceIfThen+"/", covered)
+			}
+		}
+	}
+	return covered
+}
@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranches(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected []string
+	}{
+		{name: "condição simples", rule: `$.idade >= 18`, expected: nil},
+		{name: "OR", rule: `$.moeda == "BRL" OR $.moeda == "USD"`, expected: []string{"or.left", "or.right"}},
+		{name: "OR encadeado", rule: `$.a == 1 OR $.b == 2 OR $.c == 3`, expected: []string{"or.left", "or.right", "or.right/or.left", "or.right/or.right"}},
+		{name: "OR entre aspas", rule: `$.texto == "a OR b"`, expected: nil},
+		{name: "IF", rule: `IF $.tipo == "premium" THEN SET $.desconto = 10`, expected: []string{"if.then", "if.else"}},
+		{name: "IF aninhado", rule: `IF $.a == 1 THEN IF $.b == 2 THEN SET $.c = 3`, expected: []string{"if.then", "if.then/if.then", "if.then/if.else", "if.else"}},
+		{name: "laço com OR no corpo", rule: `ALL i IN $.itens: i.a == 1 OR i.b == 2`, expected: []string{"item/or.left", "item/or.right"}},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			var ids []string
+			for _, branch := range Branches(cenario.rule) {
+				ids = append(ids, branch.ID)
+			}
+			assert.Equal(t, cenario.expected, ids, cenario.name)
+		}
+	})
+}
+
+func TestCoveredBranches(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		data     map[string]interface{}
+		expected []string
+	}{
+		{name: "OR pelo lado esquerdo", rule: `$.a == 1 OR $.b == 2 OR $.c == 3`, data: map[string]interface{}{"a": 1.0}, expected: []string{"or.left"}},
+		{name: "OR até o último lado", rule: `$.a == 1 OR $.b == 2 OR $.c == 3`, data: map[string]interface{}{"a": 0.0, "b": 0.0, "c": 3.0}, expected: []string{"or.left", "or.right", "or.right/or.left", "or.right/or.right"}},
+		{name: "IF verdadeiro", rule: `IF $.a == 1 THEN IF $.b == 2 THEN SET $.c = 3`, data: map[string]interface{}{"a": 1.0, "b": 0.0}, expected: []string{"if.then", "if.then/if.else"}},
+		{name: "IF falso", rule: `IF $.a == 1 THEN SET $.c = 3`, data: map[string]interface{}{"a": 2.0}, expected: []string{"if.else"}},
+		{
+			name:     "ramos do laço por item",
+			rule:     `FOREACH i IN $.itens DO IF i.a == 1 THEN SET i.b = 2`,
+			data:     map[string]interface{}{"itens": []interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 1.0}}},
+			expected: []string{"item/if.then", "item/if.then"},
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			root := NewTrace(TracePolicy, "cobertura")
+			_, _, err := EvaluateRuleWithTrace(cenario.rule, cenario.data, root)
+			assert.NoError(t, err)
+			assert.Equal(t, cenario.expected, CoveredBranches(root.Children[0]))
+		})
+	}
+
+	assert.Nil(t, CoveredBranches(nil))
+}
@@ -0,0 +1,233 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// dateLayouts são os formatos aceitos ao converter texto em data. Formatos sem fuso são
+// interpretados no fuso informado (UTC por padrão).
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// dateLayoutTokens traduz os formatos no estilo "YYYY-MM-DD HH:mm:ss" para o layout do Go.
+var dateLayoutTokens = strings.NewReplacer(
+	"YYYY", "2006", "yyyy", "2006", "MM", "01", "DD", "02", "dd", "02",
+	"HH", "15", "mm", "04", "ss", "05",
+)
+
+// toTime converte um valor em data: datas como estão, texto em um dos formatos de
+// dateLayouts e números como segundos desde 01/01/1970 (Unix).
+func toTime(value interface{}, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), loc); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("data inválida: '%s'", v)
+	case nil:
+		return time.Time{}, fmt.Errorf("data nula")
+	}
+	if seconds, ok := convertToFloat64(value); ok {
+		sec, frac := math.Modf(seconds)
+		return time.Unix(int64(sec), int64(frac*1e9)).In(loc), nil
+	}
+	return time.Time{}, fmt.Errorf("valor %v (%T) não é uma data", value, value)
+}
+
+// locationArg carrega o fuso horário opcional (ex.: "America/Sao_Paulo") do argumento 'index'.
+func locationArg(args []interface{}, index int) (*time.Location, error) {
+	if len(args) <= index {
+		return nil, nil
+	}
+	name, err := textValue(args[index])
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("fuso horário inválido: '%s'", name)
+	}
+	return loc, nil
+}
+
+// timeOperands converte os operandos de uma comparação em datas quando ao menos um deles já
+// é uma data (ex.: $.vencimento < NOW()); o outro pode ser texto ou número.
+func timeOperands(a, b interface{}) (time.Time, time.Time, bool) {
+	_, aIsTime := a.(time.Time)
+	_, bIsTime := b.(time.Time)
+	if !aIsTime && !bIsTime {
+		return time.Time{}, time.Time{}, false
+	}
+	aTime, errA := toTime(a, nil)
+	bTime, errB := toTime(b, nil)
+	return aTime, bTime, errA == nil && errB == nil
+}
+
+// nowFunc implementa NOW([fuso]) usando o relógio do contexto.
+func nowFunc(ctx *Context, args []interface{}) (interface{}, error) {
+	loc, err := locationArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	now := ctx.now()
+	if loc != nil {
+		now = now.In(loc)
+	}
+	return now, nil
+}
+
+// dateFunc implementa DATE(valor[, fuso]): converte o valor em data. Com o fuso, datas sem
+// fuso são interpretadas nele e as demais são convertidas para ele.
+func dateFunc(_ *Context, args []interface{}) (interface{}, error) {
+	loc, err := locationArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	t, err := toTime(args[0], loc)
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t, nil
+}
+
+// parseDateFunc implementa PARSE_DATE(texto, formato[, fuso]). O formato aceita o layout do
+// Go ("02/01/2006") ou os marcadores YYYY, MM, DD, HH, mm e ss ("DD/MM/YYYY").
+func parseDateFunc(_ *Context, args []interface{}) (interface{}, error) {
+	text, err := textValue(args[0])
+	if err != nil {
+		return nil, err
+	}
+	layout, err := textValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	loc, err := locationArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(dateLayoutTokens.Replace(layout), text, loc)
+	if err != nil {
+		return nil, fmt.Errorf("data '%s' não corresponde ao formato '%s'", text, layout)
+	}
+	return t, nil
+}
+
+// dateAddFunc implementa DATE_ADD(data, quantidade, unidade), com unidade YEARS, MONTHS,
+// WEEKS, DAYS, HOURS, MINUTES ou SECONDS. Quantidades negativas subtraem.
+func dateAddFunc(_ *Context, args []interface{}) (interface{}, error) {
+	t, err := toTime(args[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := intValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	unit, err := dateUnit(args[2])
+	if err != nil {
+		return nil, err
+	}
+	switch unit {
+	case "YEAR":
+		return t.AddDate(amount, 0, 0), nil
+	case "MONTH":
+		return t.AddDate(0, amount, 0), nil
+	case "WEEK":
+		return t.AddDate(0, 0, 7*amount), nil
+	case "DAY":
+		return t.AddDate(0, 0, amount), nil
+	}
+	return t.Add(time.Duration(amount) * unitDurations[unit]), nil
+}
+
+// dateDiffFunc implementa DATE_DIFF(data1, data2, unidade): o número de unidades inteiras
+// (WEEKS, DAYS, HOURS, MINUTES ou SECONDS) de data2 até data1, negativo se data1 for anterior.
+func dateDiffFunc(_ *Context, args []interface{}) (interface{}, error) {
+	end, err := toTime(args[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	start, err := toTime(args[1], nil)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := dateUnit(args[2])
+	if err != nil {
+		return nil, err
+	}
+	duration, ok := unitDurations[unit]
+	if !ok {
+		return nil, fmt.Errorf("unidade não suportada em DATE_DIFF: %s (use AGE para anos)", unit)
+	}
+	return math.Trunc(float64(end.Sub(start)) / float64(duration)), nil
+}
+
+// unitDurations são as unidades de duração fixa usadas em DATE_DIFF (dias de 24 horas).
+var unitDurations = map[string]time.Duration{
+	"WEEK":   7 * 24 * time.Hour,
+	"DAY":    24 * time.Hour,
+	"HOUR":   time.Hour,
+	"MINUTE": time.Minute,
+	"SECOND": time.Second,
+}
+
+// dateUnit normaliza a unidade ("days", "DAY", "Hours"...) para o singular em maiúsculas.
+func dateUnit(value interface{}) (string, error) {
+	text, err := textValue(value)
+	if err != nil {
+		return "", err
+	}
+	unit := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(text)), "S")
+	switch unit {
+	case "YEAR", "MONTH", "WEEK", "DAY", "HOUR", "MINUTE", "SECOND":
+		return unit, nil
+	}
+	return "", fmt.Errorf("unidade de tempo inválida: '%s'", text)
+}
+
+// ageFunc implementa AGE(nascimento): a idade em anos completos na data atual do relógio.
+func ageFunc(ctx *Context, args []interface{}) (interface{}, error) {
+	birth, err := toTime(args[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	now := ctx.now().In(birth.Location())
+	years := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		years--
+	}
+	return float64(years), nil
+}
+
+// dayOfWeekFunc implementa DAY_OF_WEEK(data): 1 (segunda-feira) a 7 (domingo), como na
+// ISO 8601. O dia é o do fuso da data (ver DATE(valor, fuso)).
+func dayOfWeekFunc(_ *Context, args []interface{}) (interface{}, error) {
+	t, err := toTime(args[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.Weekday() == time.Sunday {
+		return 7.0, nil
+	}
+	return float64(t.Weekday()), nil
+}
@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedNow é o horário do relógio dos testes: quarta-feira, 01/05/2024 12:00 UTC.
+var fixedNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func dateContext(data map[string]interface{}) *Context {
+	ctx := NewContext(data)
+	ctx.Clock = func() time.Time { return fixedNow }
+	return ctx
+}
+
+func datePayload() map[string]interface{} {
+	return map[string]interface{}{
+		"nascimento": "2006-05-02",
+		"vencimento": "2024-04-28T10:00:00Z",
+		"timestamp":  "2024-05-01T01:30:00Z",
+		"entrega":    "15/05/2024",
+		"idade":      30.0,
+	}
+}
+
+func TestDateFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "vencido", rule: `DATE($.vencimento) < NOW()`, expected: true},
+		{name: "texto comparado com data", rule: `$.vencimento < NOW()`, expected: true},
+		{name: "NOW igual ao relógio", rule: `NOW() == "2024-05-01T12:00:00Z"`, expected: true},
+		{name: "idade incompleta", rule: `AGE($.nascimento) >= 18`, expected: false},
+		{name: "idade", rule: `AGE($.nascimento) == 17`, expected: true},
+		{name: "DATE_DIFF em dias", rule: `DATE_DIFF(NOW(), $.vencimento, "days") == 3`, expected: true},
+		{name: "DATE_DIFF em horas", rule: `DATE_DIFF(NOW(), $.vencimento, "HOURS") == 74`, expected: true},
+		{name: "DATE_DIFF negativo", rule: `DATE_DIFF($.vencimento, NOW(), "days") == -3`, expected: true},
+		{name: "DATE_ADD", rule: `DATE_ADD($.vencimento, 1, "month") == "2024-05-28T10:00:00Z"`, expected: true},
+		{name: "DATE_ADD negativo", rule: `DATE_ADD(NOW(), -2, "hours") > $.vencimento`, expected: true},
+		{name: "PARSE_DATE", rule: `PARSE_DATE($.entrega, "DD/MM/YYYY") == "2024-05-15"`, expected: true},
+		{name: "PARSE_DATE com layout do Go", rule: `PARSE_DATE($.entrega, "02/01/2006") > NOW()`, expected: true},
+		{name: "dia da semana", rule: `DAY_OF_WEEK(NOW()) == 3`, expected: true},
+		{name: "dia da semana em outro fuso", rule: `DAY_OF_WEEK(DATE($.timestamp, "America/Sao_Paulo")) == 2`, expected: true},
+		{name: "data sem fuso interpretada no fuso", rule: `DATE("2024-05-01 09:00:00", "America/Sao_Paulo") == NOW()`, expected: true},
+		{name: "NOW em outro fuso é o mesmo instante", rule: `NOW("Asia/Tokyo") == NOW()`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRuleWithContext(cenario.rule, dateContext(datePayload()), nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+}
+
+func TestDateFunctionErrors(t *testing.T) {
+	all_cases := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "data inválida", rule: `DATE("amanhã") > NOW()`, message: "data inválida: 'amanhã'"},
+		{name: "data nula", rule: `AGE($.ausente) > 18`, message: "AGE: data nula"},
+		{name: "fuso inválido", rule: `NOW("Lua/Base") > $.vencimento`, message: "fuso horário inválido"},
+		{name: "formato não corresponde", rule: `PARSE_DATE($.entrega, "YYYY-MM-DD") > NOW()`, message: "não corresponde ao formato"},
+		{name: "unidade inválida", rule: `DATE_ADD(NOW(), 1, "século") > NOW()`, message: "unidade de tempo inválida"},
+		{name: "anos em DATE_DIFF", rule: `DATE_DIFF(NOW(), $.nascimento, "years") > 18`, message: "use AGE"},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRuleWithContext(cenario.rule, dateContext(datePayload()), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "número no intervalo", rule: `$.idade BETWEEN 18 AND 65`, expected: true},
+		{name: "limites inclusivos", rule: `$.idade BETWEEN 30 AND EXP(15 * 2)`, expected: true},
+		{name: "fora do intervalo", rule: `$.idade BETWEEN 31 AND 65`, expected: false},
+		{name: "datas", rule: `NOW() BETWEEN $.vencimento AND DATE_ADD($.vencimento, 7, "days")`, expected: true},
+		{name: "data fora do intervalo", rule: `DATE($.nascimento) BETWEEN "2024-01-01" AND "2024-12-31"`, expected: false},
+		{name: "AND entre aspas", rule: `LEN(CONCAT($.idade, " AND ")) BETWEEN 1 AND 10`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRuleWithContext(cenario.rule, dateContext(datePayload()), nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	t.Run("sem AND", func(t *testing.T) {
+		_, _, err := EvaluateRule(`$.idade BETWEEN 18`, datePayload())
+		assert.Error(t, err)
+	})
+}
+
+func TestSetDate(t *testing.T) {
+	data := datePayload()
+	passed, details, err := EvaluateRuleWithContext(`SET $.proximoVencimento = DATE_ADD($.vencimento, 30, "days")`, dateContext(data), nil)
+	require.NoError(t, err, details)
+	assert.True(t, passed)
+	assert.Equal(t, "2024-05-28T10:00:00Z", data["proximoVencimento"])
+}
@@ -0,0 +1,158 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Modos de arredondamento de ROUND
+const (
+	RoundHalfUp   = "HALF_UP"   // 0,5 arredonda para longe do zero (padrão)
+	RoundHalfEven = "HALF_EVEN" // 0,5 arredonda para o par mais próximo (arredondamento bancário)
+)
+
+// toDecimal converte um valor numérico em decimal (big.Rat). Números float64 são convertidos pela
+// sua menor representação decimal (0.0165 vira 165/10000, e não a aproximação binária), que é
+// o valor escrito na política ou no JSON da requisição. Resultados de EXP no modo decimal já
+// são big.Rat e são usados como estão.
+func toDecimal(value interface{}) (*big.Rat, bool) {
+	var text string
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, true
+	case string:
+		if _, ok := convertToFloat64(v); !ok {
+			return nil, false
+		}
+		text = strings.TrimSpace(v)
+	case float32:
+		text = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	default:
+		num, ok := convertToFloat64(value)
+		if !ok {
+			return nil, false
+		}
+		text = strconv.FormatFloat(num, 'g', -1, 64)
+	}
+	r, ok := new(big.Rat).SetString(text)
+	return r, ok
+}
+
+// decimalArithmetic calcula 'x operator y' em decimal exato. No modo decimal, EXP retorna o
+// big.Rat resultante, que segue exato por variáveis (LET), expressões nomeadas, outros EXP e
+// comparações (LET a = EXP(0.1 + 0.2) seguida de EXP(@a - 0.3) == 0 é verdadeira). O valor só
+// é convertido para o float64 mais próximo ao sair da avaliação (ver plainValue).
+func decimalArithmetic(x, y *big.Rat, operator string) (*big.Rat, error) {
+	result := new(big.Rat)
+	switch operator {
+	case "+":
+		result.Add(x, y)
+	case "-":
+		result.Sub(x, y)
+	case "*":
+		result.Mul(x, y)
+	case "/":
+		if y.Sign() == 0 {
+			return nil, errors.New("divisão por zero")
+		}
+		result.Quo(x, y)
+	default:
+		return nil, fmt.Errorf("operador matemático desconhecido '%s'", operator)
+	}
+	return result, nil
+}
+
+// plainValue converte resultados decimais (big.Rat) no float64 mais próximo, inclusive dentro
+// de arrays. É aplicada onde o valor sai da avaliação: gravação nos dados (SET e ADD),
+// argumentos de funções, Context.Lookup e trace. Outros valores são retornados como estão.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		if hasDecimal(v) {
+			items := make([]interface{}, len(v))
+			for i, item := range v {
+				items[i] = plainValue(item)
+			}
+			return items
+		}
+	}
+	return value
+}
+
+// hasDecimal indica se o array contém algum big.Rat, em qualquer nível.
+func hasDecimal(items []interface{}) bool {
+	for _, item := range items {
+		switch v := item.(type) {
+		case *big.Rat:
+			return true
+		case []interface{}:
+			if hasDecimal(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roundFunc implementa ROUND(valor[, casas[, modo]]): arredonda para o número de casas decimais
+// (0 por padrão) no modo HALF_UP (padrão) ou HALF_EVEN. O arredondamento é sempre feito em
+// decimal, então ROUND(2.675, 2) resulta em 2.68 mesmo fora do modo decimal.
+func roundFunc(_ *Context, args []interface{}) (interface{}, error) {
+	value, ok := toDecimal(args[0])
+	if !ok {
+		return nil, fmt.Errorf("argumento %v (%T) não é um número", args[0], args[0])
+	}
+	places := 0
+	if len(args) > 1 {
+		var err error
+		if places, err = intValue(args[1]); err != nil {
+			return nil, err
+		}
+		if places < 0 {
+			return nil, fmt.Errorf("casas decimais negativas: %d", places)
+		}
+	}
+	mode := RoundHalfUp
+	if len(args) > 2 {
+		text, err := textValue(args[2])
+		if err != nil {
+			return nil, err
+		}
+		mode = strings.ToUpper(strings.TrimSpace(text))
+		if mode != RoundHalfUp && mode != RoundHalfEven {
+			return nil, fmt.Errorf("modo de arredondamento deve ser %s ou %s: '%s'", RoundHalfUp, RoundHalfEven, text)
+		}
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(scale))
+
+	// quotient é a parte inteira (truncada em direção ao zero) e remainder, o que sobrou
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	twiceRemainder := new(big.Int).Abs(remainder)
+	twiceRemainder.Lsh(twiceRemainder, 1)
+	switch cmp := twiceRemainder.Cmp(scaled.Denom()); {
+	case cmp > 0, cmp == 0 && (mode == RoundHalfUp || quotient.Bit(0) == 1):
+		quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+	}
+
+	f, _ := new(big.Rat).SetFrac(quotient, scale).Float64()
+	return f, nil
+}
+
+// compareDecimals compara dois valores numéricos pelos seus valores decimais. ok é false se
+// algum deles não for numérico.
+func compareDecimals(a, b interface{}) (cmp int, ok bool) {
+	x, okA := toDecimal(a)
+	y, okB := toDecimal(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
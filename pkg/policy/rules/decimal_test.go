@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decimalPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"valor":    200.0,
+		"a":        0.1,
+		"b":        0.2,
+		"preco":    1.005,
+		"parcelas": 3.0,
+		"texto":    "0.30",
+	}
+}
+
+func TestDecimalMode(t *testing.T) {
+	all_cases := []struct {
+		name    string
+		rule    string
+		float   bool
+		decimal bool
+	}{
+		{name: "soma", rule: `EXP($.a + $.b) == 0.3`, float: false, decimal: true},
+		{name: "multiplicação", rule: `EXP($.valor * 0.0165) == 3.3`, float: false, decimal: true},
+		{name: "subtração", rule: `EXP(0.3 - $.b) == $.a`, float: false, decimal: true},
+		{name: "ordem", rule: `EXP($.a + $.b) <= 0.3`, float: false, decimal: true},
+		{name: "BETWEEN", rule: `EXP($.a + $.b) BETWEEN 0.1 AND 0.3`, float: false, decimal: true},
+		{name: "texto numérico", rule: `$.texto == EXP($.a + $.b)`, float: false, decimal: true},
+		{name: "divisão", rule: `EXP($.valor / $.parcelas) > 66.66`, float: true, decimal: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, decimalPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.float, actual, details)
+
+			ctx := NewContext(decimalPayload())
+			ctx.Decimal = true
+			actual, details, err = EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.decimal, actual, details)
+		})
+	}
+
+	t.Run("SET grava o float64 mais próximo do resultado", func(t *testing.T) {
+		data := decimalPayload()
+		ctx := NewContext(data)
+		ctx.Decimal = true
+		_, details, err := EvaluateRuleWithContext(`SET $.pis = EXP($.valor * 0.0165)`, ctx, nil)
+		require.NoError(t, err, details)
+		assert.Equal(t, 3.3, data["pis"])
+
+		_, details, err = EvaluateRuleWithContext(`SET $.parcela = EXP($.valor / $.parcelas)`, ctx, nil)
+		require.NoError(t, err, details)
+		assert.Equal(t, 66.66666666666667, data["parcela"])
+	})
+
+	t.Run("resultados exatos entre operações", func(t *testing.T) {
+		all_cases := []struct {
+			name  string
+			rules []string
+		}{
+			{name: "LET encadeado", rules: []string{`LET soma = EXP(0.1 + 0.2)`, `EXP(@soma - 0.3) == 0`}},
+			{name: "expressão nomeada", rules: []string{`EXP(@terco * 3) == 1`}},
+			{name: "divisão e multiplicação", rules: []string{`LET parcela = EXP($.valor / $.parcelas)`, `LET total = EXP(@parcela * $.parcelas)`, `@total == $.valor`}},
+			{name: "ordem entre resultados", rules: []string{`LET fracao = EXP(1 / 3)`, `EXP(@fracao * 3) >= 1`, `EXP(@fracao * 3) <= 1`, `EXP(@fracao * 3) BETWEEN 1 AND 1`}},
+		}
+
+		for _, cenario := range all_cases {
+			t.Run(cenario.name, func(t *testing.T) {
+				ctx := NewContext(decimalPayload())
+				ctx.Decimal = true
+				ctx.Expressions = map[string]string{"terco": "EXP(1 / 3)"}
+				for _, rule := range cenario.rules {
+					actual, details, err := EvaluateRuleWithContext(rule, ctx, nil)
+					require.NoError(t, err, details)
+					assert.True(t, actual, "%s: %s", rule, details)
+				}
+			})
+		}
+
+		// Em float64, a mesma cadeia acumula o erro binário
+		ctx := NewContext(decimalPayload())
+		_, _, err := EvaluateRuleWithContext(`LET soma = EXP(0.1 + 0.2)`, ctx, nil)
+		require.NoError(t, err)
+		actual, _, err := EvaluateRuleWithContext(`EXP(@soma - 0.3) == 0`, ctx, nil)
+		require.NoError(t, err)
+		assert.False(t, actual)
+	})
+
+	t.Run("valores decimais saem da avaliação como float64", func(t *testing.T) {
+		data := decimalPayload()
+		ctx := NewContext(data)
+		ctx.Decimal = true
+		for _, rule := range []string{`LET terco = EXP(1 / 3)`, `SET $.terco = @terco`, `ADD @terco TO $.lista`, `SET $.inteiro = EXP(@terco * 3)`} {
+			_, details, err := EvaluateRuleWithContext(rule, ctx, nil)
+			require.NoError(t, err, details)
+		}
+		assert.Equal(t, 1.0/3, data["terco"])
+		assert.Equal(t, []interface{}{1.0 / 3}, data["lista"])
+		assert.Equal(t, 1.0, data["inteiro"])
+
+		value, err := ctx.Lookup("@terco")
+		require.NoError(t, err)
+		assert.Equal(t, 1.0/3, value)
+
+		actual, details, err := EvaluateRuleWithContext(`ROUND(@terco, 2) == 0.33`, ctx, nil)
+		require.NoError(t, err, details)
+		assert.True(t, actual, details)
+	})
+
+	t.Run("divisão por zero", func(t *testing.T) {
+		ctx := NewContext(decimalPayload())
+		ctx.Decimal = true
+		_, _, err := EvaluateRuleWithContext(`SET $.x = EXP($.valor / 0)`, ctx, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "divisão por zero")
+	})
+}
+
+func TestRound(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "inteiro por padrão", rule: `ROUND(2.5) == 3`, expected: true},
+		{name: "HALF_UP", rule: `ROUND(2.675, 2) == 2.68`, expected: true},
+		{name: "HALF_UP com número negativo", rule: `ROUND(-2.5, 0, "HALF_UP") == -3`, expected: true},
+		{name: "HALF_EVEN para o par abaixo", rule: `ROUND(2.5, 0, "HALF_EVEN") == 2`, expected: true},
+		{name: "HALF_EVEN para o par acima", rule: `ROUND(3.5, 0, "half_even") == 4`, expected: true},
+		{name: "HALF_EVEN com casas", rule: `ROUND($.preco, 2, "HALF_EVEN") == 1`, expected: true},
+		{name: "HALF_EVEN fora do empate", rule: `ROUND(1.0051, 2, "HALF_EVEN") == 1.01`, expected: true},
+		{name: "de EXP", rule: `ROUND(EXP($.valor / $.parcelas), 2) == 66.67`, expected: true},
+		{name: "texto numérico", rule: `ROUND($.texto, 1) == 0.3`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, decimalPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "modo inválido", rule: `ROUND(1.5, 0, "CEIL") == 2`, message: "modo de arredondamento deve ser HALF_UP ou HALF_EVEN"},
+		{name: "casas negativas", rule: `ROUND(1.5, -1) == 0`, message: "casas decimais negativas"},
+		{name: "não numérico", rule: `ROUND("abc", 2) == 0`, message: "não é um número"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRule(cenario.rule, decimalPayload())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
@@ -1,19 +1,43 @@
 package rules
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"reflect"
 	"encoding/json"
 )
 
-// evaluateRule avalia uma única string de regra de política contra os dados.
+// EvaluateRule avalia uma única string de regra de política contra os dados.
 // Retorna: bool (passou), string (detalhes), error (erro de avaliação)
 func EvaluateRule(rule string, data map[string]interface{}) (bool, string, error) {
-	tr := NewRule(rule)
+	return evaluateRule(rule, NewContext(data), nil)
+}
+
+// EvaluateRuleWithTrace avalia a regra como EvaluateRule e anexa ao nó 'parent'
+// a árvore de avaliação (paths resolvidos, sub-expressões, ramos não avaliados e tempos).
+func EvaluateRuleWithTrace(rule string, data map[string]interface{}, parent *TraceNode) (bool, string, error) {
+	return EvaluateRuleWithContext(rule, NewContext(data), parent)
+}
+
+// EvaluateRuleWithContext avalia a regra no contexto informado, que além dos dados expõe
+// raízes somente leitura (ex.: $ctx.userId, $meta.id). O nó 'parent' do trace é opcional.
+func EvaluateRuleWithContext(rule string, ctx *Context, parent *TraceNode) (bool, string, error) {
+	node := parent.Child(TraceRule, strings.TrimSpace(rule))
+	passed, details, err := evaluateRule(rule, ctx, node)
+	node.Finish(passed, err)
+	return passed, details, err
+}
+
+// evaluateRule avalia a regra registrando a avaliação no nó de trace (que pode ser nil).
+func evaluateRule(rule string, ctx *Context, node *TraceNode) (bool, string, error) {
+	tr := newContextRule(rule, ctx, node)
+	data := ctx.Data
+
+	// Laços FOREACH, ALL e ANY; vêm antes do OR, que pode fazer parte do corpo do laço
+	if res := tr.ForEach(data); res.Executed {
+		return res.Passed, res.Details, res.Err
+	}
 
 	// Lógica OR (sem alterações)
 	if res := tr.OrCondition(data); res.Executed {
@@ -25,6 +49,21 @@ func EvaluateRule(rule string, data map[string]interface{}) (bool, string, error
 		return res.Passed, res.Details, res.Err
 	}
 
+	// Lógica ADD ... TO
+	if res := tr.AddValue(data); res.Executed {
+		return res.Passed, res.Details, res.Err
+	}
+
+	// Lógica DELETE
+	if res := tr.DeleteValue(data); res.Executed {
+		return res.Passed, res.Details, res.Err
+	}
+
+	// Lógica LET (variáveis da avaliação, lidas como @nome)
+	if res := tr.LetValue(data); res.Executed {
+		return res.Passed, res.Details, res.Err
+	}
+
 	// Lógica IF THEN (sem alterações na estrutura, mas usará EXP se presente na ação)
 	if res := tr.IfCondition(data); res.Executed {
 		return res.Passed, res.Details, res.Err
@@ -32,175 +71,214 @@ func EvaluateRule(rule string, data map[string]interface{}) (bool, string, error
 
 	trimmedRule := tr.String()
 
-	// Lógica de Condição (LHS op RHS)
-	re := regexp.MustCompile(`(.*?)\s*(>=|<=|==|!=|>|<|IN|NOT IN)\s*(.*)`)
-	matches := re.FindStringSubmatch(trimmedRule)
-	if len(matches) != 4 {
-		// Checagem de null
-		nullRe := regexp.MustCompile(`(.*?)\s*(==|!=)\s*null`)
-		nullMatches := nullRe.FindStringSubmatch(trimmedRule)
-		if len(nullMatches) == 3 {
-			lhsPath, op := strings.TrimSpace(nullMatches[1]), strings.TrimSpace(nullMatches[2])
-			lhsValue, errLhs := getValue(data, lhsPath) // Não checa EXP para null check
-			if errLhs != nil {
-				return false, fmt.Sprintf("Erro LHS '%s' em null check: %v", lhsPath, errLhs), errLhs
+	// Lógica de Condição (LHS op RHS). O operador é o primeiro fora de aspas e parênteses.
+	opStart, opEnd, op, err := findComparisonOperator(trimmedRule)
+	if err != nil {
+		return false, err.Error(), err
+	}
+	if opStart == -1 {
+		// Condição formada apenas por uma função booleana (ex.: EXISTS($.email))
+		if name, args, isCall := splitCall(trimmedRule); isCall {
+			value, err := evaluateCall(trimmedRule, name, args, ctx, node)
+			if err != nil {
+				return false, fmt.Sprintf("%s -> ERRO: %v", trimmedRule, err), err
 			}
-			result := (op == "==" && lhsValue == nil) || (op == "!=" && lhsValue != nil)
-			return result, fmt.Sprintf("%s (%v) %s null -> %t", lhsPath, lhsValue, op, result), nil
-		}
-		// Funções não implementadas
-		funcRe := regexp.MustCompile(`(COUNT|SUM)\((.*?)\)`).FindStringSubmatch(trimmedRule)
-		if len(funcRe) > 0 {
-			err := fmt.Errorf("função não implementada: %s", funcRe[0])
-			return false, err.Error(), err
+			result, isBool := value.(bool)
+			if !isBool {
+				err := fmt.Errorf("condição '%s' não resulta em booleano: %v", trimmedRule, value)
+				return false, err.Error(), err
+			}
+			return result, fmt.Sprintf("%s -> %t", trimmedRule, result), nil
 		}
 		return false, "", fmt.Errorf("regra de condição inválida: '%s'", rule)
 	}
 
-	lhsStr := strings.TrimSpace(matches[1])
-	op := strings.TrimSpace(matches[2])
-	rhsStr := strings.TrimSpace(matches[3])
+	lhsStr := strings.TrimSpace(trimmedRule[:opStart])
+	rhsStr := strings.TrimSpace(trimmedRule[opEnd:])
 
-	var lhsValue, rhsValue interface{}
-	var lhsDetails, rhsDetails string = "literal ou path", "literal ou path"
-	var err error
+	// ANY/ALL aplicam a comparação a cada item do array do LHS (ex.: ANY $.itens[*].valor > 100)
+	quantifier, lhsStr := splitQuantifier(lhsStr)
 
-	// Avaliar LHS
-	if expr, isExpr := extractExpression(lhsStr); isExpr {
-		lhsValue, lhsDetails, err = evaluateMathExpression(expr, data)
-		lhsDetails = fmt.Sprintf("EXP(%s)", lhsDetails)
+	var lhsValue interface{}
+	var lhsDetails string
+	if quantifier != "" {
+		lhsValue, lhsDetails, err = evaluateItems(quantifier, lhsStr, ctx, node)
 	} else {
-		lhsValue, err = getValue(data, lhsStr)             // Assume que é um path se não for EXP()
-		if err == nil && strings.HasPrefix(lhsStr, "$.") { // Se foi path e deu certo
-			lhsDetails = fmt.Sprintf("path %s = %v", lhsStr, lhsValue)
-		} else if err != nil { // Se getValue falhou, pode ser um literal
-			// Tenta converter como literal numérico ou booleano
-			if fVal, eConv := strconv.ParseFloat(lhsStr, 64); eConv == nil {
-				lhsValue = fVal
-				err = nil
-				lhsDetails = fmt.Sprintf("literal %f", fVal)
-			} else if bVal, eConv := strconv.ParseBool(lhsStr); eConv == nil {
-				lhsValue = bVal
-				err = nil
-				lhsDetails = fmt.Sprintf("literal %t", bVal)
-			} else { // É uma string literal ou um path inválido
-				if !strings.HasPrefix(lhsStr, "$.") { // Não é um path, então é string literal
-					lhsValue = strings.Trim(lhsStr, "\"'") // Remove aspas
-					err = nil
-					lhsDetails = fmt.Sprintf("literal string '%s'", lhsValue)
-				} else {
-					// É um path que falhou em getValue, mantém o erro
-				}
-			}
-		} else { // getValue retornou nil, nil (path válido, valor ausente)
-			lhsDetails = fmt.Sprintf("path %s = nil (ausente)", lhsStr)
-		}
+		lhsValue, lhsDetails, err = evaluateOperand(lhsStr, ctx, node)
 	}
 	if err != nil {
 		return false, fmt.Sprintf("Erro ao avaliar LHS '%s': %s. Detalhes: %v", lhsStr, lhsDetails, err), err
 	}
 
-	// Avaliar RHS (exceto para IN/NOT IN que têm tratamento especial de lista)
-	if op != "IN" && op != "NOT IN" {
-		if expr, isExpr := extractExpression(rhsStr); isExpr {
-			rhsValue, rhsDetails, err = evaluateMathExpression(expr, data)
-			rhsDetails = fmt.Sprintf("EXP(%s)", rhsDetails)
-		} else if strings.HasPrefix(rhsStr, "$.") {
-			rhsValue, err = getValue(data, rhsStr)
-			if err == nil {
-				rhsDetails = fmt.Sprintf("path %s = %v", rhsStr, rhsValue)
-			}
-		} else { // Literal
-			if fVal, eConv := strconv.ParseFloat(rhsStr, 64); eConv == nil {
-				rhsValue = fVal
-				rhsDetails = fmt.Sprintf("literal %f", fVal)
-			} else if bVal, eConv := strconv.ParseBool(rhsStr); eConv == nil {
-				rhsValue = bVal
-				rhsDetails = fmt.Sprintf("literal %t", bVal)
-			} else {
-				rhsValue = strings.Trim(rhsStr, "\"'")
-				rhsDetails = fmt.Sprintf("literal string '%s'", rhsValue)
-			}
+	rhsValue, rhsDetails, err := evaluateRHS(op, rhsStr, ctx, node)
+	if err != nil {
+		return false, rhsDetails, err
+	}
+
+	var result bool
+	if quantifier != "" {
+		result, err = quantify(quantifier, op, lhsValue.([]interface{}), rhsValue, ctx)
+		lhsDetails = quantifier + " " + lhsDetails
+	} else {
+		result, err = applyOperator(op, lhsValue, rhsValue, ctx)
+	}
+	if err != nil {
+		return false, fmt.Sprintf("%s %s %s -> ERRO: %v", lhsDetails, op, rhsDetails, err), err
+	}
+	return result, fmt.Sprintf("%s %s %s -> %t", lhsDetails, op, rhsDetails, result), nil
+}
+
+// evaluateRHS avalia o lado direito da comparação: os dois limites de BETWEEN, a lista dos
+// operadores de lista (IN, NOT IN, SUBSET OF, INTERSECTS) ou um operando simples.
+func evaluateRHS(op, rhsStr string, ctx *Context, node *TraceNode) (interface{}, string, error) {
+	switch op {
+	case "BETWEEN", "BETWEEN EXCLUSIVE":
+		lowStr, highStr, ok := splitRange(rhsStr)
+		if !ok {
+			return nil, "", fmt.Errorf("%s exige '<mínimo> AND <máximo>': %s", op, rhsStr)
 		}
+		lowValue, lowDetails, err := evaluateOperand(lowStr, ctx, node)
 		if err != nil {
-			return false, fmt.Sprintf("Erro ao avaliar RHS '%s': %s. Detalhes: %v", rhsStr, rhsDetails, err), err
-		}
-	} else { // Para IN / NOT IN, RHS é uma lista de strings
-		tempRhsStr := strings.TrimSpace(rhsStr)
-		if !(strings.HasPrefix(tempRhsStr, "[") && strings.HasSuffix(tempRhsStr, "]")) {
-			return false, "", fmt.Errorf("lista para %s deve ser [...]: %s", op, tempRhsStr)
+			return nil, fmt.Sprintf("Erro ao avaliar mínimo '%s': %s. Detalhes: %v", lowStr, lowDetails, err), err
 		}
-		listContent := strings.Trim(tempRhsStr, "[] ")
-		if listContent == "" {
-			rhsValue = []string{}
-		} else {
-			elements := regexp.MustCompile(`\s*,\s*`).Split(listContent, -1)
-			strList := make([]string, len(elements))
-			for i, el := range elements {
-				strList[i] = strings.Trim(strings.TrimSpace(el), "\"'")
-			}
-			rhsValue = strList
+		highValue, highDetails, err := evaluateOperand(highStr, ctx, node)
+		if err != nil {
+			return nil, fmt.Sprintf("Erro ao avaliar máximo '%s': %s. Detalhes: %v", highStr, highDetails, err), err
 		}
-		rhsDetails = fmt.Sprintf("lista %v", rhsValue)
+		return []interface{}{lowValue, highValue}, fmt.Sprintf("%s AND %s", lowDetails, highDetails), nil
+	case "IN", "NOT IN", "SUBSET OF", "INTERSECTS":
+		return evaluateList(op, rhsStr, ctx, node)
 	}
+	rhsValue, rhsDetails, err := evaluateOperand(rhsStr, ctx, node)
+	if err != nil {
+		return nil, fmt.Sprintf("Erro ao avaliar RHS '%s': %s. Detalhes: %v", rhsStr, rhsDetails, err), err
+	}
+	return rhsValue, rhsDetails, nil
+}
 
-	result := false
-	var finalDetails string
+// applyOperator aplica o operador de comparação aos valores já avaliados.
+func applyOperator(op string, lhsValue, rhsValue interface{}, ctx *Context) (bool, error) {
 	switch op {
 	case "==":
-		result = compareEquals(lhsValue, rhsValue)
+		return equalValues(lhsValue, rhsValue, ctx.decimal()), nil
 	case "!=":
-		result = !compareEquals(lhsValue, rhsValue)
+		return !equalValues(lhsValue, rhsValue, ctx.decimal()), nil
 	case ">", ">=", "<", "<=":
-		lhsNum, okLhs := convertToFloat64(lhsValue)
-		rhsNum, okRhs := convertToFloat64(rhsValue)
-		if !okLhs || !okRhs {
-			errText := fmt.Sprintf("não numérico: LHS (%v %T, num:%t), RHS (%v %T, num:%t)", lhsValue, lhsValue, okLhs, rhsValue, rhsValue, okRhs)
-			return false, fmt.Sprintf("%s %s %s -> ERRO: %s", lhsDetails, op, rhsDetails, errText), errors.New(errText)
+		cmp, err := orderValues(lhsValue, rhsValue, ctx.decimal())
+		if err != nil {
+			return false, err
 		}
 		switch op {
 		case ">":
-			result = lhsNum > rhsNum
+			return cmp > 0, nil
 		case ">=":
-			result = lhsNum >= rhsNum
+			return cmp >= 0, nil
 		case "<":
-			result = lhsNum < rhsNum
-		case "<=":
-			result = lhsNum <= rhsNum
+			return cmp < 0, nil
 		}
-	case "IN":
-		rhsList, _ := rhsValue.([]string) // Já validado
-		lhsStrVal, ok := lhsValue.(string)
-		if !ok {
-			result = false
-		} else {
-			for _, item := range rhsList {
-				if lhsStrVal == item {
-					result = true
-					break
-				}
-			}
+		return cmp <= 0, nil
+	case "BETWEEN", "BETWEEN EXCLUSIVE":
+		bounds, _ := rhsValue.([]interface{}) // [mínimo, máximo], já avaliados
+		low, err := orderValues(lhsValue, bounds[0], ctx.decimal())
+		if err != nil {
+			return false, err
 		}
+		high, err := orderValues(lhsValue, bounds[1], ctx.decimal())
+		if err != nil {
+			return false, err
+		}
+		if op == "BETWEEN EXCLUSIVE" {
+			return low > 0 && high < 0, nil
+		}
+		return low >= 0 && high <= 0, nil
+	}
+
+	// Os demais operadores não comparam em decimal: resultados de EXP são lidos como float64
+	lhsValue, rhsValue = plainValue(lhsValue), plainValue(rhsValue)
+	switch op {
+	case "IN":
+		return listContains(rhsValue.([]interface{}), lhsValue), nil
 	case "NOT IN":
-		rhsList, _ := rhsValue.([]string) // Já validado
-		lhsStrVal, ok := lhsValue.(string)
-		if !ok {
-			result = true
-		} else { // Se LHS não é string, ou é nil, não está na lista
-			found := false
-			for _, item := range rhsList {
-				if lhsStrVal == item {
-					found = true
-					break
-				}
+		return !listContains(rhsValue.([]interface{}), lhsValue), nil
+	case "SUBSET OF", "INTERSECTS":
+		return compareSets(op, lhsValue, rhsValue.([]interface{}))
+	case "CONTAINS":
+		return containsValue(lhsValue, rhsValue), nil
+	case "STARTS WITH", "ENDS WITH":
+		return affixMatches(op, lhsValue, rhsValue), nil
+	case "MATCHES":
+		return matchPattern(lhsValue, rhsValue)
+	}
+	return false, fmt.Errorf("operador não suportado: %s", op)
+}
+
+// evaluateOperand avalia um operando de comparação ou argumento de função: EXP(...),
+// chamada de função, caminho ou literal. Retorna também a descrição usada nos detalhes.
+func evaluateOperand(operand string, ctx *Context, node *TraceNode) (interface{}, string, error) {
+	if expr, isExpr := extractExpression(operand); isExpr {
+		value, details, err := evaluateMathExpression(expr, ctx, node)
+		return value, fmt.Sprintf("EXP(%s)", details), err
+	}
+	if isPath(operand) {
+		value, err := lookupPath(ctx, operand, node)
+		if err != nil {
+			return nil, "literal ou path", err
+		}
+		return value, fmt.Sprintf("path %s = %v", operand, plainValue(value)), nil
+	}
+	if name, args, isCall := splitCall(operand); isCall {
+		value, err := evaluateCall(operand, name, args, ctx, node)
+		return value, fmt.Sprintf("%s = %v", operand, value), err
+	}
+
+	value, _ := parseLiteral(operand)
+	traceLiteral(node, operand, value)
+	switch v := value.(type) {
+	case nil:
+		return nil, "literal null", nil
+	case float64:
+		return v, fmt.Sprintf("literal %f", v), nil
+	case bool:
+		return v, fmt.Sprintf("literal %t", v), nil
+	}
+	return value, fmt.Sprintf("literal string '%v'", value), nil
+}
+
+// equalValues implementa == e !=; no modo decimal, números são comparados em decimal.
+func equalValues(a, b interface{}, decimal bool) bool {
+	if decimal {
+		if _, _, isTime := timeOperands(a, b); !isTime {
+			if cmp, ok := compareDecimals(a, b); ok {
+				return cmp == 0
 			}
-			result = !found
 		}
-	default:
-		return false, "", fmt.Errorf("operador não suportado: %s", op)
 	}
-	finalDetails = fmt.Sprintf("%s %s %s -> %t", lhsDetails, op, rhsDetails, result)
-	return result, finalDetails, nil
+	return compareEquals(a, b)
+}
+
+// orderValues compara dois valores para os operadores de ordem e BETWEEN: datas (quando ao
+// menos um lado é data) ou números, em decimal se 'decimal'. Retorna -1, 0 ou 1.
+func orderValues(a, b interface{}, decimal bool) (int, error) {
+	if aTime, bTime, isTime := timeOperands(a, b); isTime {
+		return aTime.Compare(bTime), nil
+	}
+	if decimal {
+		if cmp, ok := compareDecimals(a, b); ok {
+			return cmp, nil
+		}
+	}
+	aNum, okA := convertToFloat64(a)
+	bNum, okB := convertToFloat64(b)
+	if !okA || !okB {
+		return 0, fmt.Errorf("não numérico: LHS (%v %T, num:%t), RHS (%v %T, num:%t)", a, a, okA, b, b, okB)
+	}
+	switch {
+	case aNum < bNum:
+		return -1, nil
+	case aNum > bNum:
+		return 1, nil
+	}
+	return 0, nil
 }
 
 // evaluateExpression avalia expressões YAML (ex.: $.idade >= 18).
@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// keywords são as palavras-chave da linguagem de regras, escritas sempre em maiúsculas.
+var keywords = map[string]bool{
+	"OR": true, "IF": true, "THEN": true, "SET": true, "ADD": true, "TO": true,
+	"DELETE": true, "IN": true, "NOT": true, "CONTAINS": true, "STARTS": true, "ENDS": true,
+	"WITH": true, "MATCHES": true, "BETWEEN": true, "AND": true, "EXCLUSIVE": true, "SUBSET": true,
+	"OF": true, "INTERSECTS": true, "ANY": true, "ALL": true, "LET": true,
+	"FOREACH": true, "DO": true,
+}
+
+// FormatRule reescreve a regra no formato canônico: palavras-chave e nomes de função em
+// maiúsculas, um espaço ao redor dos operadores, strings entre aspas duplas e números sem
+// zeros supérfluos. Regras que não podem ser interpretadas (ver Parse) retornam erro.
+func FormatRule(rule string) (string, error) {
+	node, err := Parse(normalizeKeywords(strings.TrimSpace(rule)))
+	if err != nil {
+		return "", err
+	}
+	formatted := node.String()
+	if _, err := Parse(formatted); err != nil {
+		return "", err
+	}
+	return formatted, nil
+}
+
+// FormatOperand reescreve no formato canônico um valor isolado, como o de uma expressão
+// nomeada (ver ParseOperand).
+func FormatOperand(text string) (string, error) {
+	operand, err := ParseOperand(normalizeKeywords(strings.TrimSpace(text)))
+	if err != nil {
+		return "", err
+	}
+	return operand.String(), nil
+}
+
+// normalizeKeywords coloca em maiúsculas, fora de aspas, as palavras-chave isoladas
+// (ex.: "if ... then", "not in") e os nomes de função seguidos de "(" (ex.: "exp(").
+func normalizeKeywords(rule string) string {
+	out := []byte(rule)
+	var quote byte
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+			continue
+		case !isLetter(c) || (i > 0 && strings.IndexByte(" ([,", out[i-1]) < 0):
+			continue
+		}
+
+		j := i
+		for j < len(out) && (isLetter(out[j]) || out[j] == '_' || (out[j] >= '0' && out[j] <= '9')) {
+			j++
+		}
+		word := strings.ToUpper(string(out[i:j]))
+		standalone := (i == 0 || out[i-1] == ' ') && (j == len(out) || out[j] == ' ')
+		call := j < len(out) && out[j] == '(' && (word == "EXP" || IsFunction(word))
+		if (standalone && keywords[word]) || call {
+			copy(out[i:j], word)
+		}
+		i = j - 1
+	}
+	return string(out)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// String retorna a regra do nó no formato canônico.
+func (n *Node) String() string {
+	switch n.Kind {
+	case NodeOr:
+		return n.Left.String() + " OR " + n.Right.String()
+	case NodeIf:
+		return "IF " + n.Left.String() + " THEN " + n.Right.String()
+	case NodeSet:
+		return "SET " + n.Target + " = " + n.Value.String()
+	case NodeAdd:
+		return "ADD " + n.Value.addString() + " TO " + n.Target
+	case NodeDelete:
+		return "DELETE " + n.Target
+	case NodeLet:
+		return "LET " + strings.TrimPrefix(n.Target, RootVariables) + " = " + n.Value.String()
+	case NodeCheck:
+		return n.LHS.String()
+	case NodeLoop:
+		body := n.Right.String()
+		if n.template != nil {
+			body = strings.ReplaceAll(n.template.String(), loopItemRoot, "")
+		}
+		if n.Quantifier == LoopForEach {
+			return LoopForEach + " " + n.Item + " IN " + n.Value.String() + " DO " + body
+		}
+		return n.Quantifier + " " + n.Item + " IN " + n.Value.String() + ": " + body
+	}
+	if n.Quantifier != "" {
+		return n.Quantifier + " " + n.LHS.String() + " " + n.Op + " " + n.RHS.String()
+	}
+	return n.LHS.String() + " " + n.Op + " " + n.RHS.String()
+}
+
+// String retorna o operando no formato canônico.
+func (o *Operand) String() string {
+	switch o.Kind {
+	case OperandLiteral:
+		return o.literalString()
+	case OperandList:
+		items, _ := o.Value.([]interface{})
+		formatted := make([]string, len(items))
+		for i, item := range items {
+			s, _ := item.(string)
+			if strings.ContainsAny(s, `"',`) {
+				return o.Text // O texto não pode ser reescrito sem mudar a leitura da lista
+			}
+			formatted[i] = `"` + s + `"`
+		}
+		return "[" + strings.Join(formatted, ", ") + "]"
+	case OperandExpression:
+		if o.Op == "" {
+			return "EXP(" + o.Left.String() + ")"
+		}
+		return "EXP(" + o.Left.String() + " " + o.Op + " " + o.Right.String() + ")"
+	case OperandRange:
+		return o.Left.String() + " AND " + o.Right.String()
+	case OperandCall:
+		args := make([]string, len(o.Args))
+		for i, arg := range o.Args {
+			args[i] = arg.String()
+		}
+		return o.Func + "(" + strings.Join(args, ", ") + ")"
+	}
+	return o.Text
+}
+
+// literalString escreve o literal preservando seu tipo: strings entre aspas (strings
+// numéricas continuam entre aspas), números, booleanos e null sem aspas.
+func (o *Operand) literalString() string {
+	switch v := o.Value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		if strings.ContainsAny(v, "\"'\\\n\r\t") {
+			return o.Text
+		}
+		return `"` + v + `"`
+	}
+	return o.Text
+}
+
+// addString escreve o valor de ADD, que é lido como JSON (arrays e objetos compactos).
+func (o *Operand) addString() string {
+	if o.Kind != OperandLiteral {
+		return o.String()
+	}
+	switch o.Value.(type) {
+	case []interface{}, map[string]interface{}:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(o.Value); err != nil {
+			return o.Text
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	return o.literalString()
+}
@@ -0,0 +1,194 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatRule(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected string
+	}{
+		{
+			name:     "espaços ao redor do operador",
+			rule:     `  $.idade>=18  `,
+			expected: `$.idade >= 18`,
+		},
+		{
+			name:     "aspas simples e strings sem aspas",
+			rule:     `$.tipo == 'adulto' OR $.tipo==jovem`,
+			expected: `$.tipo == "adulto" OR $.tipo == "jovem"`,
+		},
+		{
+			name:     "strings numéricas continuam entre aspas",
+			rule:     `$.codigo != '10'`,
+			expected: `$.codigo != "10"`,
+		},
+		{
+			name:     "números, booleanos e null",
+			rule:     `$.valor == 1.50 OR $.ativo == TRUE OR $.cep != null`,
+			expected: `$.valor == 1.5 OR $.ativo == true OR $.cep != null`,
+		},
+		{
+			name:     "palavras-chave em minúsculas",
+			rule:     `if $.tipo == "premium" then set $.desconto = exp($.valor*0.15)`,
+			expected: `IF $.tipo == "premium" THEN SET $.desconto = EXP($.valor * 0.15)`,
+		},
+		{
+			name:     "palavras-chave entre aspas são preservadas",
+			rule:     `$.texto == "set or if"`,
+			expected: `$.texto == "set or if"`,
+		},
+		{
+			name:     "listas de IN e NOT IN",
+			rule:     `$.estado not in [ 'SP' ,RJ,"MG" ]`,
+			expected: `$.estado NOT IN ["SP", "RJ", "MG"]`,
+		},
+		{
+			name:     "ADD com JSON",
+			rule:     `add [ {"nome": "teste", "valor": 1234} ] to $.itens`,
+			expected: `ADD [{"nome":"teste","valor":1234}] TO $.itens`,
+		},
+		{
+			name:     "DELETE e EXP com um operando",
+			rule:     `IF EXP( $.a )>0 THEN delete   $.b`,
+			expected: `IF EXP($.a) > 0 THEN DELETE $.b`,
+		},
+		{
+			name:     "operadores de texto e funções em minúsculas",
+			rule:     `upper($.nome) starts with 'A' OR $.tags contains vip`,
+			expected: `UPPER($.nome) STARTS WITH "A" OR $.tags CONTAINS "vip"`,
+		},
+		{
+			name:     "BETWEEN com datas em minúsculas",
+			rule:     `date($.vencimento) between now() and date_add(now(),7,'days')`,
+			expected: `DATE($.vencimento) BETWEEN NOW() AND DATE_ADD(NOW(), 7, "days")`,
+		},
+		{
+			name:     "funções de verificação como condição",
+			rule:     `if exists($.email) then set $.contato = coalesce($.email,'-')`,
+			expected: `IF EXISTS($.email) THEN SET $.contato = COALESCE($.email, "-")`,
+		},
+		{
+			name:     "quantificadores e operadores de conjunto",
+			rule:     `any $.itens[*].valor between exclusive 0 and 100 OR $.tags subset of $.permitidas`,
+			expected: `ANY $.itens[*].valor BETWEEN EXCLUSIVE 0 AND 100 OR $.tags SUBSET OF $.permitidas`,
+		},
+		{
+			name:     "LET e variáveis",
+			rule:     `let total = exp(@base+$.frete)`,
+			expected: `LET total = EXP(@base + $.frete)`,
+		},
+		{
+			name:     "parâmetros",
+			rule:     `if $.moeda in :moedas then set $.desconto = exp($.valor*:taxa)`,
+			expected: `IF $.moeda IN :moedas THEN SET $.desconto = EXP($.valor * :taxa)`,
+		},
+		{
+			name:     "laços",
+			rule:     `foreach item in $.transacoes do set item.taxa = exp(item.valor*0.02)`,
+			expected: `FOREACH item IN $.transacoes DO SET item.taxa = EXP(item.valor * 0.02)`,
+		},
+		{
+			name:     "laços com condição",
+			rule:     `all t in $.itens : t.a>1 or t.nome == 't'`,
+			expected: `ALL t IN $.itens: t.a > 1 OR t.nome == "t"`,
+		},
+		{
+			name:     "string com aspas mantém o texto original",
+			rule:     `$.nome == 'd"avila'`,
+			expected: `$.nome == 'd"avila'`,
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, err := FormatRule(cenario.rule)
+			require.NoError(t, err)
+			assert.Equal(t, cenario.expected, actual)
+
+			// O formato canônico é estável
+			again, err := FormatRule(actual)
+			require.NoError(t, err)
+			assert.Equal(t, actual, again)
+		})
+	}
+
+	t.Run("regra inválida", func(t *testing.T) {
+		_, err := FormatRule(`$.valor => 10`)
+		assert.Error(t, err)
+	})
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	all_cases := []string{
+		`$.idade >= 18`,
+		`$.moeda == "BRL" OR $.moeda == "USD" OR $.moeda == 'EUR'`,
+		`$.endereco.estado IN ["SP", "RJ", "MG", "RS"]`,
+		`$ctx.userId != null`,
+		`EXP($.somaTransacoes + $.valor) <= $.limites.valorTotal`,
+		`SET $.desconto = EXP($.valor * 0.1)`,
+		`SET $.status = aprovado`,
+		`SET $.codigo = "007"`,
+		`IF $.cliente.tipo == "premium" THEN SET $.desconto = EXP($.valor * 0.15)`,
+		`ADD {"b": [1, 2.50], "a": null} TO $.itens`,
+		`ADD $.item TO $.itens`,
+		`DELETE $.itens[0]`,
+		`SET $.total = SUM($.itens, 2)`,
+		`$.idade BETWEEN 18 AND EXP($.limite - 1)`,
+		`IS_EMPTY($.tags) OR TO_NUMBER($.quantidade, 0) > 1`,
+		`ALL $.tags IN $.permitidas OR $.tags INTERSECTS ["a", "b"]`,
+		`LET limite = COALESCE($.limite, @padrao.limite)`,
+		`IF @total > 100 THEN SET $.frete = 0`,
+		`ALL m IN :moedas: m != $.moeda OR $.valor <= :limites.max`,
+		`FOREACH p IN $.pedidos DO FOREACH item IN p.itens DO SET item.total = EXP(item.qtd * p.fator)`,
+		`ANY item IN $.itens: item.valor > $.item OR ALL tag IN item.tags: tag != "bloqueada"`,
+	}
+
+	for _, rule := range all_cases {
+		t.Run(rule, func(t *testing.T) {
+			node, err := Parse(rule)
+			require.NoError(t, err)
+
+			reparsed, err := Parse(node.String())
+			require.NoError(t, err)
+			assert.Equal(t, withoutText(node), withoutText(reparsed))
+			assert.Equal(t, node.String(), reparsed.String())
+		})
+	}
+}
+
+// withoutText remove os trechos de texto da AST, que mudam com a formatação.
+func withoutText(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+	copied := *n
+	copied.Text = ""
+	copied.Left, copied.Right = withoutText(n.Left), withoutText(n.Right)
+	copied.template = withoutText(n.template)
+	copied.Value, copied.LHS, copied.RHS = operandWithoutText(n.Value), operandWithoutText(n.LHS), operandWithoutText(n.RHS)
+	return &copied
+}
+
+func operandWithoutText(o *Operand) *Operand {
+	if o == nil {
+		return nil
+	}
+	copied := *o
+	copied.Text = ""
+	copied.Quoted = false
+	copied.Left, copied.Right = operandWithoutText(o.Left), operandWithoutText(o.Right)
+	copied.Args = nil
+	for _, arg := range o.Args {
+		copied.Args = append(copied.Args, operandWithoutText(arg))
+	}
+	if o.Kind == OperandPath {
+		copied.Text = o.Text
+	}
+	return &copied
+}
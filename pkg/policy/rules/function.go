@@ -2,169 +2,291 @@ package rules
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 // arrayOperation executa operações em arrays (MAX, MIN, AVERAGE, SUM, COUNT).
 func arrayOperation(data map[string]interface{}, op, path string) (interface{}, error) {
-    val, err := getValue(data, path)
-    if err != nil {
-        return nil, err
-    }
-    arr, ok := val.([]interface{})
-    if !ok {
-        return nil, fmt.Errorf("path %s is not an array", path)
-    }
-    if len(arr) == 0 {
-        return 0.0, nil
-    }
-    switch strings.ToUpper(op) {
-    case "COUNT":
-        return float64(len(arr)), nil
-    case "SUM", "AVERAGE", "MAX", "MIN":
-        sum := 0.0
-        min := float64(0)
-        max := float64(0)
-        for i, item := range arr {
-            val, ok := item.(float64)
-            if !ok {
-                return nil, fmt.Errorf("invalid number in array: %v", item)
-            }
-            sum += val
-            if i == 0 {
-                min, max = val, val
-            } else {
-                if val < min {
-                    min = val
-                }
-                if val > max {
-                    max = val
-                }
-            }
-        }
-        switch strings.ToUpper(op) {
-        case "SUM":
-            return sum, nil
-        case "AVERAGE":
-            return sum / float64(len(arr)), nil
-        case "MAX":
-            return max, nil
-        case "MIN":
-            return min, nil
-        }
-    }
-    return nil, fmt.Errorf("unsupported array operation: %s", op)
+	val, err := getValue(data, path)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("path %s is not an array", path)
+	}
+	if len(arr) == 0 {
+		return 0.0, nil
+	}
+	switch strings.ToUpper(op) {
+	case "COUNT":
+		return float64(len(arr)), nil
+	case "SUM", "AVERAGE", "MAX", "MIN":
+		sum := 0.0
+		min := float64(0)
+		max := float64(0)
+		for i, item := range arr {
+			val, ok := item.(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid number in array: %v", item)
+			}
+			sum += val
+			if i == 0 {
+				min, max = val, val
+			} else {
+				if val < min {
+					min = val
+				}
+				if val > max {
+					max = val
+				}
+			}
+		}
+		switch strings.ToUpper(op) {
+		case "SUM":
+			return sum, nil
+		case "AVERAGE":
+			return sum / float64(len(arr)), nil
+		case "MAX":
+			return max, nil
+		case "MIN":
+			return min, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported array operation: %s", op)
+}
+
+// pathSegment representa um trecho de um caminho $ (ex.: "transacoes" ou o índice 0 em "transacoes[0]").
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// splitPath quebra um caminho $ (ex.: "$.transacoes[0].valor") em segmentos de chave e índice.
+func splitPath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "$.") {
+		return nil, fmt.Errorf("invalid path: %s", path)
+	}
+	var segments []pathSegment
+	for _, part := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		key := part
+		indexes := ""
+		if bracket := strings.Index(part, "["); bracket != -1 {
+			key, indexes = part[:bracket], part[bracket:]
+		}
+		if key == "" && indexes == "" {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		if key != "" {
+			segments = append(segments, pathSegment{key: key})
+		}
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end == -1 {
+				return nil, fmt.Errorf("invalid array index in path: %s", path)
+			}
+			index, err := strconv.Atoi(indexes[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid array index: %s", indexes[1:end])
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			indexes = indexes[end+1:]
+		}
+	}
+	return segments, nil
 }
 
 // getValue recupera um valor de um map[string]interface{} aninhado usando um caminho separado por pontos.
-// Exemplo de caminho: "user.address.zipcode" ou "items[0].name"
+// Exemplo de caminho: "$.user.address.zipcode" ou "$.items[0].name"
+// Uma chave ausente no último segmento retorna nil sem erro (permite checagens "== null").
 func getValue(data map[string]interface{}, path string) (interface{}, error) {
-    if strings.HasPrefix(path, "$.") {
-        keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
-        current := data
-        for i, key := range keys {
-            if strings.Contains(key, "[") && strings.HasSuffix(key, "]") {
-                arrayKey, index, subKey, err := parseArrayPath(key)
-                if err != nil {
-                    return nil, err
-                }
-                arr, ok := current[arrayKey].([]interface{})
-                if !ok {
-                    return nil, fmt.Errorf("path %s is not an array", arrayKey)
-                }
-                if index >= len(arr) {
-                    return nil, fmt.Errorf("index %d out of bounds for %s", index, arrayKey)
-                }
-                current, ok = arr[index].(map[string]interface{})
-                if !ok {
-                    return nil, fmt.Errorf("array element at %s is not a map", path)
-                }
-                if subKey != "" {
-                    if i == len(keys)-1 {
-                        return current[subKey], nil
-                    }
-                    current, ok = current[subKey].(map[string]interface{})
-                    if !ok {
-                        return nil, fmt.Errorf("invalid subkey %s", subKey)
-                    }
-                } else if i == len(keys)-1 {
-                    return arr[index], nil
-                }
-            } else {
-                var ok bool
-                current, ok = current[key].(map[string]interface{})
-                if !ok {
-                    if i == len(keys)-1 {
-                        return data[key], nil
-                    }
-                    return nil, fmt.Errorf("invalid path: %s", path)
-                }
-            }
-        }
-        return current, nil
-    }
- 
-	return parseLiteral(path)
+	if !strings.HasPrefix(path, "$.") {
+		return parseLiteral(path)
+	}
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var current interface{} = data
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment.isIndex {
+			arr, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("path %s is not an array", path)
+			}
+			if segment.index >= len(arr) {
+				return nil, fmt.Errorf("index %d out of bounds for %s", segment.index, path)
+			}
+			current = arr[segment.index]
+			continue
+		}
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		value, exists := obj[segment.key]
+		if !exists && !last {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		current = value
+	}
+	return current, nil
+}
+
+// hasValue indica se o caminho existe nos dados: todas as chaves presentes (mesmo que com
+// null) e todos os índices dentro dos arrays.
+func hasValue(data map[string]interface{}, path string) (bool, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return false, err
+	}
+	var current interface{} = data
+	for _, segment := range segments {
+		if segment.isIndex {
+			arr, ok := current.([]interface{})
+			if !ok || segment.index >= len(arr) {
+				return false, nil
+			}
+			current = arr[segment.index]
+			continue
+		}
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		if current, ok = obj[segment.key]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
 }
 
 // setValue define um valor em um map[string]interface{} aninhado usando um caminho separado por pontos.
-// Cria mapas intermediários se eles não existirem.
+// Cria mapas intermediários (e arrays, para segmentos com índice) se eles não existirem.
 func setValue(data map[string]interface{}, path string, value interface{}) error {
-    if strings.HasPrefix(path, "$.") {
-        keys := strings.Split(strings.TrimPrefix(path, "$."), ".")
-        current := data
-        for i, key := range keys[:len(keys)-1] {
-            if strings.Contains(key, "[") && strings.HasSuffix(key, "]") {
-                arrayKey, index, subKey, err := parseArrayPath(key)
-                if err != nil {
-                    return err
-                }
-                arr, ok := current[arrayKey].([]interface{})
-                if !ok {
-                    // Criar array se não existir
-                    arr = make([]interface{}, index+1)
-                    for j := range arr {
-                        arr[j] = make(map[string]interface{})
-                    }
-                    current[arrayKey] = arr
-                } else if index >= len(arr) {
-                    // Expandir array se necessário
-                    newArr := make([]interface{}, index+1)
-                    copy(newArr, arr)
-                    for j := len(arr); j <= index; j++ {
-                        newArr[j] = make(map[string]interface{})
-                    }
-                    arr = newArr
-                    current[arrayKey] = arr
-                }
-                current = arr[index].(map[string]interface{})
-                if subKey != "" {
-                    if i == len(keys)-2 {
-                        current[subKey] = value
-                    } else {
-                        next, ok := current[subKey].(map[string]interface{})
-                        if !ok {
-                            next = make(map[string]interface{})
-                            current[subKey] = next
-                        }
-                        current = next
-                    }
-                } else if i == len(keys)-2 {
-                    arr[index] = value
-                }
-            } else {
-                next, ok := current[key].(map[string]interface{})
-                if !ok {
-                    next = make(map[string]interface{})
-                    current[key] = next
-                }
-                current = next
-                if i == len(keys)-2 {
-                    current[keys[len(keys)-1]] = value
-                }
-            }
-        }
-        return nil
-    }
-    return fmt.Errorf("invalid path: %s", path)
-}
\ No newline at end of file
+	segments, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	if segments[0].isIndex {
+		return fmt.Errorf("invalid path: %s", path)
+	}
+	_, err = setSegments(data, segments, value, path)
+	return err
+}
+
+// setSegments percorre recursivamente os segmentos criando a estrutura necessária e
+// retorna o container (mapa ou array) possivelmente realocado.
+func setSegments(container interface{}, segments []pathSegment, value interface{}, path string) (interface{}, error) {
+	segment := segments[0]
+	rest := segments[1:]
+
+	if !segment.isIndex {
+		obj, ok := container.(map[string]interface{})
+		if !ok {
+			if container != nil {
+				return nil, fmt.Errorf("path %s atravessa um valor que não é objeto", path)
+			}
+			obj = make(map[string]interface{})
+		}
+		if len(rest) == 0 {
+			obj[segment.key] = value
+			return obj, nil
+		}
+		child, err := setSegments(obj[segment.key], rest, value, path)
+		if err != nil {
+			return nil, err
+		}
+		obj[segment.key] = child
+		return obj, nil
+	}
+
+	arr, ok := container.([]interface{})
+	if !ok && container != nil {
+		return nil, fmt.Errorf("path %s is not an array", path)
+	}
+	if segment.index >= len(arr) {
+		// Expandir array se necessário
+		newArr := make([]interface{}, segment.index+1)
+		copy(newArr, arr)
+		arr = newArr
+	}
+	if len(rest) == 0 {
+		arr[segment.index] = value
+		return arr, nil
+	}
+	child, err := setSegments(arr[segment.index], rest, value, path)
+	if err != nil {
+		return nil, err
+	}
+	arr[segment.index] = child
+	return arr, nil
+}
+
+// deleteValue remove a chave (ou o elemento de array) indicado pelo caminho.
+// Retorna false se o caminho não existir nos dados.
+func deleteValue(data map[string]interface{}, path string) (bool, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return false, err
+	}
+	if segments[0].isIndex {
+		return false, fmt.Errorf("invalid path: %s", path)
+	}
+
+	var parent interface{} = data
+	var grandparent interface{}
+	var parentSegment pathSegment
+	for _, segment := range segments[:len(segments)-1] {
+		grandparent, parentSegment = parent, segment
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if segment.isIndex {
+				return false, nil
+			}
+			parent = p[segment.key]
+		case []interface{}:
+			if !segment.isIndex || segment.index >= len(p) {
+				return false, nil
+			}
+			parent = p[segment.index]
+		default:
+			return false, nil
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if last.isIndex {
+			return false, fmt.Errorf("path %s is not an array", path)
+		}
+		if _, exists := p[last.key]; !exists {
+			return false, nil
+		}
+		delete(p, last.key)
+		return true, nil
+	case []interface{}:
+		if !last.isIndex {
+			return false, fmt.Errorf("invalid path: %s", path)
+		}
+		if last.index >= len(p) {
+			return false, nil
+		}
+		shrunk := append(p[:last.index:last.index], p[last.index+1:]...)
+		// O array é realocado, então o container pai precisa apontar para a nova fatia
+		switch g := grandparent.(type) {
+		case map[string]interface{}:
+			g[parentSegment.key] = shrunk
+		case []interface{}:
+			g[parentSegment.index] = shrunk
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,305 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Tipos dos argumentos e do resultado de uma assinatura de função
+const (
+	TypeAny     = "any" // Qualquer valor, inclusive null; a função faz a própria conversão
+	TypeString  = "string"
+	TypeNumber  = "number" // Entregue como float64
+	TypeBoolean = "boolean"
+	TypeArray   = "array"  // []interface{}
+	TypeObject  = "object" // map[string]interface{}
+	TypeDate    = "date"   // time.Time; aceita também texto em um dos formatos de DATE
+)
+
+var (
+	functionNameRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	signatureTypes = map[string]bool{TypeAny: true, TypeString: true, TypeNumber: true, TypeBoolean: true, TypeArray: true, TypeObject: true, TypeDate: true}
+)
+
+// Function é a implementação de uma função chamável nas regras. Os argumentos chegam já
+// avaliados (caminhos resolvidos, literais convertidos) e o contexto dá acesso ao relógio
+// e às variáveis da avaliação.
+type Function func(ctx *Context, args []interface{}) (interface{}, error)
+
+// Signature descreve os argumentos e o resultado de uma função.
+//
+//	Signature{Args: []string{TypeString, TypeNumber}, Optional: 1, Returns: TypeBoolean}
+//
+// aceita um texto e, opcionalmente, um número, e resulta em booleano.
+type Signature struct {
+	Args     []string // Tipo de cada argumento
+	Optional int      // Quantidade de argumentos finais que podem ser omitidos
+	Variadic bool     // O último argumento pode se repetir
+	Returns  string   // Tipo do resultado ("" equivale a TypeAny)
+}
+
+// ArgType retorna o tipo do argumento na posição 'index' (o último se repete em funções
+// variádicas); TypeAny para posições fora da assinatura.
+func (s Signature) ArgType(index int) string {
+	switch {
+	case index < len(s.Args):
+		return s.Args[index]
+	case s.Variadic && len(s.Args) > 0:
+		return s.Args[len(s.Args)-1]
+	}
+	return TypeAny
+}
+
+func (s Signature) minArgs() int {
+	return len(s.Args) - s.Optional
+}
+
+// maxArgs retorna o máximo de argumentos; -1 indica quantidade variável.
+func (s Signature) maxArgs() int {
+	if s.Variadic {
+		return -1
+	}
+	return len(s.Args)
+}
+
+func (s Signature) acceptsCount(count int) bool {
+	return count >= s.minArgs() && (s.maxArgs() < 0 || count <= s.maxArgs())
+}
+
+func (s Signature) describeArity() string {
+	switch {
+	case s.maxArgs() < 0:
+		return fmt.Sprintf("ao menos %d argumento(s)", s.minArgs())
+	case s.minArgs() == s.maxArgs():
+		return fmt.Sprintf("%d argumento(s)", s.minArgs())
+	}
+	return fmt.Sprintf("de %d a %d argumentos", s.minArgs(), s.maxArgs())
+}
+
+func (s Signature) validate() error {
+	if s.Optional < 0 || s.Optional > len(s.Args) {
+		return fmt.Errorf("assinatura com %d argumento(s) opcional(is) de %d", s.Optional, len(s.Args))
+	}
+	if s.Variadic && len(s.Args) == 0 {
+		return fmt.Errorf("assinatura variádica sem argumentos")
+	}
+	types := append([]string{s.Returns}, s.Args...)
+	for _, typ := range types {
+		if typ != "" && !signatureTypes[typ] {
+			return fmt.Errorf("tipo desconhecido na assinatura: '%s'", typ)
+		}
+	}
+	return nil
+}
+
+// function é uma função do registro. As funções nativas convertem os próprios argumentos;
+// as registradas com Register (strict) recebem os argumentos e têm o resultado verificados
+// contra a assinatura.
+type function struct {
+	signature      Signature
+	fn             Function
+	strict         bool
+	acceptsMissing bool // Caminhos ausentes chegam como 'missing' em vez de null (ex.: EXISTS)
+}
+
+// FunctionRegistry reúne as funções que podem ser chamadas nas regras. Um registro criado
+// por NewFunctionRegistry (ou o valor zero, FunctionRegistry{}) contém as funções nativas e
+// as registradas nele, sem afetar os demais registros; um registro nil contém apenas as
+// nativas. É seguro registrar funções enquanto outras goroutines avaliam regras; cada
+// registro muda a versão (ver Version), e o motor verifica as políticas novamente contra as
+// novas funções antes de atender a próxima requisição (ver core.EngineContext.CheckFunctions).
+type FunctionRegistry struct {
+	mu        sync.RWMutex
+	parent    *FunctionRegistry // nil, exceto nas nativas, equivale às nativas
+	functions map[string]function
+	version   uint64
+}
+
+// NewFunctionRegistry cria um registro com as funções nativas, ao qual podem ser acrescentadas
+// funções próprias com Register.
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{parent: builtins, functions: make(map[string]function)}
+}
+
+// Register acrescenta uma função chamável nas regras como NOME(args). O nome deve estar em
+// maiúsculas e não pode repetir uma função existente. Na avaliação, os argumentos são
+// convertidos para os tipos da assinatura (ex.: números como float64, datas como time.Time)
+// e um argumento ou resultado de outro tipo é um erro da regra.
+func (r *FunctionRegistry) Register(name string, signature Signature, fn Function) error {
+	if r == nil {
+		return fmt.Errorf("registro de funções nil")
+	}
+	if !functionNameRe.MatchString(name) || name == "EXP" {
+		return fmt.Errorf("nome de função inválido: '%s'", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("função %s sem implementação", name)
+	}
+	if err := signature.validate(); err != nil {
+		return fmt.Errorf("função %s: %v", name, err)
+	}
+	if r.next().IsFunction(name) {
+		return fmt.Errorf("função %s já registrada", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.functions[name]; exists {
+		return fmt.Errorf("função %s já registrada", name)
+	}
+	if r.functions == nil {
+		r.functions = make(map[string]function)
+	}
+	r.functions[name] = function{signature: signature, fn: fn, strict: true}
+	r.version++
+	return nil
+}
+
+// Version retorna a quantidade de funções registradas com Register; muda a cada registro.
+func (r *FunctionRegistry) Version() uint64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// next retorna o registro consultado depois deste: o pai ou, sem ele, as funções nativas.
+func (r *FunctionRegistry) next() *FunctionRegistry {
+	if r == builtins {
+		return nil
+	}
+	if r.parent == nil {
+		return builtins
+	}
+	return r.parent
+}
+
+func (r *FunctionRegistry) lookup(name string) (function, bool) {
+	if r == nil {
+		r = builtins
+	}
+	for ; r != nil; r = r.next() {
+		r.mu.RLock()
+		f, exists := r.functions[name]
+		r.mu.RUnlock()
+		if exists {
+			return f, true
+		}
+	}
+	return function{}, false
+}
+
+// IsFunction indica se a função pode ser chamada nas regras.
+func (r *FunctionRegistry) IsFunction(name string) bool {
+	_, exists := r.lookup(name)
+	return exists
+}
+
+// Lookup retorna a assinatura da função.
+func (r *FunctionRegistry) Lookup(name string) (Signature, bool) {
+	f, exists := r.lookup(name)
+	return f.signature, exists
+}
+
+// Check verifica estaticamente uma chamada: a função existe, a quantidade de argumentos é
+// aceita pela assinatura e os argumentos literais (ou resultados de outras funções) têm o
+// tipo esperado.
+func (r *FunctionRegistry) Check(call *Operand) error {
+	f, exists := r.lookup(call.Func)
+	if !exists {
+		return fmt.Errorf("função não implementada: %s", call.Func)
+	}
+	if !f.signature.acceptsCount(len(call.Args)) {
+		return fmt.Errorf("função %s espera %s, recebeu %d", call.Func, f.signature.describeArity(), len(call.Args))
+	}
+	if !f.strict {
+		return nil
+	}
+	for i, arg := range call.Args {
+		typ := f.signature.ArgType(i)
+		switch arg.Kind {
+		case OperandLiteral:
+			if _, err := convertArg(typ, arg.Value); err != nil {
+				return fmt.Errorf("%s: argumento %d: %v", call.Func, i+1, err)
+			}
+		case OperandCall:
+			if nested, exists := r.lookup(arg.Func); exists && !compatibleTypes(typ, nested.signature.Returns) {
+				return fmt.Errorf("%s: argumento %d exige %s, mas %s resulta em %s", call.Func, i+1, typ, arg.Func, nested.signature.Returns)
+			}
+		}
+	}
+	return nil
+}
+
+// compatibleTypes indica se um valor do tipo 'actual' pode ser entregue a um argumento do tipo 'want'.
+func compatibleTypes(want, actual string) bool {
+	if want == TypeAny || actual == "" || actual == TypeAny || want == actual {
+		return true
+	}
+	return want == TypeDate && actual == TypeString
+}
+
+// call executa a função com os argumentos avaliados, convertendo-os e verificando o
+// resultado nas funções registradas com Register.
+func (f function) call(ctx *Context, args []interface{}) (interface{}, error) {
+	if !f.strict {
+		return f.fn(ctx, args)
+	}
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := convertArg(f.signature.ArgType(i), arg)
+		if err != nil {
+			return nil, fmt.Errorf("argumento %d: %v", i+1, err)
+		}
+		converted[i] = value
+	}
+	result, err := f.fn(ctx, converted)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = convertArg(f.signature.Returns, result); err != nil {
+		return nil, fmt.Errorf("resultado: %v", err)
+	}
+	return result, nil
+}
+
+// convertArg converte o valor para o tipo da assinatura. null só é aceito por TypeAny.
+func convertArg(typ string, value interface{}) (interface{}, error) {
+	if typ == "" || typ == TypeAny {
+		return value, nil
+	}
+	if value == nil {
+		return nil, fmt.Errorf("esperado %s, recebeu null", typ)
+	}
+	switch typ {
+	case TypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case TypeNumber:
+		if isNumber(value) {
+			num, _ := convertToFloat64(value)
+			return num, nil
+		}
+	case TypeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case TypeArray:
+		if isArray(value) {
+			return value, nil
+		}
+	case TypeObject:
+		if isObject(value) {
+			return value, nil
+		}
+	case TypeDate:
+		if t, err := toTime(value, nil); err == nil {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("esperado %s, recebeu %v (%T)", typ, value, value)
+}
@@ -0,0 +1,236 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry registra funções de domínio como as que os times acrescentam ao motor.
+func testRegistry(t *testing.T) *FunctionRegistry {
+	registry := NewFunctionRegistry()
+	require.NoError(t, registry.Register("CPF_VALIDO", Signature{Args: []string{TypeString}, Returns: TypeBoolean},
+		func(_ *Context, args []interface{}) (interface{}, error) {
+			digits := strings.NewReplacer(".", "", "-", "").Replace(args[0].(string))
+			return len(digits) == 11, nil
+		}))
+	require.NoError(t, registry.Register("CONVERTER", Signature{Args: []string{TypeNumber, TypeString, TypeString}, Optional: 1, Returns: TypeNumber},
+		func(_ *Context, args []interface{}) (interface{}, error) {
+			rates := map[string]float64{"USD": 5, "EUR": 6}
+			rate, exists := rates[args[1].(string)]
+			if !exists {
+				return nil, fmt.Errorf("moeda desconhecida '%s'", args[1])
+			}
+			return args[0].(float64) * rate, nil
+		}))
+	require.NoError(t, registry.Register("ANO", Signature{Args: []string{TypeDate}, Returns: TypeNumber},
+		func(_ *Context, args []interface{}) (interface{}, error) {
+			return args[0].(time.Time).Year(), nil
+		}))
+	require.NoError(t, registry.Register("SOMA", Signature{Args: []string{TypeNumber}, Variadic: true, Returns: TypeNumber},
+		func(_ *Context, args []interface{}) (interface{}, error) {
+			total := 0.0
+			for _, arg := range args {
+				total += arg.(float64)
+			}
+			return total, nil
+		}))
+	require.NoError(t, registry.Register("QUEBRADA", Signature{Returns: TypeString},
+		func(_ *Context, _ []interface{}) (interface{}, error) {
+			return 10, nil
+		}))
+	return registry
+}
+
+func registryPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"cpf":        "123.456.789-09",
+		"valor":      10.0,
+		"quantia":    3,
+		"moeda":      "USD",
+		"nascimento": "1990-05-01",
+		"idade":      30.0,
+		"apelido":    nil,
+	}
+}
+
+func TestRegisteredFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "função como condição", rule: `CPF_VALIDO($.cpf)`, expected: true},
+		{name: "função comparada", rule: `CONVERTER($.valor, $.moeda) == 50`, expected: true},
+		{name: "inteiro convertido em float64", rule: `CONVERTER($.quantia, "EUR") == 18`, expected: true},
+		{name: "texto convertido em data", rule: `ANO($.nascimento) == 1990`, expected: true},
+		{name: "data de outra função", rule: `ANO(DATE_ADD($.nascimento, 1, "years")) == 1991`, expected: true},
+		{name: "variádica", rule: `SOMA(1, $.valor, $.quantia) == 14`, expected: true},
+		{name: "junto às nativas", rule: `CPF_VALIDO(CONCAT("123", "456", "789", "00")) OR UPPER($.moeda) == "USD"`, expected: true},
+		{name: "no SET", rule: `IF CPF_VALIDO($.cpf) THEN SET $.convertido = CONVERTER($.valor, "EUR")`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := NewContext(registryPayload())
+			ctx.Functions = testRegistry(t)
+			actual, details, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "argumento de outro tipo", rule: `CPF_VALIDO($.idade)`, message: "CPF_VALIDO: argumento 1: esperado string, recebeu 30 (float64)"},
+		{name: "string numérica não é número", rule: `CONVERTER("10", "USD") == 50`, message: "CONVERTER: argumento 1: esperado number"},
+		{name: "argumento null", rule: `CPF_VALIDO($.apelido)`, message: "CPF_VALIDO: argumento 1: esperado string, recebeu null"},
+		{name: "data inválida", rule: `ANO($.cpf) == 1`, message: "ANO: argumento 1: esperado date"},
+		{name: "erro da função", rule: `CONVERTER($.valor, "BTC") == 1`, message: "CONVERTER: moeda desconhecida 'BTC'"},
+		{name: "resultado de outro tipo", rule: `QUEBRADA() == "10"`, message: "QUEBRADA: resultado: esperado string, recebeu 10 (int)"},
+		{name: "quantidade de argumentos", rule: `CPF_VALIDO($.cpf, 1)`, message: "função CPF_VALIDO espera 1 argumento(s), recebeu 2"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := NewContext(registryPayload())
+			ctx.Functions = testRegistry(t)
+			_, _, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+
+	t.Run("registro por contexto", func(t *testing.T) {
+		_, _, err := EvaluateRule(`CPF_VALIDO($.cpf)`, registryPayload())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "função não implementada: CPF_VALIDO")
+
+		ctx := NewContext(registryPayload())
+		ctx.Functions = NewFunctionRegistry()
+		_, _, err = EvaluateRuleWithContext(`CPF_VALIDO($.cpf)`, ctx, nil)
+		require.Error(t, err)
+		assert.False(t, IsFunction("CPF_VALIDO"))
+		assert.True(t, ctx.Functions.IsFunction("UPPER"))
+	})
+}
+
+func TestRegisterErrors(t *testing.T) {
+	noop := func(_ *Context, _ []interface{}) (interface{}, error) { return nil, nil }
+
+	all_cases := []struct {
+		name      string
+		function  string
+		signature Signature
+		fn        Function
+		message   string
+	}{
+		{name: "nome em minúsculas", function: "cpf", fn: noop, message: "nome de função inválido: 'cpf'"},
+		{name: "nome de EXP", function: "EXP", fn: noop, message: "nome de função inválido: 'EXP'"},
+		{name: "função nativa", function: "UPPER", fn: noop, message: "função UPPER já registrada"},
+		{name: "função já registrada", function: "CPF_VALIDO", fn: noop, message: "função CPF_VALIDO já registrada"},
+		{name: "sem implementação", function: "NOVA", message: "função NOVA sem implementação"},
+		{name: "tipo desconhecido", function: "NOVA", signature: Signature{Args: []string{"texto"}}, fn: noop, message: "tipo desconhecido na assinatura: 'texto'"},
+		{name: "opcionais demais", function: "NOVA", signature: Signature{Args: []string{TypeAny}, Optional: 2}, fn: noop, message: "assinatura com 2 argumento(s) opcional(is) de 1"},
+		{name: "variádica sem argumentos", function: "NOVA", signature: Signature{Variadic: true}, fn: noop, message: "assinatura variádica sem argumentos"},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			err := testRegistry(t).Register(cenario.function, cenario.signature, cenario.fn)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+
+	var registry *FunctionRegistry
+	assert.Error(t, registry.Register("NOVA", Signature{}, noop))
+	assert.True(t, registry.IsFunction("UPPER"))
+
+	t.Run("valor zero do registro", func(t *testing.T) {
+		var registry FunctionRegistry
+		assert.True(t, registry.IsFunction("UPPER"))
+		assert.Error(t, registry.Register("UPPER", Signature{}, noop))
+		require.NoError(t, registry.Register("DOBRO", Signature{Args: []string{TypeNumber}, Returns: TypeNumber},
+			func(_ *Context, args []interface{}) (interface{}, error) { return args[0].(float64) * 2, nil }))
+		assert.Equal(t, uint64(1), registry.Version())
+
+		ctx := NewContext(map[string]interface{}{"nome": "ana", "valor": 2.0})
+		ctx.Functions = &registry
+		for _, rule := range []string{`DOBRO($.valor) == 4`, `UPPER($.nome) == "ANA"`} {
+			actual, details, err := EvaluateRuleWithContext(rule, ctx, nil)
+			require.NoError(t, err, details)
+			assert.True(t, actual, details)
+		}
+	})
+
+	t.Run("registro durante avaliações", func(t *testing.T) {
+		registry := testRegistry(t)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, registry.Register(fmt.Sprintf("NOVA_%d", i), Signature{}, noop))
+			}(i)
+			go func() {
+				defer wg.Done()
+				ctx := NewContext(map[string]interface{}{"cpf": "12345678909"})
+				ctx.Functions = registry
+				_, _, err := EvaluateRuleWithContext(`CPF_VALIDO($.cpf)`, ctx, nil)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.True(t, registry.IsFunction("NOVA_7"))
+		assert.Error(t, registry.Register("NOVA_7", Signature{}, noop))
+	})
+}
+
+func TestFunctionCheck(t *testing.T) {
+	all_cases := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "chamada válida", rule: `CONVERTER($.valor, "USD") > 1`},
+		{name: "opcional informado", rule: `CONVERTER(10, "USD", "BRL") > 1`},
+		{name: "nativa sem verificação de tipos", rule: `UPPER(10) == "10"`},
+		{name: "data em texto", rule: `ANO("2024-01-01") > 1`},
+		{name: "argumentos de menos", rule: `CONVERTER($.valor) > 1`, message: "função CONVERTER espera de 2 a 3 argumentos, recebeu 1"},
+		{name: "nativa com argumentos demais", rule: `UPPER($.a, $.b) == "A"`, message: "função UPPER espera 1 argumento(s), recebeu 2"},
+		{name: "literal de outro tipo", rule: `CPF_VALIDO(123)`, message: "CPF_VALIDO: argumento 1: esperado string, recebeu 123 (float64)"},
+		{name: "literal null", rule: `CPF_VALIDO(null)`, message: "CPF_VALIDO: argumento 1: esperado string, recebeu null"},
+		{name: "resultado de outra função", rule: `SOMA(1, LEN($.a), UPPER($.b)) > 1`, message: "SOMA: argumento 3 exige number, mas UPPER resulta em string"},
+		{name: "função desconhecida", rule: `CPF($.a)`, message: "função não implementada: CPF"},
+	}
+
+	registry := testRegistry(t)
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			node, err := Parse(cenario.rule)
+			require.NoError(t, err)
+			call := node.LHS
+			err = registry.Check(call)
+			if cenario.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, cenario.message, err.Error())
+		})
+	}
+
+	signature, exists := registry.Lookup("SOMA")
+	require.True(t, exists)
+	assert.Equal(t, TypeNumber, signature.ArgType(5))
+	assert.Equal(t, TypeAny, Signature{}.ArgType(0))
+}
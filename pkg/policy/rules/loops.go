@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LoopForEach é o laço que executa uma ação para cada item de um array; ANY e ALL
+// (QuantifierAny, QuantifierAll) são os laços que verificam uma condição em cada item.
+const LoopForEach = "FOREACH"
+
+// DefaultMaxIterations é o limite de itens de cada laço quando Context.MaxIterations é 0.
+const DefaultMaxIterations = 1000
+
+var (
+	// foreachRuleRe separa "FOREACH item IN $.lista DO <ação>".
+	foreachRuleRe = regexp.MustCompile(`^FOREACH\s+(\S+)\s+IN\s+(\S+)\s+DO\s+(.+)$`)
+	// everyRuleRe separa "ALL item IN $.lista: <condição>" (e ANY). O nome do item distingue
+	// o laço do quantificador de comparação ("ALL $.lista > 0").
+	everyRuleRe = regexp.MustCompile(`^(ALL|ANY)\s+([A-Za-z_][A-Za-z0-9_]*)\s+IN\s+(:?[^\s:]+)\s*:\s*(.+)$`)
+)
+
+// actionPrefixes são os inícios de regra que alteram os dados ou as variáveis.
+var actionPrefixes = []string{"SET ", "ADD ", "DELETE ", "LET ", "IF ", LoopForEach + " "}
+
+// loop é um laço decomposto: no corpo, o nome do item se refere ao elemento da vez.
+type loop struct {
+	kind       string // LoopForEach, QuantifierAll ou QuantifierAny
+	item       string
+	collection string
+	body       string
+}
+
+// splitLoop reconhece os laços FOREACH, ALL e ANY. matched é false para outras regras;
+// err descreve um laço mal formado.
+func splitLoop(text string) (l loop, matched bool, err error) {
+	if parts := everyRuleRe.FindStringSubmatch(text); parts != nil {
+		l = loop{kind: parts[1], item: parts[2], collection: parts[3], body: strings.TrimSpace(parts[4])}
+	} else if strings.HasPrefix(text, LoopForEach+" ") {
+		parts := foreachRuleRe.FindStringSubmatch(text)
+		if parts == nil {
+			return loop{}, true, fmt.Errorf("regra FOREACH inválida: %s", text)
+		}
+		l = loop{kind: LoopForEach, item: parts[1], collection: parts[2], body: strings.TrimSpace(parts[3])}
+	} else {
+		return loop{}, false, nil
+	}
+
+	switch {
+	case !variableNameRe.MatchString(l.item) || keywords[strings.ToUpper(l.item)]:
+		return loop{}, true, fmt.Errorf("nome de item inválido no %s: '%s'", l.kind, l.item)
+	case !isPath(l.collection) || strings.Contains(l.collection, wildcardIndex):
+		return loop{}, true, fmt.Errorf("%s exige o caminho de um array, sem [*]: '%s'", l.kind, l.collection)
+	case l.kind == LoopForEach && !IsActionRule(l.body):
+		return loop{}, true, fmt.Errorf("FOREACH exige uma ação (SET, ADD, DELETE, LET, IF ou FOREACH): '%s'", l.body)
+	case l.kind != LoopForEach && IsActionRule(l.body):
+		return loop{}, true, fmt.Errorf("%s exige uma condição, não uma ação: '%s'", l.kind, l.body)
+	}
+	return l, true, nil
+}
+
+// IsActionRule indica se a regra é uma ação (SET, ADD, DELETE, LET, IF...THEN ou FOREACH) em
+// vez de uma condição. Uma ação que não falha não reprova a política.
+func IsActionRule(rule string) bool {
+	rule = strings.TrimSpace(rule)
+	for _, prefix := range actionPrefixes {
+		if strings.HasPrefix(rule, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// itemPath retorna o caminho do elemento 'index' da coleção.
+func (l loop) itemPath(index int) string {
+	return fmt.Sprintf("%s[%d]", l.collection, index)
+}
+
+// bind retorna o corpo do laço com as referências ao item trocadas pelo caminho informado.
+func (l loop) bind(path string) string {
+	return bindItem(l.body, l.item, path)
+}
+
+// bindItem substitui, fora de aspas, as referências ao item ("item", "item.valor",
+// "item[0]") pelo caminho do elemento. Campos, variáveis e parâmetros de mesmo nome ("$.item",
+// "$.pedido.item", "@item", ":item") e palavras que apenas começam pelo nome ("itens") não mudam.
+func bindItem(body, item, path string) string {
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(body[i:], item) && boundsItem(body, i, i+len(item)):
+			sb.WriteString(path)
+			i += len(item) - 1
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// boundsItem indica se body[start:end] é uma referência isolada ao item.
+func boundsItem(body string, start, end int) bool {
+	if start > 0 && (isNameByte(body[start-1]) || strings.IndexByte("$@:.", body[start-1]) >= 0) {
+		return false
+	}
+	return end == len(body) || (!isNameByte(body[end]) && body[end] != '(')
+}
+
+func isNameByte(c byte) bool {
+	return isLetter(c) || c == '_' || (c >= '0' && c <= '9')
+}
+
+// maxIterations retorna o limite de itens de cada laço.
+func (c *Context) maxIterations() int {
+	if c == nil || c.MaxIterations <= 0 {
+		return DefaultMaxIterations
+	}
+	return c.MaxIterations
+}
+
+// loopItems retorna a quantidade de itens da coleção do laço. Uma coleção ausente ou null
+// não tem itens; qualquer outro valor que não seja array é um erro.
+func (l loop) loopItems(ctx *Context, node *TraceNode) (int, error) {
+	value, err := lookupPath(ctx, l.collection, node)
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, nil
+	}
+	arr, ok := value.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("%s exige um array, mas %s é %T", l.kind, l.collection, value)
+	}
+	if limit := ctx.maxIterations(); len(arr) > limit {
+		return 0, fmt.Errorf("%s: %s tem %d itens, acima do limite de %d", l.kind, l.collection, len(arr), limit)
+	}
+	return len(arr), nil
+}
+
+// forEach executa os laços:
+//   - FOREACH item IN $.lista DO <ação>: executa a ação para cada item, na ordem;
+//   - ALL item IN $.lista: <condição>: verdadeira se todos os itens atendem (true sem itens);
+//   - ANY item IN $.lista: <condição>: verdadeira se algum item atende (false sem itens).
+//
+// A quantidade de itens é lida antes da primeira iteração e limitada por Context.MaxIterations.
+// Os detalhes trazem o resultado de cada item e o trace ganha um nó por item. Em arquivos
+// YAML, as regras ALL e ANY precisam de aspas por causa do ':'.
+func forEach(trimmedRule string, ctx *Context, node *TraceNode) RuleExecutionResult {
+	l, matched, err := splitLoop(trimmedRule)
+	if !matched {
+		return RuleExecutionResult{Executed: false}
+	}
+	if err != nil {
+		return RuleExecutionResult{Executed: true, Passed: false, Err: err}
+	}
+
+	count, err := l.loopItems(ctx, node)
+	if err != nil {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Details:  fmt.Sprintf("Falha ao obter os itens de %s: %v", l.collection, err),
+			Err:      err,
+		}
+	}
+
+	var itemDetails []string
+	var failed []int
+	for i := 0; i < count; i++ {
+		path := l.itemPath(i)
+		body := l.bind(path)
+		if l.kind == LoopForEach && strings.TrimSpace(body) == "DELETE "+path {
+			err := fmt.Errorf("FOREACH não pode remover o próprio item: %s", path)
+			return RuleExecutionResult{Executed: true, Passed: false, Details: strings.Join(itemDetails, "; "), Err: err}
+		}
+
+		itemNode := node.Child(TraceItem, path)
+		passed, details, err := evaluateRule(body, ctx, itemNode)
+		itemNode.Finish(passed, err)
+		itemDetails = append(itemDetails, fmt.Sprintf("[%d] %s", i, details))
+		if err != nil {
+			err = fmt.Errorf("item %d: %v", i, err)
+			return RuleExecutionResult{
+				Executed: true,
+				Passed:   false,
+				Details:  fmt.Sprintf("%s %s IN %s -> ERRO: %s", l.kind, l.item, l.collection, strings.Join(itemDetails, "; ")),
+				Err:      err,
+			}
+		}
+		if !passed {
+			failed = append(failed, i)
+		}
+		if l.kind == QuantifierAny && passed {
+			return RuleExecutionResult{
+				Executed: true,
+				Passed:   true,
+				Details:  fmt.Sprintf("ANY %s IN %s -> true (item %d): %s", l.item, l.collection, i, strings.Join(itemDetails, "; ")),
+			}
+		}
+	}
+
+	passed := len(failed) == 0
+	if l.kind == QuantifierAny {
+		passed = false
+	}
+	summary := fmt.Sprintf("%d itens", count)
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(", não atendidos: %v", failed)
+	}
+	return RuleExecutionResult{
+		Executed: true,
+		Passed:   passed,
+		Details:  fmt.Sprintf("%s %s IN %s -> %t (%s): %s", l.kind, l.item, l.collection, passed, summary, strings.Join(itemDetails, "; ")),
+	}
+}
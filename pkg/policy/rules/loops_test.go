@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loopPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"t":     90.0,
+		"moeda": "BRL",
+		"transacoes": []interface{}{
+			map[string]interface{}{"valor": 100.0, "moeda": "BRL"},
+			map[string]interface{}{"valor": 50.0, "moeda": "USD"},
+		},
+		"pedidos": []interface{}{
+			map[string]interface{}{"itens": []interface{}{
+				map[string]interface{}{"qtd": 2.0, "preco": 10.0},
+				map[string]interface{}{"qtd": 1.0, "preco": 5.0},
+			}},
+			map[string]interface{}{"itens": []interface{}{}},
+		},
+		"vazio": []interface{}{},
+	}
+}
+
+func TestLoops(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "FOREACH com SET", rule: `FOREACH item IN $.transacoes DO SET item.taxa = EXP(item.valor * 0.02)`, expected: true},
+		{name: "FOREACH com IF", rule: `FOREACH t IN $.transacoes DO IF t.valor > $.t THEN SET t.vip = true`, expected: true},
+		{name: "FOREACH sem itens", rule: `FOREACH item IN $.ausente DO SET item.x = 1`, expected: true},
+		{name: "ALL atendida", rule: `ALL item IN $.transacoes: item.valor > 0`, expected: true},
+		{name: "ALL não atendida", rule: `ALL item IN $.transacoes: item.valor > 60`, expected: false},
+		{name: "ALL com OR no corpo", rule: `ALL item IN $.transacoes: item.valor > 60 OR item.moeda == "USD"`, expected: true},
+		{name: "ALL sem itens", rule: `ALL item IN $.vazio: item.valor > 0`, expected: true},
+		{name: "ANY atendida", rule: `ANY item IN $.transacoes: item.moeda == "USD"`, expected: true},
+		{name: "ANY não atendida", rule: `ANY item IN $.transacoes: item.valor > 100`, expected: false},
+		{name: "ANY sem itens", rule: `ANY item IN $.vazio: item.valor > 0`, expected: false},
+		{name: "item entre aspas e campo de mesmo nome", rule: `ALL t IN $.transacoes: t.moeda != "t" OR t.valor > $.t`, expected: true},
+		{name: "laços aninhados", rule: `ALL p IN $.pedidos: ALL i IN p.itens: i.qtd >= 1`, expected: true},
+		{name: "laço após OR", rule: `$.moeda == "USD" OR ANY item IN $.transacoes: item.moeda == $.moeda`, expected: true},
+		{name: "IF com FOREACH", rule: `IF $.moeda == "BRL" THEN FOREACH item IN $.transacoes DO SET item.nacional = true`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, loopPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	t.Run("FOREACH altera cada item", func(t *testing.T) {
+		data := loopPayload()
+		_, _, err := EvaluateRule(`FOREACH item IN $.transacoes DO SET item.taxa = EXP(item.valor * 0.02)`, data)
+		require.NoError(t, err)
+		_, _, err = EvaluateRule(`FOREACH p IN $.pedidos DO FOREACH i IN p.itens DO SET i.total = EXP(i.qtd * i.preco)`, data)
+		require.NoError(t, err)
+
+		taxa, _ := getValue(data, "$.transacoes[1].taxa")
+		assert.Equal(t, 1.0, taxa)
+		total, _ := getValue(data, "$.pedidos[0].itens[0].total")
+		assert.Equal(t, 20.0, total)
+	})
+
+	t.Run("detalhes por item", func(t *testing.T) {
+		_, details, err := EvaluateRule(`ALL item IN $.transacoes: item.valor > 60`, loopPayload())
+		require.NoError(t, err)
+		assert.Contains(t, details, "ALL item IN $.transacoes -> false (2 itens, não atendidos: [1])")
+		assert.Contains(t, details, "[1] path $.transacoes[1].valor = 50")
+	})
+
+	t.Run("trace por item", func(t *testing.T) {
+		root := NewTrace(TracePolicy, "teste")
+		_, _, err := EvaluateRuleWithTrace(`ALL item IN $.transacoes: item.valor > 0`, loopPayload(), root)
+		require.NoError(t, err)
+
+		ruleNode := root.Children[0]
+		var items []string
+		for _, child := range ruleNode.Children {
+			if child.Kind == TraceItem {
+				items = append(items, child.Expr)
+			}
+		}
+		assert.Equal(t, []string{"$.transacoes[0]", "$.transacoes[1]"}, items)
+	})
+
+	t.Run("limite de itens", func(t *testing.T) {
+		ctx := NewContext(loopPayload())
+		ctx.MaxIterations = 1
+		_, _, err := EvaluateRuleWithContext(`ALL item IN $.transacoes: item.valor > 0`, ctx, nil)
+		require.Error(t, err)
+		assert.Equal(t, "ALL: $.transacoes tem 2 itens, acima do limite de 1", err.Error())
+	})
+}
+
+func TestLoopErrors(t *testing.T) {
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "lista que não é array", rule: `FOREACH item IN $.moeda DO SET item.x = 1`, message: "FOREACH exige um array, mas $.moeda é string"},
+		{name: "erro em um item", rule: `FOREACH item IN $.transacoes DO SET item.taxa = EXP(item.moeda * 2)`, message: "item 0: "},
+		{name: "FOREACH sem DO", rule: `FOREACH item IN $.transacoes SET item.x = 1`, message: "regra FOREACH inválida"},
+		{name: "FOREACH com condição", rule: `FOREACH item IN $.transacoes DO item.valor > 0`, message: "FOREACH exige uma ação"},
+		{name: "ALL com ação", rule: `ALL item IN $.transacoes: SET item.x = 1`, message: "ALL exige uma condição, não uma ação"},
+		{name: "item com nome de palavra-chave", rule: `ANY in IN $.transacoes: in.valor > 0`, message: "nome de item inválido no ANY: 'in'"},
+		{name: "lista com curinga", rule: `ALL item IN $.pedidos[*].itens: item.qtd > 0`, message: "ALL exige o caminho de um array, sem [*]"},
+		{name: "remoção do próprio item", rule: `FOREACH item IN $.transacoes DO DELETE item`, message: "FOREACH não pode remover o próprio item: $.transacoes[0]"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRule(cenario.rule, loopPayload())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestBindItem(t *testing.T) {
+	all_cases := []struct {
+		body     string
+		expected string
+	}{
+		{body: `SET item.taxa = EXP(item.valor * 0.02)`, expected: `SET $.l[0].taxa = EXP($.l[0].valor * 0.02)`},
+		{body: `item[1] == item`, expected: `$.l[0][1] == $.l[0]`},
+		{body: `item.nome == "item" OR item.nome == 'item'`, expected: `$.l[0].nome == "item" OR $.l[0].nome == 'item'`},
+		{body: `$.item == @item OR $.pedido.item IN itens`, expected: `$.item == @item OR $.pedido.item IN itens`},
+		{body: `COALESCE(item.a, item_b) > 0`, expected: `COALESCE($.l[0].a, item_b) > 0`},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.body, func(t *testing.T) {
+			assert.Equal(t, cenario.expected, bindItem(cenario.body, "item", "$.l[0]"))
+		})
+	}
+}
+
+func TestIsActionRule(t *testing.T) {
+	all_cases := []struct {
+		rule     string
+		expected bool
+	}{
+		{rule: `SET $.x = 1`, expected: true},
+		{rule: `  IF $.x > 1 THEN SET $.y = 2`, expected: true},
+		{rule: `LET total = EXP($.a + $.b)`, expected: true},
+		{rule: `FOREACH item IN $.l DO DELETE item.x`, expected: true},
+		{rule: `ALL item IN $.l: item.x > 0`, expected: false},
+		{rule: `$.SET > 1`, expected: false},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.rule, func(t *testing.T) {
+			assert.Equal(t, cenario.expected, IsActionRule(cenario.rule))
+		})
+	}
+}
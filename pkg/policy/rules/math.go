@@ -3,12 +3,22 @@ package rules
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 )
 
 // evaluateMathExpression avalia uma expressão matemática simples (op1 operator op2).
-func evaluateMathExpression(expressionStr string, data map[string]interface{}) (float64, string, error) {
+// O nó de trace (opcional) recebe um filho com os operandos resolvidos e o resultado. O
+// resultado é float64 ou, no modo decimal, *big.Rat (ver decimalArithmetic).
+func evaluateMathExpression(expressionStr string, ctx *Context, trace *TraceNode) (interface{}, string, error) {
 	expressionStr = strings.TrimSpace(expressionStr)
+	node := trace.Child(TraceExpression, expressionStr)
+	result, details, err := calculateMathExpression(expressionStr, ctx, node)
+	node.Finish(result, err)
+	return result, details, err
+}
+
+func calculateMathExpression(expressionStr string, ctx *Context, node *TraceNode) (interface{}, string, error) {
 	var op1Str, op2Str, operator string
 
 	// Tenta encontrar operadores. A ordem pode importar se permitirmos expressões mais complexas no futuro.
@@ -49,22 +59,26 @@ func evaluateMathExpression(expressionStr string, data map[string]interface{}) (
 		}
 	}
 
+	if ctx.decimal() {
+		return calculateDecimalExpression(expressionStr, op1Str, operator, op2Str, ctx, node)
+	}
+
 	if !foundOperator {
 		// Pode ser um único operando (um número literal ou um caminho $)
-		num, err := parseOperand(expressionStr, data)
+		num, err := parseOperand(expressionStr, ctx, node)
 		if err != nil {
-			return 0, fmt.Sprintf("Expressão '%s' não é um número nem uma expressão válida: %v", expressionStr, err), err
+			return nil, fmt.Sprintf("Expressão '%s' não é um número nem uma expressão válida: %v", expressionStr, err), err
 		}
 		return num, fmt.Sprintf("%f", num), nil // Retorna o número como está
 	}
 
-	op1Num, err := parseOperand(op1Str, data)
+	op1Num, err := parseOperand(op1Str, ctx, node)
 	if err != nil {
-		return 0, fmt.Sprintf("Erro no operando esquerdo ('%s') da expressão '%s': %v", op1Str, expressionStr, err), err
+		return nil, fmt.Sprintf("Erro no operando esquerdo ('%s') da expressão '%s': %v", op1Str, expressionStr, err), err
 	}
-	op2Num, err := parseOperand(op2Str, data)
+	op2Num, err := parseOperand(op2Str, ctx, node)
 	if err != nil {
-		return 0, fmt.Sprintf("Erro no operando direito ('%s') da expressão '%s': %v", op2Str, expressionStr, err), err
+		return nil, fmt.Sprintf("Erro no operando direito ('%s') da expressão '%s': %v", op2Str, expressionStr, err), err
 	}
 
 	var result float64
@@ -78,12 +92,51 @@ func evaluateMathExpression(expressionStr string, data map[string]interface{}) (
 	case "/":
 		if op2Num == 0 {
 			err := errors.New("divisão por zero")
-			return 0, fmt.Sprintf("%.2f %s %.2f -> ERRO: %v", op1Num, operator, op2Num, err), err
+			return nil, fmt.Sprintf("%.2f %s %.2f -> ERRO: %v", op1Num, operator, op2Num, err), err
 		}
 		result = op1Num / op2Num
 	default:
 		err := fmt.Errorf("operador matemático desconhecido '%s' na expressão '%s'", operator, expressionStr)
-		return 0, err.Error(), err
+		return nil, err.Error(), err
 	}
 	return result, fmt.Sprintf("%.2f %s %.2f = %.2f", op1Num, operator, op2Num, result), nil
 }
+
+// calculateDecimalExpression avalia a expressão no modo decimal: operandos e resultado são
+// big.Rat (ver decimalArithmetic). Sem operador (op1Str vazio), retorna o único operando.
+func calculateDecimalExpression(expressionStr, op1Str, operator, op2Str string, ctx *Context, node *TraceNode) (interface{}, string, error) {
+	if op1Str == "" {
+		value, err := decimalOperand(expressionStr, ctx, node)
+		if err != nil {
+			return nil, fmt.Sprintf("Expressão '%s' não é um número nem uma expressão válida: %v", expressionStr, err), err
+		}
+		return value, fmt.Sprintf("%v (decimal)", plainValue(value)), nil
+	}
+
+	x, err := decimalOperand(op1Str, ctx, node)
+	if err != nil {
+		return nil, fmt.Sprintf("Erro no operando esquerdo ('%s') da expressão '%s': %v", op1Str, expressionStr, err), err
+	}
+	y, err := decimalOperand(op2Str, ctx, node)
+	if err != nil {
+		return nil, fmt.Sprintf("Erro no operando direito ('%s') da expressão '%s': %v", op2Str, expressionStr, err), err
+	}
+	result, err := decimalArithmetic(x, y, operator)
+	if err != nil {
+		return nil, fmt.Sprintf("%v %s %v -> ERRO: %v", plainValue(x), operator, plainValue(y), err), err
+	}
+	return result, fmt.Sprintf("%v %s %v = %v (decimal)", plainValue(x), operator, plainValue(y), plainValue(result)), nil
+}
+
+// decimalOperand resolve um operando de EXP como decimal (ver toDecimal).
+func decimalOperand(operandStr string, ctx *Context, node *TraceNode) (*big.Rat, error) {
+	value, err := numericOperand(operandStr, ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := toDecimal(value)
+	if !ok {
+		return nil, fmt.Errorf("operando '%s' (valor: %v) não é um decimal válido", operandStr, value)
+	}
+	return r, nil
+}
@@ -2,51 +2,53 @@ package rules
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"encoding/json"
 )
 
-// parseArrayPath analisa caminhos de array (ex.: teste[0].nome).
-func parseArrayPath(key string) (string, int, string, error) {
-    parts := strings.SplitN(key, "[", 2)
-    arrayKey := parts[0]
-    rest := strings.TrimSuffix(parts[1], "]")
-    if strings.Contains(rest, ".") {
-        subParts := strings.SplitN(rest, ".", 2)
-        index, err := strconv.Atoi(subParts[0])
-        if err != nil {
-            return "", 0, "", fmt.Errorf("invalid array index: %s", subParts[0])
-        }
-        return arrayKey, index, subParts[1], nil
-    }
-    index, err := strconv.Atoi(rest)
-    if err != nil {
-        return "", 0, "", fmt.Errorf("invalid array index: %s", rest)
-    }
-    return arrayKey, index, "", nil
+// parseOperand converte uma string de operando (literal, caminho $ ou chamada de função) em float64.
+func parseOperand(operandStr string, ctx *Context, node *TraceNode) (float64, error) {
+	value, err := numericOperand(operandStr, ctx, node)
+	if err != nil {
+		return 0, err
+	}
+	num, _ := convertToFloat64(value)
+	return num, nil
 }
 
-// parseOperand converte uma string de operando (literal ou caminho $) em float64.
-func parseOperand(operandStr string, data map[string]interface{}) (float64, error) {
+// numericOperand resolve um operando numérico de EXP (literal, caminho $ ou chamada de função)
+// sem convertê-lo: o valor lido (ex.: um big.Rat guardado por LET) ou, para literais, o texto.
+func numericOperand(operandStr string, ctx *Context, node *TraceNode) (interface{}, error) {
 	operandStr = strings.TrimSpace(operandStr)
-	if strings.HasPrefix(operandStr, "$.") {
-		val, err := getValue(data, operandStr)
+	if isPath(operandStr) {
+		val, err := lookupPath(ctx, operandStr, node)
 		if err != nil {
-			return 0, fmt.Errorf("falha ao obter valor do caminho do operando '%s': %v", operandStr, err)
+			return nil, fmt.Errorf("falha ao obter valor do caminho do operando '%s': %v", operandStr, err)
 		}
-		num, ok := convertToFloat64(val)
-		if !ok {
-			return 0, fmt.Errorf("operando do caminho '%s' (valor: %v, tipo: %T) não é um número válido", operandStr, val, val)
+		if _, ok := convertToFloat64(val); !ok {
+			return nil, fmt.Errorf("operando do caminho '%s' (valor: %v, tipo: %T) não é um número válido", operandStr, val, val)
 		}
-		return num, nil
+		return val, nil
+	}
+	if name, args, isCall := splitCall(operandStr); isCall {
+		val, err := evaluateCall(operandStr, name, args, ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := convertToFloat64(val); !ok {
+			return nil, fmt.Errorf("resultado de '%s' (valor: %v, tipo: %T) não é um número válido", operandStr, val, val)
+		}
+		return val, nil
 	}
 	// É um literal
 	num, ok := convertToFloat64(operandStr)
 	if !ok {
-		return 0, fmt.Errorf("operando literal '%s' não é um número válido", operandStr)
+		return nil, fmt.Errorf("operando literal '%s' não é um número válido", operandStr)
 	}
-	return num, nil
+	traceLiteral(node, operandStr, num)
+	return operandStr, nil
 }
 
 func convertToFloat64(val interface{}) (float64, bool) {
@@ -56,6 +58,9 @@ func convertToFloat64(val interface{}) (float64, bool) {
 	switch v := val.(type) {
 	case float64:
 		return v, true
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f, true
 	case float32:
 		return float64(v), true
 	case int:
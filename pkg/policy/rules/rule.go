@@ -1,9 +1,11 @@
 package rules
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Rule interface {
@@ -11,9 +13,17 @@ type Rule interface {
 	IfCondition(data map[string]interface{}) RuleExecutionResult
 	OrCondition(data map[string]interface{}) RuleExecutionResult
 	SetValue(data map[string]interface{}) RuleExecutionResult
+	AddValue(data map[string]interface{}) RuleExecutionResult
+	DeleteValue(data map[string]interface{}) RuleExecutionResult
+	LetValue(data map[string]interface{}) RuleExecutionResult
+	ForEach(data map[string]interface{}) RuleExecutionResult
 }
 
-type rule string
+type rule struct {
+	text  string
+	ctx   *Context   // Contexto da avaliação (nil: apenas os dados passados aos métodos)
+	trace *TraceNode // Nó de trace da avaliação (nil quando o explain está desativado)
+}
 
 type RuleExecutionResult struct {
 	Rule     string `json:"rule"`
@@ -24,20 +34,32 @@ type RuleExecutionResult struct {
 }
 
 func NewRule(raw_rule string) Rule {
-	trimmedRule := rule(strings.TrimSpace(raw_rule))
-	return &trimmedRule
+	return newContextRule(raw_rule, nil, nil)
+}
+
+func newContextRule(raw_rule string, ctx *Context, trace *TraceNode) *rule {
+	return &rule{text: strings.TrimSpace(raw_rule), ctx: ctx, trace: trace}
+}
+
+// scope retorna o contexto da avaliação, ou um contexto apenas com os dados recebidos.
+func (tr *rule) scope(data map[string]interface{}) *Context {
+	if tr.ctx != nil {
+		return tr.ctx
+	}
+	return NewContext(data)
 }
 
 func (tr *rule) String() string {
-	return string(*tr)
+	return tr.text
 }
 
 func (tr *rule) IfCondition(data map[string]interface{}) RuleExecutionResult {
-	return ifCondition(tr.String(), data)
+	return ifCondition(tr.String(), tr.scope(data), tr.trace)
 }
 
 func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 	trimmedRule := tr.String()
+	ctx := tr.scope(data)
 
 	if strings.HasPrefix(trimmedRule, "SET ") {
 		parts := strings.SplitN(strings.TrimPrefix(trimmedRule, "SET "), "=", 2)
@@ -55,7 +77,7 @@ func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 		var evalDetails string
 
 		if expr, isExpr := extractExpression(valueStr); isExpr {
-			calculatedValue, details, err := evaluateMathExpression(expr, data)
+			calculatedValue, details, err := evaluateMathExpression(expr, ctx, tr.trace)
 			evalDetails = fmt.Sprintf("EXP(%s)", details)
 			if err != nil {
 				return RuleExecutionResult{
@@ -66,8 +88,8 @@ func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 				}
 			}
 			valueToSet = calculatedValue
-		} else if strings.HasPrefix(valueStr, "$.") { // Atribuição direta de caminho
-			val, err := getValue(data, valueStr)
+		} else if isPath(valueStr) { // Atribuição direta de caminho
+			val, err := lookupPath(ctx, valueStr, tr.trace)
 			if err != nil {
 				return RuleExecutionResult{
 					Executed: true,
@@ -76,8 +98,26 @@ func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 					Err:      err,
 				}
 			}
+			if t, isTime := val.(time.Time); isTime {
+				val = t.Format(time.RFC3339) // Variáveis (@nome) podem guardar datas
+			}
 			valueToSet = val
 			evalDetails = fmt.Sprintf("path %s = %v", valueStr, val)
+		} else if name, args, isCall := splitCall(valueStr); isCall {
+			val, err := evaluateCall(valueStr, name, args, ctx, tr.trace)
+			if err != nil {
+				return RuleExecutionResult{
+					Executed: true,
+					Passed:   false,
+					Details:  fmt.Sprintf("Erro ao avaliar %s em SET para '%s': %v", valueStr, targetPath, err),
+					Err:      err,
+				}
+			}
+			if t, isTime := val.(time.Time); isTime {
+				val = t.Format(time.RFC3339) // Datas são gravadas como texto
+			}
+			valueToSet = val
+			evalDetails = fmt.Sprintf("%s = %v", valueStr, val)
 		} else { // Literal
 			if fVal, err := strconv.ParseFloat(valueStr, 64); err == nil {
 				valueToSet = fVal
@@ -92,9 +132,12 @@ func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 				}
 			}
 			evalDetails = fmt.Sprintf("literal = %v", valueToSet)
+			traceLiteral(tr.trace, valueStr, valueToSet)
 		}
 
-		err := setValue(data, targetPath, valueToSet)
+		valueToSet = plainValue(valueToSet) // Resultados decimais são gravados como float64
+		err := ctx.assign(targetPath, valueToSet)
+		tr.trace.Child(TraceSet, targetPath).Finish(valueToSet, err)
 		if err != nil {
 			return RuleExecutionResult{
 				Executed: true,
@@ -116,6 +159,156 @@ func (tr *rule) SetValue(data map[string]interface{}) RuleExecutionResult {
 	}
 }
 
+// ForEach executa os laços "FOREACH item IN $.lista DO <ação>", "ALL item IN $.lista: <condição>"
+// e "ANY item IN $.lista: <condição>" (ver forEach).
+func (tr *rule) ForEach(data map[string]interface{}) RuleExecutionResult {
+	return forEach(tr.String(), tr.scope(data), tr.trace)
+}
+
 func (tr *rule) OrCondition(data map[string]interface{}) RuleExecutionResult {
-	return orCondition(tr.String(), data)
+	return orCondition(tr.String(), tr.scope(data), tr.trace)
+}
+
+// AddValue executa regras "ADD <valor> TO $.path", acrescentando o valor ao array do caminho.
+// Se o valor for um array, seus elementos são acrescentados um a um; se o caminho não existir,
+// o array é criado.
+func (tr *rule) AddValue(data map[string]interface{}) RuleExecutionResult {
+	trimmedRule := tr.String()
+	ctx := tr.scope(data)
+	if !strings.HasPrefix(trimmedRule, "ADD ") {
+		return RuleExecutionResult{Executed: false}
+	}
+
+	// O caminho de destino é sempre o último trecho, então " TO " dentro do valor não interfere
+	idx := strings.LastIndex(trimmedRule, " TO ")
+	if idx == -1 {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Err:      fmt.Errorf("regra ADD inválida: %s", trimmedRule),
+		}
+	}
+	valueStr := strings.TrimSpace(strings.TrimPrefix(trimmedRule[:idx], "ADD "))
+	targetPath := strings.TrimSpace(trimmedRule[idx+len(" TO "):])
+
+	var item interface{}
+	if isPath(valueStr) {
+		val, err := lookupPath(ctx, valueStr, tr.trace)
+		if err != nil {
+			return RuleExecutionResult{
+				Executed: true,
+				Passed:   false,
+				Details:  fmt.Sprintf("Falha ao obter valor para ADD path '%s': %v", valueStr, err),
+				Err:      err,
+			}
+		}
+		item = plainValue(val)
+	} else if err := json.Unmarshal([]byte(valueStr), &item); err != nil {
+		item, _ = parseLiteral(valueStr)
+	}
+
+	current, err := ctx.resolve(targetPath)
+	if err != nil {
+		current = nil
+	}
+	var arr []interface{}
+	if current != nil {
+		var ok bool
+		if arr, ok = current.([]interface{}); !ok {
+			err := fmt.Errorf("path %s is not an array", targetPath)
+			return RuleExecutionResult{
+				Executed: true,
+				Passed:   false,
+				Details:  fmt.Sprintf("Falha ao ADD valor em '%s': %v", targetPath, err),
+				Err:      err,
+			}
+		}
+	}
+	if items, isArray := item.([]interface{}); isArray {
+		arr = append(arr, items...)
+	} else {
+		arr = append(arr, item)
+	}
+
+	err = ctx.assign(targetPath, arr)
+	tr.trace.Child(TraceAdd, targetPath).Finish(item, err)
+	if err != nil {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Details:  fmt.Sprintf("Falha ao ADD valor em '%s': %v", targetPath, err),
+			Err:      err,
+		}
+	}
+	return RuleExecutionResult{
+		Executed: true,
+		Passed:   true,
+		Details:  fmt.Sprintf("ADD %v TO %s (tamanho: %d)", item, targetPath, len(arr)),
+	}
+}
+
+// DeleteValue executa regras "DELETE $.path", removendo a chave ou elemento de array.
+// Remover um caminho inexistente não é considerado erro.
+func (tr *rule) DeleteValue(data map[string]interface{}) RuleExecutionResult {
+	trimmedRule := tr.String()
+	if !strings.HasPrefix(trimmedRule, "DELETE ") {
+		return RuleExecutionResult{Executed: false}
+	}
+
+	targetPath := strings.TrimSpace(strings.TrimPrefix(trimmedRule, "DELETE "))
+	removed, err := tr.scope(data).remove(targetPath)
+	tr.trace.Child(TraceDelete, targetPath).Finish(removed, err)
+	if err != nil {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Details:  fmt.Sprintf("Falha ao DELETE path '%s': %v", targetPath, err),
+			Err:      err,
+		}
+	}
+	return RuleExecutionResult{
+		Executed: true,
+		Passed:   true,
+		Details:  fmt.Sprintf("DELETE %s (removido: %t)", targetPath, removed),
+	}
+}
+
+// LetValue executa regras "LET nome = <valor>", guardando o valor como variável da avaliação,
+// lida depois como "@nome". Variáveis não alteram os dados e, portanto, não chegam à resposta.
+func (tr *rule) LetValue(data map[string]interface{}) RuleExecutionResult {
+	trimmedRule := tr.String()
+	if !strings.HasPrefix(trimmedRule, "LET ") {
+		return RuleExecutionResult{Executed: false}
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(trimmedRule, "LET "), "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Err:      fmt.Errorf("regra LET inválida: %s", trimmedRule),
+		}
+	}
+	name := strings.TrimSpace(parts[0])
+	valueStr := strings.TrimSpace(parts[1])
+	ctx := tr.scope(data)
+
+	value, details, err := evaluateOperand(valueStr, ctx, tr.trace)
+	if err == nil {
+		err = ctx.define(name, value)
+	}
+	tr.trace.Child(TraceLet, RootVariables+name).Finish(value, err)
+	if err != nil {
+		return RuleExecutionResult{
+			Executed: true,
+			Passed:   false,
+			Details:  fmt.Sprintf("Falha ao definir a variável @%s: %v. Detalhes da avaliação: %s", name, err, details),
+			Err:      err,
+		}
+	}
+	return RuleExecutionResult{
+		Executed: true,
+		Passed:   true,
+		Details:  fmt.Sprintf("LET @%s = %v (Detalhes: %s)", name, plainValue(value), details),
+	}
 }
@@ -55,3 +55,37 @@ func TestPolicySetCondition(t *testing.T) {
 		}
 	})
 }
+
+func TestPolicyAddAndDeleteValue(t *testing.T) {
+	payload := map[string]interface{}{
+		"tags":     []interface{}{"a"},
+		"endereco": map[string]interface{}{"cep": "01234-567", "estado": "SP"},
+	}
+
+	all_rules := []struct {
+		name     string
+		rule     string
+		passed   bool
+		path     string
+		expected interface{}
+	}{
+		{name: "add literal", rule: `ADD "b" TO $.tags`, passed: true, path: "$.tags", expected: []interface{}{"a", "b"}},
+		{name: "add array", rule: `ADD ["c", "d"] TO $.tags`, passed: true, path: "$.tags", expected: []interface{}{"a", "b", "c", "d"}},
+		{name: "add cria array", rule: `ADD {"id": 1} TO $.itens`, passed: true, path: "$.itens", expected: []interface{}{map[string]interface{}{"id": 1.0}}},
+		{name: "add em não-array", rule: `ADD 1 TO $.endereco.estado`, passed: false, path: "$.endereco.estado", expected: "SP"},
+		{name: "delete chave", rule: `DELETE $.endereco.cep`, passed: true, path: "$.endereco.cep", expected: nil},
+		{name: "delete item", rule: `DELETE $.tags[0]`, passed: true, path: "$.tags", expected: []interface{}{"b", "c", "d"}},
+		{name: "delete ausente", rule: `DELETE $.naoExiste`, passed: true, path: "$.naoExiste", expected: nil},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_rules {
+			passed, _, _ := EvaluateRule(cenario.rule, payload)
+			actual, err := getValue(payload, cenario.path)
+
+			assert.NoError(t, err, cenario.name)
+			assert.Equal(t, cenario.passed, passed, cenario.name)
+			assert.Equal(t, cenario.expected, actual, cenario.name)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+func upperFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	return strings.ToUpper(s), err
+}
+
+func lowerFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	return strings.ToLower(s), err
+}
+
+func trimFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	return strings.TrimSpace(s), err
+}
+
+// lenFunc retorna o número de caracteres de um texto ou de itens de um array ou objeto.
+func lenFunc(_ *Context, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	s, err := textValue(args[0])
+	return float64(utf8.RuneCountInString(s)), err
+}
+
+// substrFunc implementa SUBSTR(texto, inicio[, tamanho]), com posições em caracteres a
+// partir de 0. Trechos além do fim do texto são ignorados.
+func substrFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := intValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+	end := len(runes)
+	if len(args) == 3 {
+		length, err := intValue(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, fmt.Errorf("tamanho negativo: %d", length)
+		}
+		end = start + length
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("início negativo: %d", start)
+	}
+	if start > len(runes) {
+		start = len(runes)
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end]), nil
+}
+
+func concatFunc(_ *Context, args []interface{}) (interface{}, error) {
+	var sb strings.Builder
+	for _, arg := range args {
+		s, err := textValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(s)
+	}
+	return sb.String(), nil
+}
+
+func replaceFunc(_ *Context, args []interface{}) (interface{}, error) {
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		s, err := textValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		texts[i] = s
+	}
+	return strings.ReplaceAll(texts[0], texts[1], texts[2]), nil
+}
+
+func splitFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := textValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, sep)
+	items := make([]interface{}, len(parts))
+	for i, part := range parts {
+		items[i] = part
+	}
+	return items, nil
+}
+
+func joinFunc(_ *Context, args []interface{}) (interface{}, error) {
+	items, ok := args[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argumento %v (%T) não é um array", args[0], args[0])
+	}
+	sep, err := textValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		if parts[i], err = textValue(item); err != nil {
+			return nil, err
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// MaxPadLength é o maior tamanho aceito por PAD, para que uma regra não aloque textos arbitrariamente
+// grandes.
+const MaxPadLength = 10000
+
+// padFunc implementa PAD(texto, tamanho[, caractere[, lado]]): completa o texto até o tamanho
+// com o caractere (espaço por padrão) à esquerda ("LEFT", padrão) ou à direita ("RIGHT"). O
+// tamanho é limitado por MaxPadLength.
+func padFunc(_ *Context, args []interface{}) (interface{}, error) {
+	s, err := textValue(args[0])
+	if err != nil {
+		return nil, err
+	}
+	size, err := intValue(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if size > MaxPadLength {
+		return nil, fmt.Errorf("tamanho %d acima do limite de %d", size, MaxPadLength)
+	}
+	fill, side := " ", "LEFT"
+	if len(args) > 2 {
+		if fill, err = textValue(args[2]); err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(fill) != 1 {
+			return nil, fmt.Errorf("caractere de preenchimento deve ter um caractere: '%s'", fill)
+		}
+	}
+	if len(args) > 3 {
+		if side, err = textValue(args[3]); err != nil {
+			return nil, err
+		}
+		side = strings.ToUpper(side)
+		if side != "LEFT" && side != "RIGHT" {
+			return nil, fmt.Errorf("lado deve ser LEFT ou RIGHT: '%s'", side)
+		}
+	}
+
+	missing := size - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s, nil
+	}
+	if side == "RIGHT" {
+		return s + strings.Repeat(fill, missing), nil
+	}
+	return strings.Repeat(fill, missing) + s, nil
+}
+
+// containsValue implementa CONTAINS: trecho de texto ou item de array. Outros tipos
+// (incluindo null) não contêm nada.
+func containsValue(lhs, rhs interface{}) bool {
+	switch v := lhs.(type) {
+	case string:
+		sub, err := textValue(rhs)
+		return err == nil && strings.Contains(v, sub)
+	case []interface{}:
+		for _, item := range v {
+			if compareEquals(item, rhs) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// affixMatches implementa STARTS WITH e ENDS WITH; operandos que não são texto resultam em false.
+func affixMatches(op string, lhs, rhs interface{}) bool {
+	s, ok := lhs.(string)
+	if !ok {
+		return false
+	}
+	affix, err := textValue(rhs)
+	if err != nil {
+		return false
+	}
+	if op == "STARTS WITH" {
+		return strings.HasPrefix(s, affix)
+	}
+	return strings.HasSuffix(s, affix)
+}
+
+// matchPattern implementa MATCHES. O padrão é compilado uma vez e reaproveitado.
+func matchPattern(lhs, rhs interface{}) (bool, error) {
+	pattern, err := textValue(rhs)
+	if err != nil {
+		return false, fmt.Errorf("padrão de MATCHES inválido: %v", err)
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	s, ok := lhs.(string)
+	return ok && re.MatchString(s), nil
+}
+
+// maxCachedPatterns limita o cache de expressões regulares, já que o padrão pode vir dos dados.
+const maxCachedPatterns = 1000
+
+var patternCache = struct {
+	sync.RWMutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// compilePattern compila a expressão regular, usando o cache quando possível.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternCache.RLock()
+	re, cached := patternCache.patterns[pattern]
+	patternCache.RUnlock()
+	if cached {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("expressão regular inválida '%s': %v", pattern, err)
+	}
+	patternCache.Lock()
+	if len(patternCache.patterns) < maxCachedPatterns {
+		patternCache.patterns[pattern] = re
+	}
+	patternCache.Unlock()
+	return re, nil
+}
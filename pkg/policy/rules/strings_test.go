@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"nome":   "  Maria Silva ",
+		"email":  "maria@exemplo.com.br",
+		"codigo": 7.0,
+		"tags":   []interface{}{"vip", "pj"},
+		"cep":    "01234-567",
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "UPPER e TRIM", rule: `UPPER(TRIM($.nome)) == "MARIA SILVA"`, expected: true},
+		{name: "LOWER", rule: `LOWER("ABC") == "abc"`, expected: true},
+		{name: "LEN de texto", rule: `LEN(TRIM($.nome)) == 11`, expected: true},
+		{name: "LEN de array", rule: `LEN($.tags) >= 2`, expected: true},
+		{name: "SUBSTR", rule: `SUBSTR($.cep, 0, 5) == "01234"`, expected: true},
+		{name: "SUBSTR até o fim", rule: `SUBSTR($.cep, 6) == "567"`, expected: true},
+		{name: "SUBSTR além do fim", rule: `SUBSTR($.cep, 20, 3) == ""`, expected: true},
+		{name: "CONCAT com número", rule: `CONCAT("cod-", $.codigo) == "cod-7"`, expected: true},
+		{name: "REPLACE", rule: `REPLACE($.cep, "-", "") == "01234567"`, expected: true},
+		{name: "SPLIT e JOIN", rule: `JOIN(SPLIT($.email, "@"), "|") == "maria|exemplo.com.br"`, expected: true},
+		{name: "PAD à esquerda", rule: `PAD($.codigo, 3, "0") == "007"`, expected: true},
+		{name: "PAD à direita", rule: `PAD("ab", 4, ".", "right") == "ab.."`, expected: true},
+		{name: "função em EXP", rule: `EXP(LEN($.tags) * 10) == 20`, expected: true},
+		{name: "função com IN", rule: `LOWER("SP") IN ["sp", "rj"]`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, stringPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+}
+
+func TestStringFunctionErrors(t *testing.T) {
+	all_cases := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "função desconhecida", rule: `COUNT($.tags) > 1`, message: "função não implementada: COUNT"},
+		{name: "aridade", rule: `UPPER($.nome, 1) == "A"`, message: "função UPPER espera 1 argumento(s), recebeu 2"},
+		{name: "argumento nulo", rule: `UPPER($.ausente) == "A"`, message: "UPPER: argumento nulo"},
+		{name: "JOIN sem array", rule: `JOIN($.nome, ",") == "a"`, message: "não é um array"},
+		{name: "posição não inteira", rule: `SUBSTR($.nome, 1.5) == "a"`, message: "não é um número inteiro"},
+		{name: "PAD acima do limite", rule: `PAD("a", 1000000000, "0") == "a"`, message: "tamanho 1000000000 acima do limite de 10000"},
+		{name: "PAD com lado inválido", rule: `PAD("a", 3, "0", "meio") == "a"`, message: "lado deve ser LEFT ou RIGHT"},
+		{name: "expressão regular inválida", rule: `$.nome MATCHES "(a"`, message: "expressão regular inválida"},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, _, err := EvaluateRule(cenario.rule, stringPayload())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestStringOperators(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "CONTAINS em texto", rule: `$.email CONTAINS "@exemplo"`, expected: true},
+		{name: "CONTAINS em array", rule: `$.tags CONTAINS "vip"`, expected: true},
+		{name: "CONTAINS ausente", rule: `$.tags CONTAINS "pf"`, expected: false},
+		{name: "CONTAINS em null", rule: `$.ausente CONTAINS "a"`, expected: false},
+		{name: "STARTS WITH", rule: `$.cep STARTS WITH "012"`, expected: true},
+		{name: "ENDS WITH", rule: `$.email ENDS WITH ".com"`, expected: false},
+		{name: "ENDS WITH com função", rule: `LOWER($.email) ENDS WITH ".br"`, expected: true},
+		{name: "MATCHES", rule: `$.cep MATCHES "^[0-9]{5}-[0-9]{3}$"`, expected: true},
+		{name: "MATCHES sem correspondência", rule: `$.email MATCHES "^[0-9]+$"`, expected: false},
+		{name: "MATCHES com número", rule: `$.codigo MATCHES "7"`, expected: false},
+		{name: "operador entre aspas", rule: `$.nome CONTAINS " IN "`, expected: false},
+		{name: "OR com operadores de texto", rule: `$.cep STARTS WITH "9" OR $.tags CONTAINS "pj"`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			actual, details, err := EvaluateRule(cenario.rule, stringPayload())
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	t.Run("padrão compilado uma vez", func(t *testing.T) {
+		first, err := compilePattern(`^\d+$`)
+		require.NoError(t, err)
+		second, err := compilePattern(`^\d+$`)
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+	})
+}
+
+func TestSetWithStringFunctions(t *testing.T) {
+	data := stringPayload()
+
+	passed, details, err := EvaluateRule(`SET $.nomeNormalizado = UPPER(TRIM($.nome))`, data)
+	require.NoError(t, err, details)
+	assert.True(t, passed)
+	assert.Equal(t, "MARIA SILVA", data["nomeNormalizado"])
+
+	passed, _, err = EvaluateRule(`SET $.partes = SPLIT($.cep, "-")`, data)
+	require.NoError(t, err)
+	assert.True(t, passed)
+	assert.Equal(t, []interface{}{"01234", "567"}, data["partes"])
+
+	passed, _, err = EvaluateRule(`SET $.x = SUBSTR($.nome)`, data)
+	assert.Error(t, err)
+	assert.False(t, passed)
+}
@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Table é uma tabela de referência consultada nas regras por TABLE e LOOKUP. Cada linha é
+// identificada por uma chave; chaves são comparadas pelo texto (ex.: 1 e "1" são a mesma chave).
+type Table struct {
+	Name string
+	keys []interface{}
+	rows map[string]interface{} // Texto da chave -> linha (objeto ou valor simples)
+}
+
+// NewTable cria uma tabela a partir de dados já decodificados:
+//   - objeto: cada chave é uma linha e o valor, a linha (objeto com colunas ou valor simples);
+//   - lista de valores simples: cada valor é uma chave (ex.: estados permitidos);
+//   - lista de objetos: cada objeto é uma linha, identificada pela coluna 'key'.
+//
+// As chaves de um objeto ficam em ordem alfabética; as de uma lista, na ordem da lista.
+func NewTable(name, key string, data interface{}) (*Table, error) {
+	t := &Table{Name: name, rows: make(map[string]interface{})}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := t.add(k, v[k]); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if key == "" {
+				if isArray(item) || isObject(item) || item == nil {
+					return nil, fmt.Errorf("tabela '%s': item %d não é um valor simples; informe a coluna chave para listas de objetos", name, i)
+				}
+				if err := t.add(item, item); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tabela '%s': item %d não é um objeto", name, i)
+			}
+			value, exists := row[key]
+			if !exists || value == nil || isArray(value) || isObject(value) {
+				return nil, fmt.Errorf("tabela '%s': item %d sem valor simples na coluna chave '%s'", name, i, key)
+			}
+			if err := t.add(value, row); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("tabela '%s' deve ser um objeto ou uma lista, recebeu %T", name, data)
+	}
+	return t, nil
+}
+
+func (t *Table) add(key, row interface{}) error {
+	text, err := textValue(key)
+	if err != nil {
+		return fmt.Errorf("tabela '%s': chave inválida: %v", t.Name, err)
+	}
+	if _, exists := t.rows[text]; exists {
+		return fmt.Errorf("tabela '%s': chave '%s' repetida", t.Name, text)
+	}
+	t.keys = append(t.keys, key)
+	t.rows[text] = row
+	return nil
+}
+
+// Keys retorna as chaves da tabela, na ordem descrita em NewTable.
+func (t *Table) Keys() []interface{} {
+	return append([]interface{}{}, t.keys...)
+}
+
+// Row retorna a linha identificada pela chave.
+func (t *Table) Row(key interface{}) (interface{}, bool) {
+	text, err := textValue(key)
+	if err != nil {
+		return nil, false
+	}
+	row, exists := t.rows[text]
+	return row, exists
+}
+
+// TableSet guarda as tabelas de referência disponíveis para as regras. É seguro para uso
+// concorrente: as tabelas podem ser recarregadas enquanto as regras são avaliadas.
+type TableSet struct {
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+// NewTableSet cria um conjunto com as tabelas informadas.
+func NewTableSet(tables ...*Table) *TableSet {
+	s := &TableSet{tables: make(map[string]*Table, len(tables))}
+	s.Store(tables...)
+	return s
+}
+
+// Store acrescenta as tabelas ao conjunto, substituindo de uma só vez as de mesmo nome.
+func (s *TableSet) Store(tables ...*Table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range tables {
+		s.tables[t.Name] = t
+	}
+}
+
+// Get retorna a tabela pelo nome.
+func (s *TableSet) Get(name string) (*Table, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, exists := s.tables[name]
+	return t, exists
+}
+
+// Names retorna os nomes das tabelas em ordem alfabética.
+func (s *TableSet) Names() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// table retorna a tabela de referência pelo nome.
+func (c *Context) table(name interface{}) (*Table, error) {
+	text, ok := name.(string)
+	if !ok {
+		return nil, fmt.Errorf("nome de tabela deve ser texto, recebeu %v (%T)", name, name)
+	}
+	var tables *TableSet
+	if c != nil {
+		tables = c.Tables
+	}
+	t, exists := tables.Get(text)
+	if !exists {
+		return nil, fmt.Errorf("tabela '%s' não carregada", text)
+	}
+	return t, nil
+}
+
+// tableFunc implementa TABLE(nome): as chaves da tabela, para uso com IN e NOT IN.
+func tableFunc(ctx *Context, args []interface{}) (interface{}, error) {
+	t, err := ctx.table(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t.Keys(), nil
+}
+
+// lookupFunc implementa LOOKUP(tabela, chave[, coluna]): a linha da chave ou, com a coluna,
+// o valor da coluna nessa linha. Uma chave ausente (ou null) resulta em null.
+func lookupFunc(ctx *Context, args []interface{}) (interface{}, error) {
+	t, err := ctx.table(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if args[1] == nil {
+		return nil, nil
+	}
+	row, exists := t.Row(args[1])
+	if !exists || len(args) < 3 {
+		return row, nil
+	}
+	column, ok := args[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("nome de coluna deve ser texto, recebeu %v (%T)", args[2], args[2])
+	}
+	values, ok := row.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("tabela '%s' não tem colunas", t.Name)
+	}
+	value, exists := values[column]
+	if !exists {
+		return nil, fmt.Errorf("coluna '%s' não existe na tabela '%s'", column, t.Name)
+	}
+	return value, nil
+}
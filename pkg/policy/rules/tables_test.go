@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTables(t *testing.T) *TableSet {
+	aliquotas, err := NewTable("aliquotas", "tipo", []interface{}{
+		map[string]interface{}{"tipo": "servico", "pis": 0.0165, "cofins": 0.076},
+		map[string]interface{}{"tipo": "produto", "pis": 0.0065, "cofins": 0.03},
+	})
+	require.NoError(t, err)
+	estados, err := NewTable("estados_permitidos", "", []interface{}{"SP", "RJ", "MG", "RS"})
+	require.NoError(t, err)
+	faixas, err := NewTable("faixas", "", map[string]interface{}{"1": "bronze", "2": "prata"})
+	require.NoError(t, err)
+	return NewTableSet(aliquotas, estados, faixas)
+}
+
+func tablePayload() map[string]interface{} {
+	return map[string]interface{}{
+		"tipo":     "servico",
+		"valor":    1000.0,
+		"nivel":    2.0,
+		"endereco": map[string]interface{}{"estado": "SP"},
+		"origem":   "BA",
+		"vazio":    nil,
+	}
+}
+
+func TestTables(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rule     string
+		expected bool
+	}{
+		{name: "IN TABLE", rule: `$.endereco.estado IN TABLE('estados_permitidos')`, expected: true},
+		{name: "NOT IN TABLE", rule: `$.origem NOT IN TABLE("estados_permitidos")`, expected: true},
+		{name: "LOOKUP com coluna", rule: `LOOKUP('aliquotas', $.tipo, 'pis') == 0.0165`, expected: true},
+		{name: "LOOKUP em EXP", rule: `EXP($.valor * LOOKUP('aliquotas', $.tipo, 'cofins')) == 76`, expected: true},
+		{name: "LOOKUP sem coluna", rule: `LOOKUP('faixas', $.nivel) == "prata"`, expected: true},
+		{name: "chave ausente", rule: `LOOKUP('aliquotas', $.origem, 'pis') == null`, expected: true},
+		{name: "chave null", rule: `DEFAULT(LOOKUP('aliquotas', $.vazio, 'pis'), 0) == 0`, expected: true},
+		{name: "LEN de TABLE", rule: `LEN(TABLE('aliquotas')) == 2`, expected: true},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := NewContext(tablePayload())
+			ctx.Tables = testTables(t)
+			actual, details, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.NoError(t, err, details)
+			assert.Equal(t, cenario.expected, actual, details)
+		})
+	}
+
+	all_errors := []struct {
+		name    string
+		rule    string
+		message string
+	}{
+		{name: "tabela não carregada", rule: `$.tipo IN TABLE('cfop')`, message: "TABLE: tabela 'cfop' não carregada"},
+		{name: "coluna inexistente", rule: `LOOKUP('aliquotas', $.tipo, 'icms') > 0`, message: "LOOKUP: coluna 'icms' não existe na tabela 'aliquotas'"},
+		{name: "coluna em tabela sem colunas", rule: `LOOKUP('faixas', 1, 'nome') == "a"`, message: "LOOKUP: tabela 'faixas' não tem colunas"},
+		{name: "nome que não é texto", rule: `LOOKUP($.valor, $.tipo) == 1`, message: "LOOKUP: nome de tabela deve ser texto, recebeu 1000 (float64)"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			ctx := NewContext(tablePayload())
+			ctx.Tables = testTables(t)
+			_, _, err := EvaluateRuleWithContext(cenario.rule, ctx, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+
+	t.Run("tabela recarregada", func(t *testing.T) {
+		tables := testTables(t)
+		ctx := NewContext(tablePayload())
+		ctx.Tables = tables
+
+		estados, err := NewTable("estados_permitidos", "", []interface{}{"BA"})
+		require.NoError(t, err)
+		tables.Store(estados)
+		actual, _, err := EvaluateRuleWithContext(`$.origem IN TABLE('estados_permitidos')`, ctx, nil)
+		require.NoError(t, err)
+		assert.True(t, actual)
+		assert.Equal(t, []string{"aliquotas", "estados_permitidos", "faixas"}, tables.Names())
+	})
+}
+
+func TestNewTableErrors(t *testing.T) {
+	all_errors := []struct {
+		name    string
+		key     string
+		data    interface{}
+		message string
+	}{
+		{name: "lista de objetos sem chave", data: []interface{}{map[string]interface{}{"uf": "SP"}}, message: "tabela 't': item 0 não é um valor simples"},
+		{name: "item sem a coluna chave", key: "uf", data: []interface{}{map[string]interface{}{"nome": "São Paulo"}}, message: "tabela 't': item 0 sem valor simples na coluna chave 'uf'"},
+		{name: "item que não é objeto", key: "uf", data: []interface{}{"SP"}, message: "tabela 't': item 0 não é um objeto"},
+		{name: "chave repetida", data: []interface{}{"SP", "SP"}, message: "tabela 't': chave 'SP' repetida"},
+		{name: "chave repetida como número", data: []interface{}{1.0, "1"}, message: "tabela 't': chave '1' repetida"},
+		{name: "valor simples", data: "SP", message: "tabela 't' deve ser um objeto ou uma lista, recebeu string"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, err := NewTable("t", cenario.key, cenario.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tipos de nó do trace de avaliação
+const (
+	TracePolicy     = "policy"
+	TraceRule       = "rule"
+	TraceOrLeft     = "or.left"
+	TraceOrRight    = "or.right"
+	TraceIfCond     = "if.condition"
+	TraceIfThen     = "if.then"
+	TraceSet        = "set"
+	TraceAdd        = "add"
+	TraceDelete     = "delete"
+	TraceLet        = "let"
+	TraceItem       = "item" // Um item de FOREACH, ALL ou ANY
+	TracePath       = "path"
+	TraceExpression = "expression"
+	TraceCall       = "call"
+	TraceLiteral    = "literal"
+)
+
+// TraceNode é um nó da árvore de avaliação de uma regra (explain).
+// Todos os métodos aceitam receptor nil, então o código de avaliação pode
+// registrar o trace incondicionalmente: sem trace ativo nada é alocado.
+type TraceNode struct {
+	Kind     string        `json:"kind"`
+	Expr     string        `json:"expr"`
+	Value    interface{}   `json:"value,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"` // Ramo não avaliado por curto-circuito
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"durationNs"`
+	Children []*TraceNode  `json:"children,omitempty"`
+
+	start time.Time
+}
+
+// NewTrace cria um nó raiz de trace.
+func NewTrace(kind, expr string) *TraceNode {
+	return &TraceNode{Kind: kind, Expr: expr, start: time.Now()}
+}
+
+// Child cria e anexa um nó filho. Retorna nil se o nó atual for nil.
+func (n *TraceNode) Child(kind, expr string) *TraceNode {
+	if n == nil {
+		return nil
+	}
+	child := NewTrace(kind, expr)
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Skip anexa um nó filho marcado como não avaliado.
+func (n *TraceNode) Skip(kind, expr string) {
+	if child := n.Child(kind, expr); child != nil {
+		child.Skipped = true
+	}
+}
+
+// Finish registra o valor resolvido, o erro (se houver) e o tempo gasto no nó.
+func (n *TraceNode) Finish(value interface{}, err error) {
+	if n == nil {
+		return
+	}
+	n.Value = plainValue(value)
+	if err != nil {
+		n.Error = err.Error()
+	}
+	n.Duration = time.Since(n.start)
+}
+
+// String renderiza o trace como uma árvore legível.
+func (n *TraceNode) String() string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	n.render(&sb, "", "")
+	return sb.String()
+}
+
+func (n *TraceNode) render(sb *strings.Builder, prefix, childPrefix string) {
+	sb.WriteString(prefix)
+	sb.WriteString(fmt.Sprintf("[%s] %s", n.Kind, n.Expr))
+	switch {
+	case n.Skipped:
+		sb.WriteString(" (não avaliado)")
+	case n.Error != "":
+		sb.WriteString(fmt.Sprintf(" -> ERRO: %s", n.Error))
+	default:
+		sb.WriteString(fmt.Sprintf(" -> %v", n.Value))
+	}
+	if !n.Skipped {
+		sb.WriteString(fmt.Sprintf(" (%s)", n.Duration))
+	}
+	sb.WriteString("\n")
+
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			child.render(sb, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.render(sb, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// lookupPath resolve um caminho $ registrando a consulta no trace.
+func lookupPath(ctx *Context, path string, node *TraceNode) (interface{}, error) {
+	child := node.Child(TracePath, path)
+	value, err := ctx.resolve(path)
+	child.Finish(value, err)
+	return value, err
+}
+
+// traceLiteral registra no trace um operando literal já resolvido.
+func traceLiteral(node *TraceNode, expr string, value interface{}) {
+	node.Child(TraceLiteral, expr).Finish(value, nil)
+}
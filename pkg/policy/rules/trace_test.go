@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateRuleWithTrace(t *testing.T) {
+	t.Run("or com curto-circuito", func(t *testing.T) {
+		root := NewTrace(TracePolicy, "teste")
+		passed, _, err := EvaluateRuleWithTrace(`$.moeda == "BRL" OR $.moeda == "USD"`, conditionPayload, root)
+
+		assert.NoError(t, err)
+		assert.True(t, passed)
+		assert.Len(t, root.Children, 1)
+
+		ruleNode := root.Children[0]
+		assert.Equal(t, TraceRule, ruleNode.Kind)
+		assert.Equal(t, true, ruleNode.Value)
+		assert.Len(t, ruleNode.Children, 2)
+		assert.Equal(t, TraceOrLeft, ruleNode.Children[0].Kind)
+		assert.Equal(t, TraceOrRight, ruleNode.Children[1].Kind)
+		assert.True(t, ruleNode.Children[1].Skipped)
+
+		path := ruleNode.Children[0].Children[0]
+		assert.Equal(t, TracePath, path.Kind)
+		assert.Equal(t, "$.moeda", path.Expr)
+		assert.Equal(t, "BRL", path.Value)
+	})
+
+	t.Run("if com expressão", func(t *testing.T) {
+		payload := map[string]interface{}{"valor": 200.0, "cliente": map[string]interface{}{"tipo": "premium"}}
+		root := NewTrace(TracePolicy, "teste")
+		_, _, err := EvaluateRuleWithTrace(`IF $.cliente.tipo == "premium" THEN SET $.desconto = EXP($.valor * 0.15)`, payload, root)
+
+		assert.NoError(t, err)
+		ruleNode := root.Children[0]
+		assert.Equal(t, TraceIfCond, ruleNode.Children[0].Kind)
+		assert.Equal(t, true, ruleNode.Children[0].Value)
+
+		then := ruleNode.Children[1]
+		assert.Equal(t, TraceIfThen, then.Kind)
+		assert.Equal(t, TraceExpression, then.Children[0].Kind)
+		assert.Equal(t, 30.0, then.Children[0].Value)
+		assert.Equal(t, TraceSet, then.Children[1].Kind)
+		assert.Contains(t, root.String(), "[set] $.desconto -> 30")
+	})
+
+	t.Run("sem trace", func(t *testing.T) {
+		var root *TraceNode
+		passed, _, err := EvaluateRuleWithTrace(`$.idade >= 18`, conditionPayload, root)
+
+		assert.NoError(t, err)
+		assert.True(t, passed)
+		assert.Equal(t, "", root.String())
+	})
+}
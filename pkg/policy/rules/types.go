@@ -12,8 +12,3 @@ type Policy struct {
     Name  string   `yaml:"name"`
     Rules []string `yaml:"rules"`
 }
-
-type Context struct {
-    Data map[string]interface{}
-    Vars map[string]interface{}
-}
\ No newline at end of file
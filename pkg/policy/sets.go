@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultSet é o conjunto aplicado quando a requisição não informa políticas.
+	DefaultSet = "default"
+
+	// TagSelectorPrefix identifica seletores por tag (ex.: "tag:pricing").
+	TagSelectorPrefix = "tag:"
+)
+
+// ValidateSets verifica se os conjuntos não colidem com nomes de políticas e se todos
+// os seus seletores resolvem para políticas existentes.
+func ValidateSets(policies map[string]PolicyDefinition, sets map[string][]string) error {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		if _, exists := policies[name]; exists {
+			return fmt.Errorf("conjunto '%s' tem o mesmo nome de uma política", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		selected, err := ExpandSelectors(policies, sets, []string{name})
+		if err != nil {
+			return fmt.Errorf("conjunto '%s': %v", name, err)
+		}
+		for _, policyName := range selected {
+			if _, exists := policies[policyName]; !exists {
+				return fmt.Errorf("conjunto '%s' referencia política ou conjunto não definido '%s'", name, policyName)
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandSelectors converte seletores em nomes de políticas, sem repetições e na ordem em que aparecem.
+// Um seletor pode ser o nome de uma política, o nome de um conjunto (expandido recursivamente)
+// ou "tag:<nome>" (todas as políticas com a tag, em ordem alfabética). Uma tag que não
+// seleciona nenhuma política é um erro, para que uma tag digitada errado não execute zero
+// políticas e aprove a requisição. Nomes desconhecidos são mantidos, para que a execução
+// reporte a política ausente.
+func ExpandSelectors(policies map[string]PolicyDefinition, sets map[string][]string, selectors []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			expanded = append(expanded, name)
+		}
+	}
+
+	var expand func(selector string, stack []string) error
+	expand = func(selector string, stack []string) error {
+		selector = strings.TrimSpace(selector)
+		if tag, isTag := strings.CutPrefix(selector, TagSelectorPrefix); isTag {
+			tagged := PoliciesWithTag(policies, tag)
+			if len(tagged) == 0 {
+				return fmt.Errorf("nenhuma política com a tag '%s'", tag)
+			}
+			for _, name := range tagged {
+				add(name)
+			}
+			return nil
+		}
+
+		members, isSet := sets[selector]
+		if !isSet {
+			add(selector)
+			return nil
+		}
+		for _, s := range stack {
+			if s == selector {
+				return fmt.Errorf("referência circular entre conjuntos de políticas: %s", strings.Join(append(stack, selector), " -> "))
+			}
+		}
+		for _, member := range members {
+			if err := expand(member, append(stack, selector)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, selector := range selectors {
+		if err := expand(selector, nil); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// PoliciesWithTag retorna, em ordem alfabética, as políticas marcadas com a tag.
+func PoliciesWithTag(policies map[string]PolicyDefinition, tag string) []string {
+	var names []string
+	for name, def := range policies {
+		for _, t := range def.Tags {
+			if t == tag {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var setPolicies = map[string]PolicyDefinition{
+	"ValidarIdade":          {Name: "ValidarIdade"},
+	"ValidarValorTransacao": {Name: "ValidarValorTransacao"},
+	"CalcularDesconto":      {Name: "CalcularDesconto", Tags: []string{"pricing"}},
+	"AplicarImpostos":       {Name: "AplicarImpostos", Tags: []string{"pricing", "fiscal"}},
+}
+
+var policySets = map[string][]string{
+	"validacoes": {"ValidarIdade", "ValidarValorTransacao"},
+	"checkout":   {"validacoes", "tag:pricing"},
+}
+
+func TestExpandSelectors(t *testing.T) {
+	all_cases := []struct {
+		name      string
+		selectors []string
+		expected  []string
+	}{
+		{name: "nomes de políticas", selectors: []string{"CalcularDesconto", "ValidarIdade"}, expected: []string{"CalcularDesconto", "ValidarIdade"}},
+		{name: "conjunto aninhado", selectors: []string{"checkout"}, expected: []string{"ValidarIdade", "ValidarValorTransacao", "AplicarImpostos", "CalcularDesconto"}},
+		{name: "tag", selectors: []string{"tag:fiscal"}, expected: []string{"AplicarImpostos"}},
+		{name: "sem repetições", selectors: []string{"ValidarIdade", "validacoes"}, expected: []string{"ValidarIdade", "ValidarValorTransacao"}},
+		{name: "nome desconhecido mantido", selectors: []string{"Inexistente"}, expected: []string{"Inexistente"}},
+	}
+
+	_, err := ExpandSelectors(setPolicies, policySets, []string{"ValidarIdade", "tag:pricng"})
+	assert.EqualError(t, err, "nenhuma política com a tag 'pricng'")
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			actual, err := ExpandSelectors(setPolicies, policySets, cenario.selectors)
+			assert.NoError(t, err, cenario.name)
+			assert.Equal(t, cenario.expected, actual, cenario.name)
+		}
+	})
+}
+
+func TestValidateSets(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		sets     map[string][]string
+		expected string
+	}{
+		{name: "conjuntos válidos", sets: policySets, expected: ""},
+		{name: "colisão com política", sets: map[string][]string{"ValidarIdade": {"CalcularDesconto"}}, expected: "mesmo nome de uma política"},
+		{name: "política não definida", sets: map[string][]string{"checkout": {"Inexistente"}}, expected: "não definido 'Inexistente'"},
+		{name: "tag sem políticas", sets: map[string][]string{"checkout": {"validacoes", "tag:pricng"}, "validacoes": {"ValidarIdade"}}, expected: "conjunto 'checkout': nenhuma política com a tag 'pricng'"},
+		{name: "ciclo", sets: map[string][]string{"a": {"b"}, "b": {"a"}}, expected: "referência circular"},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			err := ValidateSets(setPolicies, cenario.sets)
+			if cenario.expected == "" {
+				assert.NoError(t, err, cenario.name)
+			} else {
+				assert.ErrorContains(t, err, cenario.expected, cenario.name)
+			}
+		}
+	})
+}
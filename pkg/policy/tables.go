@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core/loader"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// Formatos das tabelas de referência
+const (
+	TableFormatCSV  = "csv"
+	TableFormatJSON = "json"
+	TableFormatYAML = "yaml"
+)
+
+var tableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TableSource declara a origem de uma tabela de referência no arquivo de políticas. Aceita
+// apenas a origem ("aliquotas: tabelas/aliquotas.csv") ou o mapa com "source", "format"
+// e "key".
+type TableSource struct {
+	Source string `yaml:"source" json:"source"`           // Caminho local (relativo ao arquivo de políticas), "s3://bucket/chave" ou "ssm://parametro"
+	Format string `yaml:"format" json:"format,omitempty"` // csv, json ou yaml; sem ele, a extensão da origem
+	Key    string `yaml:"key" json:"key,omitempty"`       // Coluna chave de listas de objetos; no CSV, a primeira coluna
+}
+
+func (s *TableSource) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Source)
+	}
+	type plain TableSource
+	return node.Decode((*plain)(s))
+}
+
+// format retorna o formato declarado ou, sem ele, o da extensão da origem ("" se desconhecido).
+func (s TableSource) format() string {
+	if s.Format != "" {
+		return strings.ToLower(s.Format)
+	}
+	switch strings.ToLower(path.Ext(s.Source)) {
+	case ".csv":
+		return TableFormatCSV
+	case ".json":
+		return TableFormatJSON
+	case ".yaml", ".yml":
+		return TableFormatYAML
+	}
+	return ""
+}
+
+// ValidateTables verifica as tabelas declaradas no arquivo de políticas: nomes, origens e formatos.
+func ValidateTables(tables map[string]TableSource) error {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		source := tables[name]
+		if !tableNameRe.MatchString(name) {
+			return fmt.Errorf("nome de tabela inválido: '%s'", name)
+		}
+		if source.Source == "" {
+			return fmt.Errorf("tabela '%s' sem origem", name)
+		}
+		switch source.format() {
+		case TableFormatCSV, TableFormatJSON, TableFormatYAML:
+		case "":
+			return fmt.Errorf("tabela '%s': formato de '%s' desconhecido; informe format (%s, %s ou %s)", name, source.Source, TableFormatCSV, TableFormatJSON, TableFormatYAML)
+		default:
+			return fmt.Errorf("tabela '%s': format deve ser %s, %s ou %s, recebeu '%s'", name, TableFormatCSV, TableFormatJSON, TableFormatYAML, source.Format)
+		}
+	}
+	return nil
+}
+
+// ParseTable interpreta o conteúdo de uma tabela de referência no formato da origem (ver
+// rules.NewTable). No CSV, a primeira linha traz os nomes das colunas, a coluna chave é a
+// primeira (ou a informada em key) e cada célula vira número, booleano, null (vazia) ou texto.
+func ParseTable(name string, source TableSource, content []byte) (*rules.Table, error) {
+	var data interface{}
+	key := source.Key
+	switch source.format() {
+	case TableFormatCSV:
+		header, rows, err := parseCSV(content)
+		if err != nil {
+			return nil, fmt.Errorf("tabela '%s' inválida: %v", name, err)
+		}
+		if key == "" && len(header) > 0 {
+			key = header[0]
+		}
+		data = rows
+	case TableFormatJSON:
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("tabela '%s' inválida: %v", name, err)
+		}
+	case TableFormatYAML:
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("tabela '%s' inválida: %v", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("tabela '%s': formato desconhecido '%s'", name, source.format())
+	}
+	return rules.NewTable(name, key, data)
+}
+
+// parseCSV lê o cabeçalho e as linhas do CSV, cada linha como um objeto.
+func parseCSV(content []byte) ([]string, []interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, []interface{}{}, nil
+	}
+	header := records[0]
+	rows := make([]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			row[column] = csvValue(record[i])
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+func csvValue(cell string) interface{} {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil
+	}
+	if num, err := strconv.ParseFloat(cell, 64); err == nil {
+		return num
+	}
+	if cell == "true" || cell == "false" {
+		return cell == "true"
+	}
+	return cell
+}
+
+// resolveTables torna as origens locais relativas das tabelas relativas ao diretório do
+// arquivo de políticas.
+func (c *Catalog) resolveTables(dir string) {
+	for name, table := range c.Tables {
+		if strings.HasPrefix(table.Source, "s3://") || strings.HasPrefix(table.Source, "ssm://") || filepath.IsAbs(table.Source) {
+			continue
+		}
+		table.Source = filepath.Join(dir, table.Source)
+		c.Tables[name] = table
+	}
+}
+
+// LoadTables lê e interpreta as tabelas declaradas, de origens locais, S3 ou SSM. Falha na
+// primeira tabela que não puder ser carregada.
+func LoadTables(sources map[string]TableSource) ([]*rules.Table, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tables := make([]*rules.Table, 0, len(names))
+	for _, name := range names {
+		source := sources[name]
+		content, err := loader.ReadSource(source.Source)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao carregar a tabela '%s' de '%s': %v", name, source.Source, err)
+		}
+		table, err := ParseTable(name, source, content)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+func TestParseTables(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(`
+CalcularImpostos:
+- SET $.pis = EXP($.valor * LOOKUP('aliquotas', $.tipo, 'pis'))
+tables:
+  estados_permitidos: tabelas/estados.json
+  aliquotas: {source: s3://bucket/aliquotas.csv, key: tipo}
+  faixas: {source: ssm://faixas, format: yaml}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]TableSource{
+		"estados_permitidos": {Source: "tabelas/estados.json"},
+		"aliquotas":          {Source: "s3://bucket/aliquotas.csv", Key: "tipo"},
+		"faixas":             {Source: "ssm://faixas", Format: "yaml"},
+	}, catalog.Tables)
+	assert.NotContains(t, catalog.Policies, TablesKey)
+
+	all_errors := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{name: "nome inválido", content: "tables:\n  aliquotas-pis: a.csv\n", message: "nome de tabela inválido: 'aliquotas-pis'"},
+		{name: "sem origem", content: "tables:\n  a: {format: csv}\n", message: "tabela 'a' sem origem"},
+		{name: "extensão desconhecida", content: "tables:\n  a: ssm://aliquotas\n", message: "tabela 'a': formato de 'ssm://aliquotas' desconhecido; informe format (csv, json ou yaml)"},
+		{name: "formato desconhecido", content: "tables:\n  a: {source: a.txt, format: xml}\n", message: "tabela 'a': format deve ser csv, json ou yaml, recebeu 'xml'"},
+		{name: "formato inválido", content: "tables: [a]\n", message: "tabelas de referência inválidas"},
+	}
+
+	for _, cenario := range all_errors {
+		t.Run(cenario.name, func(t *testing.T) {
+			_, err := ParseCatalog([]byte(cenario.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), cenario.message)
+		})
+	}
+}
+
+func TestParseTable(t *testing.T) {
+	all_cases := []struct {
+		name    string
+		source  TableSource
+		content string
+		key     interface{}
+		column  string
+		value   interface{}
+		keys    []interface{}
+	}{
+		{
+			name:    "CSV com a primeira coluna como chave",
+			source:  TableSource{Source: "aliquotas.csv"},
+			content: "tipo,pis,isento\nservico,0.0165,false\nproduto,,true\n",
+			key:     "servico", column: "pis", value: 0.0165,
+			keys: []interface{}{"servico", "produto"},
+		},
+		{
+			name:    "CSV com coluna chave informada",
+			source:  TableSource{Source: "aliquotas.csv", Key: "codigo"},
+			content: "tipo,codigo\nproduto,10\n",
+			key:     10, column: "tipo", value: "produto",
+			keys: []interface{}{10.0},
+		},
+		{
+			name:    "JSON com lista de valores",
+			source:  TableSource{Source: "s3://bucket/estados.json"},
+			content: `["SP", "RJ"]`,
+			key:     "RJ", value: "RJ",
+			keys: []interface{}{"SP", "RJ"},
+		},
+		{
+			name:    "YAML indexado pela chave",
+			source:  TableSource{Source: "ssm://faixas", Format: "yaml"},
+			content: "prata: {minimo: 100}\nbronze: {minimo: 0}\n",
+			key:     "prata", column: "minimo", value: 100,
+			keys: []interface{}{"bronze", "prata"},
+		},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			table, err := ParseTable("t", cenario.source, []byte(cenario.content))
+			require.NoError(t, err)
+			assert.Equal(t, cenario.keys, table.Keys())
+
+			row, exists := table.Row(cenario.key)
+			require.True(t, exists)
+			if cenario.column != "" {
+				row = row.(map[string]interface{})[cenario.column]
+			}
+			assert.Equal(t, cenario.value, row)
+		})
+	}
+
+	_, err := ParseTable("t", TableSource{Source: "t.csv"}, []byte("a,b\n1\n"))
+	assert.ErrorContains(t, err, "tabela 't' inválida")
+	_, err = ParseTable("t", TableSource{Source: "t.json"}, []byte(`[{"uf": "SP"}]`))
+	assert.ErrorContains(t, err, "informe a coluna chave para listas de objetos")
+}
+
+func TestLoadTables(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "estados.json"), []byte(`["SP", "RJ"]`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "aliquotas.csv"), []byte("tipo,pis\nservico,0.0165\n"), 0o644))
+
+	tables, err := LoadTables(map[string]TableSource{
+		"estados":   {Source: filepath.Join(dir, "estados.json")},
+		"aliquotas": {Source: filepath.Join(dir, "aliquotas.csv")},
+	})
+	require.NoError(t, err)
+	set := rules.NewTableSet(tables...)
+	assert.Equal(t, []string{"aliquotas", "estados"}, set.Names())
+
+	ctx := rules.NewContext(map[string]interface{}{"tipo": "servico", "uf": "SP"})
+	ctx.Tables = set
+	passed, details, err := rules.EvaluateRuleWithContext(`$.uf IN TABLE('estados') OR LOOKUP('aliquotas', $.tipo, 'pis') > 0`, ctx, nil)
+	require.NoError(t, err, details)
+	assert.True(t, passed)
+
+	_, err = LoadTables(map[string]TableSource{"ausente": {Source: filepath.Join(dir, "ausente.csv")}})
+	assert.ErrorContains(t, err, "falha ao carregar a tabela 'ausente'")
+}
+
+func TestLintTables(t *testing.T) {
+	content := []byte(`A:
+- $.uf IN TABLE('estados')
+- LOOKUP("aliquotas", $.tipo, "pis") > 0
+- LOOKUP($.tabela, $.tipo) == 1
+tables:
+  estados: estados.json
+`)
+	issues, err := LintContent(content, LintOptions{})
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "error: política 'A', regra 1: tabela 'aliquotas' não declarada em tables [unknown-table]", issues[0].String())
+
+	issues = Lint(map[string]PolicyDefinition{"A": {Name: "A", Rules: []string{`LOOKUP("aliquotas", $.tipo, "pis") > 0`}}}, nil, LintOptions{})
+	assert.Empty(t, issues)
+}
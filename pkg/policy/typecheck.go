@@ -0,0 +1,525 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+// Tipos JSON inferidos para os operandos; "integer" do schema é tratado como "number".
+const (
+	typeNumber  = "number"
+	typeString  = "string"
+	typeBoolean = "boolean"
+	typeNull    = "null"
+	typeArray   = "array"
+	typeObject  = "object"
+)
+
+// schemaPath é o resultado de percorrer um schema pelos segmentos de um caminho.
+type schemaPath struct {
+	schema       map[string]interface{} // Sub-schema do caminho; nil quando o schema não o restringe
+	exists       bool                   // false quando o schema declara as propriedades e a chave não está entre elas
+	allowed      bool                   // false quando "additionalProperties: false" proíbe a chave
+	optionalAt   int                    // Segmento do primeiro campo não obrigatório (-1 se todos são)
+	lastRequired bool                   // O último segmento é obrigatório no objeto pai
+}
+
+// lookupSchema percorre o schema pelos segmentos do caminho, registrando a existência da
+// chave e a obrigatoriedade ("required") de cada campo.
+func lookupSchema(s map[string]interface{}, segments []rules.PathSegment) schemaPath {
+	res := schemaPath{schema: s, exists: true, allowed: true, optionalAt: -1}
+	for i, segment := range segments {
+		current := res.schema
+		if current == nil {
+			return res
+		}
+		if segment.IsIndex {
+			res.schema, _ = current["items"].(map[string]interface{})
+			continue
+		}
+
+		properties, hasProperties := current["properties"].(map[string]interface{})
+		if hasProperties {
+			res.lastRequired = requiredKeys(current)[segment.Key]
+			if !res.lastRequired && res.optionalAt == -1 {
+				res.optionalAt = i
+			}
+		}
+		if child, ok := properties[segment.Key].(map[string]interface{}); ok {
+			res.schema = child
+			continue
+		}
+		switch additional := current["additionalProperties"].(type) {
+		case map[string]interface{}:
+			res.schema = additional
+			continue
+		case bool:
+			if !additional {
+				res.schema, res.exists, res.allowed = nil, false, false
+				return res
+			}
+		}
+		res.schema, res.exists = nil, !hasProperties
+		if !res.exists {
+			return res
+		}
+	}
+	return res
+}
+
+func requiredKeys(s map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	required, _ := s["required"].([]interface{})
+	for _, key := range required {
+		if name, ok := key.(string); ok {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// schemaTypes retorna os tipos declarados em "type" (string ou lista), ou nil se não houver.
+func schemaTypes(s map[string]interface{}) []string {
+	if s == nil {
+		return nil
+	}
+	var declared []interface{}
+	switch t := s["type"].(type) {
+	case string:
+		declared = []interface{}{t}
+	case []interface{}:
+		declared = t
+	}
+	var types []string
+	for _, t := range declared {
+		name, _ := t.(string)
+		if name == "integer" {
+			name = typeNumber
+		}
+		if name != "" {
+			types = append(types, name)
+		}
+	}
+	return types
+}
+
+// acceptsArgument indica se algum dos tipos do schema atende ao tipo de um argumento de
+// função; datas são aceitas como texto.
+func acceptsArgument(want string, types []string) bool {
+	if want == rules.TypeDate {
+		want = typeString
+	}
+	return hasType(types, want)
+}
+
+func hasType(types []string, want string) bool {
+	for _, t := range types {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
+
+func disjointTypes(a, b []string) bool {
+	for _, t := range a {
+		if hasType(b, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeTypes(types []string) string {
+	return strings.Join(types, "|")
+}
+
+// pathPrefix monta o caminho com os segmentos até 'last' (inclusive).
+func pathPrefix(segments []rules.PathSegment, last int) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, segment := range segments[:last+1] {
+		if segment.IsIndex {
+			fmt.Fprintf(&sb, "[%d]", segment.Index)
+			continue
+		}
+		sb.WriteString(".")
+		sb.WriteString(segment.Key)
+	}
+	return sb.String()
+}
+
+// typeCheck verifica a regra contra os schemas: tipos dos operandos em comparações e EXP,
+// campos não obrigatórios lidos sem verificação de null e destinos de SET, ADD e DELETE
+// incompatíveis com o schema da resposta. 'guards' são os caminhos que as condições já
+// avaliadas garantem presentes (ex.: "$.cliente != null").
+func (l *linter) typeCheck(name string, index int, n *rules.Node, guards []string) {
+	switch n.Kind {
+	case rules.NodeOr:
+		l.typeCheck(name, index, n.Left, guards)
+		// O lado direito só é avaliado quando o esquerdo é falso
+		l.typeCheck(name, index, n.Right, append(guards[:len(guards):len(guards)], presentWhen(n.Left, false)...))
+	case rules.NodeIf:
+		l.typeCheck(name, index, n.Left, guards)
+		l.typeCheck(name, index, n.Right, append(guards[:len(guards):len(guards)], presentWhen(n.Left, true)...))
+	case rules.NodeCompare:
+		l.checkComparisonTypes(name, index, n, guards)
+	case rules.NodeCheck:
+		l.checkReads(name, index, n.LHS, false, guards)
+	case rules.NodeLet:
+		l.checkReads(name, index, n.Value, false, guards)
+	case rules.NodeSet:
+		l.checkReads(name, index, n.Value, false, guards)
+		l.checkSetTarget(name, index, n)
+	case rules.NodeAdd:
+		l.checkReads(name, index, n.Value, false, guards)
+		l.checkAddTarget(name, index, n)
+	case rules.NodeDelete:
+		l.checkDeleteTarget(name, index, n)
+	case rules.NodeLoop:
+		// No corpo, a lista e o item (lido como o primeiro elemento) estão presentes
+		l.checkArrayOperand(name, index, n.Quantifier, n.Value)
+		l.typeCheck(name, index, n.Right, append(guards[:len(guards):len(guards)], n.Value.Text, n.Value.Text+"[0]"))
+	}
+}
+
+func (l *linter) checkComparisonTypes(name string, index int, n *rules.Node, guards []string) {
+	if n.Quantifier != "" {
+		n = l.itemComparison(name, index, n)
+	}
+	lhsTypes, rhsTypes := l.operandTypes(n.LHS), l.operandTypes(n.RHS)
+	switch n.Op {
+	case ">", ">=", "<", "<=", "BETWEEN", "BETWEEN EXCLUSIVE":
+		for _, operand := range numericOperands(n) {
+			if types := l.operandTypes(operand); operand.Kind == rules.OperandPath && types != nil && !hasType(types, typeNumber) {
+				l.report(name, index, SeverityError, LintTypeMismatch, "operador %s exige números, mas %s é %s no schema da requisição", n.Op, operand.Text, describeTypes(types))
+			}
+		}
+		l.checkReads(name, index, n.LHS, true, guards)
+		l.checkReads(name, index, n.RHS, true, guards)
+		return
+	case "==", "!=":
+		if !isNullLiteral(n.LHS) && !isNullLiteral(n.RHS) {
+			if lhsTypes != nil && rhsTypes != nil && disjointTypes(lhsTypes, rhsTypes) && !n.LHS.IsNumber() && !n.RHS.IsNumber() {
+				l.report(name, index, SeverityWarning, LintTypeMismatch, "comparação entre tipos incompatíveis: %s (%s) %s %s (%s)", n.LHS.Text, describeTypes(lhsTypes), n.Op, n.RHS.Text, describeTypes(rhsTypes))
+			}
+			l.checkEnum(name, index, n, n.LHS, n.RHS)
+			l.checkEnum(name, index, n, n.RHS, n.LHS)
+		}
+	case "CONTAINS":
+		if n.LHS.Kind == rules.OperandPath && lhsTypes != nil && !hasType(lhsTypes, typeString) && !hasType(lhsTypes, typeArray) {
+			l.report(name, index, SeverityWarning, LintTypeMismatch, "%s exige texto ou array, mas %s é %s no schema da requisição", n.Op, n.LHS.Text, describeTypes(lhsTypes))
+		}
+	case "IN", "NOT IN":
+		if n.LHS.Kind == rules.OperandPath && lhsTypes != nil && !hasType(lhsTypes, typeString) && !hasType(lhsTypes, typeNumber) {
+			l.report(name, index, SeverityWarning, LintTypeMismatch, "%s compara apenas strings e números, mas %s é %s no schema da requisição", n.Op, n.LHS.Text, describeTypes(lhsTypes))
+		}
+		l.checkArrayOperand(name, index, n.Op, n.RHS)
+	case "SUBSET OF", "INTERSECTS":
+		l.checkArrayOperand(name, index, n.Op, n.LHS)
+		l.checkArrayOperand(name, index, n.Op, n.RHS)
+	case "STARTS WITH", "ENDS WITH", "MATCHES":
+		if n.LHS.Kind == rules.OperandPath && lhsTypes != nil && !hasType(lhsTypes, typeString) {
+			l.report(name, index, SeverityWarning, LintTypeMismatch, "%s compara apenas strings, mas %s é %s no schema da requisição", n.Op, n.LHS.Text, describeTypes(lhsTypes))
+		}
+	}
+	l.checkReads(name, index, n.LHS, false, guards)
+	l.checkReads(name, index, n.RHS, false, guards)
+}
+
+// itemComparison verifica que o operando de ANY/ALL é um array e retorna a comparação
+// equivalente sobre um item ("ANY $.tags == 'vip'" compara $.tags[*]), para que os tipos
+// sejam verificados contra o schema dos itens.
+func (l *linter) itemComparison(name string, index int, n *rules.Node) *rules.Node {
+	if n.LHS.Kind != rules.OperandPath {
+		return n
+	}
+	item := n.LHS.Text
+	if !strings.Contains(item, "[*]") {
+		l.checkArrayOperand(name, index, n.Quantifier, n.LHS)
+		item += "[*]"
+	}
+	compare := *n
+	compare.Quantifier = ""
+	compare.LHS = &rules.Operand{Kind: rules.OperandPath, Text: item}
+	return &compare
+}
+
+// checkArrayOperand aponta caminhos que o operador exige como array, mas que o schema da
+// requisição declara com outro tipo.
+func (l *linter) checkArrayOperand(name string, index int, op string, operand *rules.Operand) {
+	if types := l.operandTypes(operand); operand.Kind == rules.OperandPath && types != nil && !hasType(types, typeArray) {
+		l.report(name, index, SeverityError, LintTypeMismatch, "%s exige um array, mas %s é %s no schema da requisição", op, operand.Text, describeTypes(types))
+	}
+}
+
+// checkEnum aponta comparações de um caminho com um literal fora do "enum" do schema.
+func (l *linter) checkEnum(name string, index int, n *rules.Node, path, literal *rules.Operand) {
+	if path.Kind != rules.OperandPath || literal.Kind != rules.OperandLiteral {
+		return
+	}
+	sub := l.requestSchemaAt(path.Text)
+	enum, ok := sub["enum"].([]interface{})
+	if !ok {
+		return
+	}
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(literal.Value) {
+			return
+		}
+	}
+	l.report(name, index, SeverityWarning, LintConstantCondition, "condição sempre %s: %s não está entre os valores permitidos para %s no schema da requisição", describeBool(n.Op == "!="), literal.Text, path.Text)
+}
+
+// checkReads verifica os caminhos lidos pelo operando; 'numeric' indica que o valor é usado
+// como número (comparações de ordem e EXP), em que um campo ausente (nil) gera erro.
+func (l *linter) checkReads(name string, index int, o *rules.Operand, numeric bool, guards []string) {
+	switch o.Kind {
+	case rules.OperandPath:
+		l.checkOptionalPath(name, index, o.Text, numeric, guards)
+	case rules.OperandExpression:
+		for _, operand := range []*rules.Operand{o.Left, o.Right} {
+			if operand == nil {
+				continue
+			}
+			if types := l.operandTypes(operand); operand.Kind == rules.OperandPath && types != nil && !hasType(types, typeNumber) {
+				l.report(name, index, SeverityError, LintTypeMismatch, "operando %s de %s é %s no schema da requisição", operand.Text, o.Text, describeTypes(types))
+			}
+			l.checkReads(name, index, operand, true, guards)
+		}
+	case rules.OperandCall:
+		signature, _ := l.opts.Functions.Lookup(o.Func)
+		for i, arg := range o.Args {
+			if arg.Kind == rules.OperandPath && rules.AcceptsMissingPaths(o.Func) {
+				continue // A função trata o caminho ausente (ex.: EXISTS, COALESCE)
+			}
+			if want := signature.ArgType(i); arg.Kind == rules.OperandPath && want != rules.TypeAny {
+				if types := l.operandTypes(arg); types != nil && !acceptsArgument(want, types) {
+					l.report(name, index, SeverityError, LintTypeMismatch, "argumento %d de %s exige %s, mas %s é %s no schema da requisição", i+1, o.Func, want, arg.Text, describeTypes(types))
+				}
+			}
+			l.checkReads(name, index, arg, false, guards)
+		}
+	case rules.OperandRange:
+		l.checkReads(name, index, o.Left, numeric, guards)
+		l.checkReads(name, index, o.Right, numeric, guards)
+	}
+}
+
+// checkOptionalPath aponta leituras de campos não obrigatórios no schema da requisição sem
+// uma condição anterior que garanta sua presença. Um objeto intermediário ausente faz a
+// leitura falhar; um campo final ausente resulta em null, o que só falha em uso numérico.
+func (l *linter) checkOptionalPath(name string, index int, path string, numeric bool, guards []string) {
+	if l.opts.RequestSchema == nil || l.isWritten(path) {
+		return
+	}
+	root, segments, err := rules.ParsePath(path)
+	if err != nil || root != "" || len(segments) == 0 {
+		return
+	}
+	res := lookupSchema(*l.opts.RequestSchema, segments)
+	if !res.exists || res.optionalAt == -1 {
+		return
+	}
+	if last := len(segments) - 1; res.optionalAt < last {
+		prefix := pathPrefix(segments, res.optionalAt)
+		if !isGuarded(prefix, guards) {
+			l.report(name, index, SeverityWarning, LintOptionalPath, "%s falha quando %s está ausente (campo não obrigatório no schema da requisição)", path, prefix)
+		}
+		return
+	}
+	if numeric && !isGuarded(path, guards) {
+		l.report(name, index, SeverityWarning, LintOptionalPath, "%s é null quando ausente (campo não obrigatório no schema da requisição), mas é usado como número", path)
+	}
+}
+
+func (l *linter) checkSetTarget(name string, index int, n *rules.Node) {
+	res, ok := l.responseTarget(name, index, n)
+	if !ok || res.schema == nil {
+		return
+	}
+	declared, assigned := schemaTypes(res.schema), l.operandTypes(n.Value)
+	if declared != nil && assigned != nil && disjointTypes(declared, assigned) {
+		l.report(name, index, SeverityError, LintResponseSchema, "SET %s atribui %s, mas o schema da resposta declara %s", n.Target, describeTypes(assigned), describeTypes(declared))
+	}
+}
+
+func (l *linter) checkAddTarget(name string, index int, n *rules.Node) {
+	if l.opts.RequestSchema != nil {
+		if _, segments, err := rules.ParsePath(n.Target); err == nil {
+			if types := schemaTypes(lookupSchema(*l.opts.RequestSchema, segments).schema); types != nil && !hasType(types, typeArray) {
+				l.report(name, index, SeverityError, LintTypeMismatch, "ADD exige um array, mas %s é %s no schema da requisição", n.Target, describeTypes(types))
+			}
+		}
+	}
+	res, ok := l.responseTarget(name, index, n)
+	if !ok {
+		return
+	}
+	if types := schemaTypes(res.schema); types != nil && !hasType(types, typeArray) {
+		l.report(name, index, SeverityError, LintResponseSchema, "ADD exige um array, mas %s é %s no schema da resposta", n.Target, describeTypes(types))
+	}
+}
+
+func (l *linter) checkDeleteTarget(name string, index int, n *rules.Node) {
+	if l.opts.ResponseSchema == nil {
+		return
+	}
+	_, segments, err := rules.ParsePath(n.Target)
+	if err != nil || len(segments) == 0 || segments[len(segments)-1].IsIndex {
+		return
+	}
+	if res := lookupSchema(*l.opts.ResponseSchema, segments); res.exists && res.lastRequired {
+		l.report(name, index, SeverityWarning, LintResponseSchema, "DELETE remove %s, obrigatório no schema da resposta", n.Target)
+	}
+}
+
+// responseTarget localiza o destino de SET ou ADD no schema da resposta e aponta destinos
+// proibidos por "additionalProperties: false".
+func (l *linter) responseTarget(name string, index int, n *rules.Node) (schemaPath, bool) {
+	if l.opts.ResponseSchema == nil {
+		return schemaPath{}, false
+	}
+	_, segments, err := rules.ParsePath(n.Target)
+	if err != nil {
+		return schemaPath{}, false
+	}
+	res := lookupSchema(*l.opts.ResponseSchema, segments)
+	if !res.allowed {
+		l.report(name, index, SeverityError, LintResponseSchema, "%s não é permitido pelo schema da resposta (additionalProperties: false)", n.Target)
+		return res, false
+	}
+	return res, true
+}
+
+// operandTypes infere os tipos possíveis do operando; nil quando desconhecidos. Caminhos
+// alterados por alguma regra não têm tipo inferido, pois o valor pode vir do SET.
+func (l *linter) operandTypes(o *rules.Operand) []string {
+	switch o.Kind {
+	case rules.OperandLiteral:
+		switch o.Value.(type) {
+		case float64:
+			return []string{typeNumber}
+		case bool:
+			return []string{typeBoolean}
+		case nil:
+			return []string{typeNull}
+		case string:
+			return []string{typeString}
+		case []interface{}:
+			return []string{typeArray}
+		case map[string]interface{}:
+			return []string{typeObject}
+		}
+	case rules.OperandList:
+		return []string{typeArray}
+	case rules.OperandExpression:
+		return []string{typeNumber}
+	case rules.OperandCall:
+		// Datas e valores sem tipo declarado não têm equivalente nos tipos do schema
+		signature, _ := l.opts.Functions.Lookup(o.Func)
+		switch signature.Returns {
+		case rules.TypeString, rules.TypeNumber, rules.TypeBoolean, rules.TypeArray, rules.TypeObject:
+			return []string{signature.Returns}
+		}
+	case rules.OperandPath:
+		if l.isWritten(o.Text) {
+			return nil
+		}
+		return schemaTypes(l.requestSchemaAt(o.Text))
+	}
+	return nil
+}
+
+// requestSchemaAt retorna o sub-schema da requisição de um caminho de dados (nil se desconhecido).
+func (l *linter) requestSchemaAt(path string) map[string]interface{} {
+	if l.opts.RequestSchema == nil {
+		return nil
+	}
+	// Os itens selecionados por [*] têm o schema do primeiro item
+	root, segments, err := rules.ParsePath(strings.ReplaceAll(path, "[*]", "[0]"))
+	if err != nil || root != "" {
+		return nil
+	}
+	return lookupSchema(*l.opts.RequestSchema, segments).schema
+}
+
+func (l *linter) isWritten(path string) bool {
+	for _, written := range l.writes {
+		if pathsOverlap(path, written) {
+			return true
+		}
+	}
+	return false
+}
+
+// presentWhen retorna os caminhos que a condição garante presentes quando seu resultado é
+// 'outcome': "$.x != null" verdadeira, "$.x == null" falsa, comparações de ordem verdadeiras
+// (que exigem números), IS_* verdadeiras, IS_EMPTY falsa e, para OR falso, o que ambos os
+// lados garantem. EXISTS verdadeira garante apenas o objeto pai, já que o campo pode ser null.
+func presentWhen(n *rules.Node, outcome bool) []string {
+	switch n.Kind {
+	case rules.NodeCheck:
+		call := n.LHS
+		if len(call.Args) != 1 || call.Args[0].Kind != rules.OperandPath {
+			return nil
+		}
+		switch call.Func {
+		case "EXISTS":
+			root, segments, err := rules.ParsePath(call.Args[0].Text)
+			if outcome && err == nil && root == "" && len(segments) > 1 {
+				return []string{pathPrefix(segments, len(segments)-2)}
+			}
+		case "IS_STRING", "IS_NUMBER", "IS_ARRAY", "IS_OBJECT":
+			if outcome {
+				return []string{call.Args[0].Text}
+			}
+		case "IS_EMPTY":
+			if !outcome {
+				return []string{call.Args[0].Text}
+			}
+		}
+		return nil
+	case rules.NodeCompare:
+		if n.Quantifier != "" {
+			return nil // ANY/ALL não garantem a presença do array nem dos itens
+		}
+		var paths []string
+		for _, pair := range [][2]*rules.Operand{{n.LHS, n.RHS}, {n.RHS, n.LHS}} {
+			path, other := pair[0], pair[1]
+			if path.Kind != rules.OperandPath {
+				continue
+			}
+			switch {
+			case isNullLiteral(other):
+				if (n.Op == "!=" && outcome) || (n.Op == "==" && !outcome) {
+					paths = append(paths, path.Text)
+				}
+			case outcome && (n.Op == ">" || n.Op == ">=" || n.Op == "<" || n.Op == "<="):
+				paths = append(paths, path.Text)
+			}
+		}
+		return paths
+	case rules.NodeOr:
+		if !outcome {
+			return append(presentWhen(n.Left, false), presentWhen(n.Right, false)...)
+		}
+	}
+	return nil
+}
+
+func isNullLiteral(o *rules.Operand) bool {
+	return o.Kind == rules.OperandLiteral && o.Value == nil
+}
+
+// isGuarded indica se alguma condição garante a presença do caminho (ou de um descendente).
+func isGuarded(path string, guards []string) bool {
+	for _, guard := range guards {
+		if guard == path || strings.HasPrefix(guard, path+".") || strings.HasPrefix(guard, path+"[") {
+			return true
+		}
+	}
+	return false
+}
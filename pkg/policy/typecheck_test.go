@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+var (
+	typeCheckRequest = schema.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"valor": map[string]interface{}{"type": "number"},
+			"nome":  map[string]interface{}{"type": "string"},
+			"tipo":  map[string]interface{}{"type": "string", "enum": []interface{}{"adulto", "juvenil"}},
+			"idade": map[string]interface{}{"type": []interface{}{"integer", "null"}},
+			"ativo": map[string]interface{}{"type": "boolean"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"cliente": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"limite": map[string]interface{}{"type": "number"}},
+				"required":   []interface{}{"limite"},
+			},
+		},
+		"required": []interface{}{"valor", "nome", "tipo", "ativo", "tags"},
+	}
+	typeCheckResponse = schema.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"valor":    map[string]interface{}{"type": "number"},
+			"desconto": map[string]interface{}{"type": "number"},
+			"nome":     map[string]interface{}{"type": "string"},
+			"tags":     map[string]interface{}{"type": "array"},
+			"resumo": map[string]interface{}{
+				"type":                 "object",
+				"properties":           map[string]interface{}{"total": map[string]interface{}{"type": "number"}},
+				"additionalProperties": false,
+			},
+		},
+		"required": []interface{}{"valor", "nome"},
+	}
+)
+
+func TestTypeCheck(t *testing.T) {
+	all_cases := []struct {
+		name     string
+		rules    []string
+		expected []string // Código e regra de cada problema, na ordem
+	}{
+		{
+			name:  "tipos compatíveis",
+			rules: []string{`$.valor > 10`, `$.nome == "ana"`, `$.tipo IN ["adulto"]`, `SET $.desconto = EXP($.valor * 0.1)`, `ADD "novo" TO $.tags`},
+		},
+		{name: "string comparada com >", rules: []string{`$.nome > 10`}, expected: []string{"type-mismatch:0"}},
+		{name: "string em EXP", rules: []string{`SET $.desconto = EXP($.nome * 2)`}, expected: []string{"type-mismatch:0"}},
+		{name: "tipos incompatíveis em ==", rules: []string{`$.ativo == "sim"`, `$.valor == "10"`}, expected: []string{"type-mismatch:0"}},
+		{name: "IN com booleano", rules: []string{`$.ativo IN ["sim"]`, `$.valor IN [10, 20]`}, expected: []string{"type-mismatch:0"}},
+		{name: "IN com caminho que não é array", rules: []string{`$.nome IN $.tags`, `$.nome IN $.valor`}, expected: []string{"type-mismatch:1"}},
+		{name: "quantificadores", rules: []string{`ANY $.tags == "vip"`, `ALL $.tags > 1`, `ANY $.valor > 1`}, expected: []string{"type-mismatch:1", "type-mismatch:2"}},
+		{name: "operadores de conjunto", rules: []string{`$.tags SUBSET OF ["a"]`, `$.nome INTERSECTS $.tags`}, expected: []string{"type-mismatch:1"}},
+		{name: "operadores de texto", rules: []string{`$.valor STARTS WITH "1"`, `$.nome MATCHES "^a"`, `$.tags CONTAINS "x"`, `$.ativo CONTAINS "x"`}, expected: []string{"type-mismatch:0", "type-mismatch:3"}},
+		{name: "literal fora do enum", rules: []string{`$.tipo == "servico"`, `$.tipo != "adulto"`}, expected: []string{"constant-condition:0"}},
+		{name: "ADD em campo que não é array", rules: []string{`ADD 1 TO $.nome`}, expected: []string{"type-mismatch:0", "response-schema:0"}},
+		{name: "campo opcional usado como número", rules: []string{`$.idade >= 18`, `$.idade == null`}, expected: []string{"optional-path:0"}},
+		{name: "campo opcional verificado antes", rules: []string{`$.idade != null`, `$.idade >= 18`}},
+		{name: "campo opcional verificado no IF", rules: []string{`IF $.idade != null THEN SET $.desconto = EXP($.idade * 2)`}},
+		{name: "campo opcional verificado no OR", rules: []string{`$.idade == null OR $.idade >= 18`}},
+		{name: "objeto intermediário opcional", rules: []string{`$.cliente.limite == 1`}, expected: []string{"optional-path:0"}},
+		{name: "objeto intermediário verificado com EXISTS", rules: []string{`EXISTS($.cliente.limite)`, `$.cliente.limite == 1`}},
+		{name: "EXISTS não garante valor diferente de null", rules: []string{`EXISTS($.idade)`, `$.idade >= 18`}, expected: []string{"optional-path:1"}},
+		{name: "campo opcional verificado com IS_NUMBER", rules: []string{`IF IS_NUMBER($.idade) THEN SET $.desconto = EXP($.idade * 2)`}},
+		{name: "funções que aceitam campo ausente", rules: []string{`COALESCE($.cliente.limite, 0) > 1`, `SET $.desconto = DEFAULT($.idade, 0)`}},
+		{name: "laços sobre arrays", rules: []string{`ALL tag IN $.tags: tag != "bloqueada"`, `ANY tag IN $.tags: tag > 1`, `ALL v IN $.cliente.limite: v >= 1`}, expected: []string{"type-mismatch:1", "type-mismatch:2"}},
+		{name: "SET com tipo diferente da resposta", rules: []string{`SET $.desconto = "dez"`}, expected: []string{"response-schema:0"}},
+		{name: "SET proibido na resposta", rules: []string{`SET $.resumo.extra = 1`, `SET $.resumo.total = 1`}, expected: []string{"response-schema:0"}},
+		{name: "DELETE de campo obrigatório", rules: []string{`DELETE $.nome`, `DELETE $.desconto`}, expected: []string{"response-schema:0"}},
+		{name: "caminhos alterados não são inferidos", rules: []string{`SET $.nome = 10`, `$.nome > 5`}, expected: []string{"response-schema:0"}},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			issues := Lint(map[string]PolicyDefinition{"P": {Name: "P", Rules: cenario.rules}}, nil, LintOptions{
+				RequestSchema:  &typeCheckRequest,
+				ResponseSchema: &typeCheckResponse,
+			})
+			var codes []string
+			for _, issue := range issues {
+				codes = append(codes, fmt.Sprintf("%s:%d", issue.Code, issue.Rule))
+			}
+			assert.Equal(t, cenario.expected, codes, fmt.Sprint(issues))
+		})
+	}
+}
+
+func TestTypeCheckRegisteredFunctions(t *testing.T) {
+	registry := rules.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("CPF_VALIDO", rules.Signature{Args: []string{rules.TypeString}, Returns: rules.TypeBoolean},
+		func(_ *rules.Context, _ []interface{}) (interface{}, error) { return true, nil }))
+	assert.NoError(t, registry.Register("CONVERTER", rules.Signature{Args: []string{rules.TypeNumber, rules.TypeString}, Returns: rules.TypeNumber},
+		func(_ *rules.Context, args []interface{}) (interface{}, error) { return args[0], nil }))
+
+	all_cases := []struct {
+		name     string
+		rules    []string
+		expected []string
+	}{
+		{name: "chamadas compatíveis", rules: []string{`CPF_VALIDO($.nome)`, `CONVERTER($.valor, $.tipo) > 10`, `SET $.desconto = CONVERTER($.valor, "USD")`}},
+		{name: "caminho de outro tipo", rules: []string{`CPF_VALIDO($.valor)`, `CONVERTER($.nome, "USD") > 1`}, expected: []string{"type-mismatch:0", "type-mismatch:1"}},
+		{name: "literal de outro tipo", rules: []string{`CPF_VALIDO(123)`}, expected: []string{"function-call:0"}},
+		{name: "argumentos de menos", rules: []string{`CONVERTER($.valor) > 1`}, expected: []string{"function-call:0"}},
+		{name: "resultado comparado com outro tipo", rules: []string{`CONVERTER($.valor, "USD") == "dez"`}, expected: []string{"type-mismatch:0"}},
+	}
+
+	for _, cenario := range all_cases {
+		t.Run(cenario.name, func(t *testing.T) {
+			issues := Lint(map[string]PolicyDefinition{"P": {Name: "P", Rules: cenario.rules}}, nil, LintOptions{
+				RequestSchema:  &typeCheckRequest,
+				ResponseSchema: &typeCheckResponse,
+				Functions:      registry,
+			})
+			var codes []string
+			for _, issue := range issues {
+				codes = append(codes, fmt.Sprintf("%s:%d", issue.Code, issue.Rule))
+			}
+			assert.Equal(t, cenario.expected, codes, fmt.Sprint(issues))
+		})
+	}
+
+	issues := Lint(map[string]PolicyDefinition{"P": {Name: "P", Rules: []string{`CPF_VALIDO($.nome)`}}}, nil, LintOptions{})
+	assert.Len(t, issues, 1)
+	assert.Equal(t, LintUnknownFunction, issues[0].Code)
+}
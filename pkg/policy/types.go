@@ -1,14 +1,34 @@
 package policy
 
 import (
+	"github.com/raywall/cloud-policy-serializer/pkg/json/patch"
 	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
 )
 
 // PolicyDefinition representa uma única política com suas regras.
 // As regras são strings na linguagem de política customizada.
+// DependsOn lista as políticas que devem executar (e passar) antes desta.
+// Tags permitem selecionar a política por categoria (ex.: "tag:pricing").
+// Numeric escolhe a aritmética de EXP e das comparações numéricas (ver NumericDecimal).
+// Params declara os parâmetros lidos nas regras como ":nome" (ver ResolveParams).
 type PolicyDefinition struct {
-	Name  string
-	Rules []string
+	Name      string           `json:"name"`
+	Rules     []string         `json:"rules"`
+	DependsOn []string         `json:"dependsOn,omitempty"`
+	Tags      []string         `json:"tags,omitempty"`
+	Numeric   string           `json:"numeric,omitempty"`
+	Params    map[string]Param `json:"params,omitempty"`
+}
+
+// Modos numéricos de uma política (PolicyDefinition.Numeric)
+const (
+	NumericFloat   = "float"   // Ponto flutuante binário (padrão)
+	NumericDecimal = "decimal" // EXP e comparações em decimal exato, convertido para float64 ao gravar nos dados; recomendado para valores monetários
+)
+
+// Decimal indica se as regras da política usam aritmética decimal (ver NumericDecimal).
+func (p PolicyDefinition) Decimal() bool {
+	return p.Numeric == NumericDecimal
 }
 
 // PolicyExecutionResult armazena o resultado da execução de uma política.
@@ -17,4 +37,15 @@ type PolicyExecutionResult struct {
 	Passed      bool
 	Error       error // Mensagem de erro se a avaliação falhou ou a regra não foi cumprida
 	RuleResults []rules.RuleExecutionResult
+	Changes     []patch.Operation // Alterações (SET/ADD/DELETE) feitas nos dados, como JSON Patch (apenas com changes ativo)
+	Trace       *rules.TraceNode  // Árvore de avaliação das regras (apenas com explain ativo)
+}
+
+// CollectChanges concatena, na ordem de execução, as alterações de todas as políticas.
+func CollectChanges(results []PolicyExecutionResult) []patch.Operation {
+	changes := []patch.Operation{}
+	for _, res := range results {
+		changes = append(changes, res.Changes...)
+	}
+	return changes
 }
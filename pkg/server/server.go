@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+)
+
+// Valores padrão da configuração do servidor
+const (
+	DefaultAddr            = ":8080"
+	DefaultMaxBodyBytes    = 1 << 20 // 1 MiB
+	DefaultShutdownTimeout = 10 * time.Second
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+)
+
+// Config contém as opções do servidor HTTP. Campos zerados usam os valores padrão.
+type Config struct {
+	Addr            string
+	MaxBodyBytes    int64
+	ShutdownTimeout time.Duration
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+}
+
+// Server expõe o motor de políticas via HTTP:
+//   - POST /evaluate: processa uma requisição (mesmo formato de EngineContext.ProcessRequest)
+//   - POST /validate: valida {"data": {...}} contra o schema da requisição
+//   - GET /policies: lista as políticas e conjuntos carregados
+//   - POST /tables/reload: recarrega as tabelas de referência (ver EngineContext.ReloadTables)
+//   - GET /healthz: verificação de saúde
+type Server struct {
+	Engine *core.EngineContext
+	config Config
+}
+
+// New cria um servidor para o motor informado.
+func New(ec *core.EngineContext, cfg Config) *Server {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultAddr
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = DefaultReadTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultWriteTimeout
+	}
+	return &Server{Engine: ec, config: cfg}
+}
+
+// Handler retorna o roteador HTTP com todas as rotas do servidor.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /evaluate", s.handleEvaluate)
+	mux.HandleFunc("POST /validate", s.handleValidate)
+	mux.HandleFunc("GET /policies", s.handlePolicies)
+	mux.HandleFunc("POST /tables/reload", s.handleReloadTables)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	return mux
+}
+
+// ListenAndServe inicia o servidor e bloqueia até o contexto ser cancelado.
+// No cancelamento, aguarda as requisições em andamento por até ShutdownTimeout.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:         s.config.Addr,
+		Handler:      s.Handler(),
+		ReadTimeout:  s.config.ReadTimeout,
+		WriteTimeout: s.config.WriteTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("falha ao encerrar o servidor: %v", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req core.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, &core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao desserializar requisição: %v", err)})
+		return
+	}
+	req.Headers = make(map[string]string, len(r.Header))
+	for name := range r.Header {
+		req.Headers[name] = r.Header.Get(name)
+	}
+
+	response, err := s.Engine.Process(&req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ValidationResponse é o corpo da resposta de POST /validate.
+type ValidationResponse struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req core.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, &core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao desserializar requisição: %v", err)})
+		return
+	}
+
+	response := ValidationResponse{Valid: true}
+	for _, err := range s.Engine.ValidateData(req.Data) {
+		response.Valid = false
+		response.Errors = append(response.Errors, err.Error())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// PoliciesResponse é o corpo da resposta de GET /policies.
+type PoliciesResponse struct {
+	Policies    []policy.PolicyDefinition `json:"policies"`
+	Sets        map[string][]string       `json:"sets,omitempty"`
+	Expressions map[string]string         `json:"expressions,omitempty"`
+	Tables      []string                  `json:"tables,omitempty"`
+}
+
+func (s *Server) handlePolicies(w http.ResponseWriter, _ *http.Request) {
+	response := PoliciesResponse{
+		Policies:    []policy.PolicyDefinition{},
+		Sets:        s.Engine.PolicySets,
+		Expressions: s.Engine.Expressions,
+		Tables:      s.Engine.Tables.Names(),
+	}
+	for _, def := range s.Engine.Policies {
+		response.Policies = append(response.Policies, def)
+	}
+	sort.Slice(response.Policies, func(i, j int) bool {
+		return response.Policies[i].Name < response.Policies[j].Name
+	})
+	writeJSON(w, http.StatusOK, response)
+}
+
+// TablesResponse é o corpo da resposta de POST /tables/reload.
+type TablesResponse struct {
+	Tables []string `json:"tables"`
+}
+
+func (s *Server) handleReloadTables(w http.ResponseWriter, _ *http.Request) {
+	if err := s.Engine.ReloadTables(); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, TablesResponse{Tables: s.Engine.Tables.Names()})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readBody lê o corpo respeitando MaxBodyBytes; em caso de falha já escreve a resposta de erro.
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, core.ErrorBody{
+				Status:  "error",
+				Kind:    string(core.ErrorKindRequest),
+				Message: fmt.Sprintf("corpo da requisição excede o limite de %d bytes", maxBytesErr.Limit),
+			})
+			return nil, false
+		}
+		writeError(w, &core.EngineError{Kind: core.ErrorKindRequest, Err: fmt.Errorf("falha ao ler corpo da requisição: %v", err)})
+		return nil, false
+	}
+	return body, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, core.KindOf(err).HTTPStatus(), core.NewErrorBody(err))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raywall/cloud-policy-serializer/pkg/core"
+	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy"
+	"github.com/raywall/cloud-policy-serializer/pkg/policy/rules"
+)
+
+func newTestServer(cfg Config) *Server {
+	reqSchema := schema.Schema{
+		"type":     "object",
+		"required": []interface{}{"idade"},
+		"properties": map[string]interface{}{
+			"idade": map[string]interface{}{"type": "integer"},
+		},
+	}
+	policies := map[string]policy.PolicyDefinition{
+		"ValidarIdade":     {Name: "ValidarIdade", Rules: []string{`$.idade >= 18`}},
+		"CalcularDesconto": {Name: "CalcularDesconto", Rules: []string{`SET $.canal = $meta.headers.x-source`}, Tags: []string{"pricing"}},
+	}
+	ec := core.NewEngineContext(&reqSchema, nil, policies, "Local")
+	ec.PolicySets = map[string][]string{"default": {"ValidarIdade"}}
+	return New(ec, cfg)
+}
+
+func TestServerRoutes(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(Config{}).Handler())
+	defer ts.Close()
+
+	all_cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		contains string
+	}{
+		{name: "healthz", method: http.MethodGet, path: "/healthz", status: http.StatusOK, contains: `"status":"ok"`},
+		{name: "evaluate sucesso", method: http.MethodPost, path: "/evaluate", body: `{"id":"r1","data":{"idade":20}}`, status: http.StatusOK, contains: `"status":"success"`},
+		{name: "evaluate política falhou", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":10},"policies":["ValidarIdade"]}`, status: http.StatusUnprocessableEntity, contains: `"kind":"policy"`},
+		{name: "evaluate schema inválido", method: http.MethodPost, path: "/evaluate", body: `{"data":{}}`, status: http.StatusBadRequest, contains: `"kind":"schema"`},
+		{name: "evaluate json inválido", method: http.MethodPost, path: "/evaluate", body: `{`, status: http.StatusBadRequest, contains: `"kind":"request"`},
+		{name: "evaluate cabeçalhos em $meta", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":20},"policies":["CalcularDesconto"]}`, status: http.StatusOK, contains: `"canal":"web"`},
+		{name: "evaluate com alterações", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":20},"policies":["CalcularDesconto"],"includeChanges":true}`, status: http.StatusOK, contains: `"changes":[{"op":"add","path":"/canal","value":"web"`},
+		{name: "evaluate tag digitada errado", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":20},"policies":["tag:pricng"]}`, status: http.StatusUnprocessableEntity, contains: "nenhuma política com a tag 'pricng'"},
+		{name: "evaluate tenant desconhecido", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":20},"tenant":"lojaA"}`, status: http.StatusBadRequest, contains: "tenant 'lojaA' não configurado"},
+		{name: "evaluate parâmetro não declarado", method: http.MethodPost, path: "/evaluate", body: `{"data":{"idade":20},"params":{"ValidarIdade":{"minimo":21}}}`, status: http.StatusBadRequest, contains: "não declara o parâmetro :minimo"},
+		{name: "validate válido", method: http.MethodPost, path: "/validate", body: `{"data":{"idade":20}}`, status: http.StatusOK, contains: `"valid":true`},
+		{name: "validate inválido", method: http.MethodPost, path: "/validate", body: `{"data":{"idade":"x"}}`, status: http.StatusOK, contains: `"valid":false`},
+		{name: "policies", method: http.MethodGet, path: "/policies", status: http.StatusOK, contains: `"name":"CalcularDesconto"`},
+		{name: "método não permitido", method: http.MethodGet, path: "/evaluate", status: http.StatusMethodNotAllowed},
+	}
+
+	t.Run("", func(t *testing.T) {
+		for _, cenario := range all_cases {
+			req, err := http.NewRequest(cenario.method, ts.URL+cenario.path, strings.NewReader(cenario.body))
+			require.NoError(t, err, cenario.name)
+			req.Header.Set("X-Source", "web")
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, cenario.name)
+			body, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			require.NoError(t, err, cenario.name)
+
+			assert.Equal(t, cenario.status, res.StatusCode, cenario.name)
+			assert.Contains(t, string(body), cenario.contains, cenario.name)
+		}
+	})
+}
+
+func TestServerBodyLimit(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(Config{MaxBodyBytes: 32}).Handler())
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/evaluate", "application/json", strings.NewReader(`{"data":{"idade":20,"nome":"um nome longo demais"}}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var body core.ErrorBody
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	assert.Contains(t, body.Message, "32 bytes")
+}
+
+func TestServerReloadTables(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "estados.json")
+	require.NoError(t, os.WriteFile(source, []byte(`["SP"]`), 0o644))
+
+	srv := newTestServer(Config{})
+	srv.Engine.Policies["ValidarEstado"] = policy.PolicyDefinition{Name: "ValidarEstado", Rules: []string{`$.estado IN TABLE('estados')`}}
+	srv.Engine.TableSources = map[string]policy.TableSource{"estados": {Source: source}}
+	require.NoError(t, srv.Engine.ReloadTables())
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	evaluate := func() int {
+		res, err := http.Post(ts.URL+"/evaluate", "application/json", strings.NewReader(`{"data":{"idade":20,"estado":"RJ"},"policies":["ValidarEstado"]}`))
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	reload := func() (int, string) {
+		res, err := http.Post(ts.URL+"/tables/reload", "application/json", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, evaluate())
+
+	require.NoError(t, os.WriteFile(source, []byte(`["SP", "RJ"]`), 0o644))
+	status, body := reload()
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"tables":["estados"]}`, body)
+	assert.Equal(t, http.StatusOK, evaluate())
+
+	require.NoError(t, os.WriteFile(source, []byte(`{`), 0o644))
+	status, body = reload()
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, body, "tabela 'estados' inválida")
+	assert.Equal(t, http.StatusOK, evaluate(), "a falha mantém a versão anterior da tabela")
+}
+
+func TestServerFunctionCheck(t *testing.T) {
+	srv := newTestServer(Config{})
+	srv.Engine.Policies["ValidarCPF"] = policy.PolicyDefinition{Name: "ValidarCPF", Rules: []string{`CPF_VALIDO($.cpf)`}}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	evaluate := func() (int, string) {
+		res, err := http.Post(ts.URL+"/evaluate", "application/json", strings.NewReader(`{"data":{"idade":20,"cpf":"12345678909"},"policies":["ValidarIdade"]}`))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	// Sem CheckFunctions, o servidor recusa as requisições enquanto alguma política chamar
+	// uma função não registrada, mesmo que a requisição não execute essa política
+	status, body := evaluate()
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, body, "função desconhecida 'CPF_VALIDO'")
+
+	require.NoError(t, srv.Engine.Functions.Register("CPF_VALIDO", rules.Signature{Args: []string{rules.TypeString}, Returns: rules.TypeBoolean},
+		func(_ *rules.Context, args []interface{}) (interface{}, error) {
+			return len(args[0].(string)) == 11, nil
+		}))
+	status, body = evaluate()
+	assert.Equal(t, http.StatusOK, status, body)
+
+	// Um registro inválido para as políticas volta a recusar as requisições
+	require.NoError(t, srv.Engine.Functions.Register("DOBRO", rules.Signature{Args: []string{rules.TypeNumber}, Returns: rules.TypeNumber},
+		func(_ *rules.Context, args []interface{}) (interface{}, error) { return args[0].(float64) * 2, nil }))
+	srv.Engine.Policies["Dobrar"] = policy.PolicyDefinition{Name: "Dobrar", Rules: []string{`DOBRO("dois") == 4`}}
+	status, body = evaluate()
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, body, "DOBRO")
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- newTestServer(Config{Addr: addr, ShutdownTimeout: time.Second}).ListenAndServe(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		res, err := http.Get("http://" + addr + "/healthz")
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	}, 2*time.Second, 20*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("servidor não encerrou após o cancelamento do contexto")
+	}
+}
@@ -6,8 +6,6 @@ import (
 
 	"github.com/raywall/cloud-policy-serializer/pkg/json/schema"
 	"github.com/raywall/cloud-policy-serializer/pkg/policy"
-
-	"gopkg.in/yaml.v3"
 )
 
 type FilePath string
@@ -32,18 +30,18 @@ func (fp *FilePath) GetPolicies() (*map[string]policy.PolicyDefinition, error) {
 		return nil, err
 	}
 
-	itens := make(map[string][]string, 0)
-	err = yaml.Unmarshal(fileContent, &itens)
+	data, err := policy.Parse(fileContent)
 	if err != nil {
 		return nil, err
 	}
+	return &data, nil
+}
 
-	data := make(map[string]policy.PolicyDefinition, 0)
-	for key, value := range itens {
-		data[key] = policy.PolicyDefinition{
-			Name:  key,
-			Rules: value,
-		}
+// GetPolicyCatalog lê o arquivo de políticas incluindo os conjuntos nomeados ("sets").
+func (fp *FilePath) GetPolicyCatalog() (*policy.Catalog, error) {
+	fileContent, err := ioutil.ReadFile(string(*fp))
+	if err != nil {
+		return nil, err
 	}
-	return &data, nil
+	return policy.ParseCatalog(fileContent)
 }